@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"sort"
 	"text/template"
 
 	"github.com/Bitspark/slang/pkg/core"
@@ -35,6 +36,13 @@ type OperatorDefinition struct {
 	JSON string
 }
 
+// ServiceInfo describes the ports of a service in the short type notation, e.g. "map{a: number?, b?: string}"
+type ServiceInfo struct {
+	Name string
+	In   string
+	Out  string
+}
+
 type OperatorUsage struct {
 	Count int
 	Info  *OperatorInfo
@@ -59,6 +67,7 @@ type OperatorInfo struct {
 	Slug                string
 	Tags                []*TagInfo
 	OperatorDefinitions []OperatorDefinition
+	Services            []ServiceInfo
 	Tests               []TestCase
 
 	OperatorContentCount int
@@ -312,6 +321,12 @@ func (dg *DocGenerator) collect(strict bool) {
 			})
 		}
 
+		opServices := []ServiceInfo{}
+		for _, srvName := range sortedServiceNames(blueprint) {
+			srv := blueprint.ServiceDefs[srvName]
+			opServices = append(opServices, ServiceInfo{srvName, srv.In.String(), srv.Out.String()})
+		}
+
 		*opInfo = OperatorInfo{
 			ID:                  id,
 			Name:                blueprint.Meta.Name,
@@ -323,6 +338,7 @@ func (dg *DocGenerator) collect(strict bool) {
 			Tags:                opTags,
 			Tests:               opTests,
 			OperatorDefinitions: opJSONDefs,
+			Services:            opServices,
 			operatorDefinition:  blueprint,
 			operatorContent:     make(map[uuid.UUID]*OperatorUsage),
 			operatorsUsing:      make(map[uuid.UUID]*OperatorUsage),
@@ -568,3 +584,19 @@ func dumpDefinitions(id uuid.UUID, store *storage.Storage) map[uuid.UUID]Operato
 
 	return defs
 }
+
+// sortedServiceNames returns the names of the services of blueprint with the main service first
+func sortedServiceNames(blueprint *core.Blueprint) []string {
+	names := make([]string, 0, len(blueprint.ServiceDefs))
+	for name := range blueprint.ServiceDefs {
+		if name != core.MAIN_SERVICE {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if _, ok := blueprint.ServiceDefs[core.MAIN_SERVICE]; ok {
+		names = append([]string{core.MAIN_SERVICE}, names...)
+	}
+	return names
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Bitspark/slang/pkg/core"
+)
+
+// breakpointFlags collects the breakpoints given with -break, each of the form "PORT" or "PORT if CONDITION"
+type breakpointFlags []string
+
+func (b *breakpointFlags) String() string {
+	return strings.Join(*b, ", ")
+}
+
+func (b *breakpointFlags) Set(value string) error {
+	*b = append(*b, value)
+	return nil
+}
+
+// parseBreakpoint splits "PORT if CONDITION" into port reference and condition
+func parseBreakpoint(s string) (string, string) {
+	if i := strings.Index(s, " if "); i >= 0 {
+		return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+4:])
+	}
+	return strings.TrimSpace(s), ""
+}
+
+// startDebugger attaches a debugger with the given breakpoints to the operator. It is controlled from the terminal
+// as standard input carries the data of the operator.
+func startDebugger(operator *core.Operator, breakpoints []string) error {
+	d := core.NewDebugger()
+	operator.SetDebugger(d)
+
+	for _, b := range breakpoints {
+		ref, cond := parseBreakpoint(b)
+		if _, err := d.SetBreakpoint(ref, cond); err != nil {
+			return err
+		}
+	}
+
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return fmt.Errorf("debugger needs a terminal: %s", err)
+	}
+
+	d.OnPause(func(pause core.Pause) {
+		printPause(os.Stderr, pause)
+	})
+	go debugConsole(d, tty, os.Stderr)
+	return nil
+}
+
+func printPause(w io.Writer, pause core.Pause) {
+	item, _ := json.Marshal(core.PlainItem(pause.Item))
+	if pause.Breakpoint != 0 {
+		fmt.Fprintf(w, "paused at %s (breakpoint %d): %s\n", pause.Port, pause.Breakpoint, item)
+	} else {
+		fmt.Fprintf(w, "paused at %s: %s\n", pause.Port, item)
+	}
+}
+
+// debugConsole reads debugger commands from r until it is closed
+func debugConsole(d *core.Debugger, r io.Reader, w io.Writer) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if err := debugCommand(d, strings.TrimSpace(scanner.Text()), w); err != nil {
+			fmt.Fprintln(w, "error:", err)
+		}
+	}
+}
+
+func debugCommand(d *core.Debugger, line string, w io.Writer) error {
+	cmd, arg := line, ""
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		cmd, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch cmd {
+	case "":
+		return nil
+	case "c", "continue":
+		return d.Continue()
+	case "s", "step":
+		d.Step()
+	case "p", "print":
+		pause, ok := d.Paused()
+		if !ok {
+			fmt.Fprintln(w, "running")
+			return nil
+		}
+		printPause(w, pause)
+	case "set":
+		var item interface{}
+		if err := json.Unmarshal([]byte(arg), &item); err != nil {
+			return err
+		}
+		return d.SetItem(item)
+	case "b", "break":
+		bp, err := d.SetBreakpoint(parseBreakpoint(arg))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "breakpoint %d at %s\n", bp.Id, bp.Port)
+	case "d", "delete":
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return err
+		}
+		return d.RemoveBreakpoint(id)
+	case "l", "list":
+		for _, bp := range d.Breakpoints() {
+			if bp.Condition != "" {
+				fmt.Fprintf(w, "%d: %s if %s\n", bp.Id, bp.Port, bp.Condition)
+			} else {
+				fmt.Fprintf(w, "%d: %s\n", bp.Id, bp.Port)
+			}
+		}
+	default:
+		fmt.Fprintln(w, "commands: continue, step, print, set ITEM, break PORT [if CONDITION], delete ID, list")
+	}
+	return nil
+}
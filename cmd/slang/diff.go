@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// runDiff prints the semantic differences between two blueprint files. Besides "slang diff OLD NEW" it accepts the
+// seven arguments git passes to external diff drivers. It returns the exit code.
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print changes as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "slang diff OPTIONS OLD NEW")
+		fmt.Fprintln(flags.Output(), "slang diff OPTIONS PATH OLD OLD-HEX OLD-MODE NEW NEW-HEX NEW-MODE (git diff driver)")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var name, oldPath, newPath string
+	switch flags.NArg() {
+	case 2:
+		oldPath, newPath = flags.Arg(0), flags.Arg(1)
+		name = newPath
+	case 7:
+		name, oldPath, newPath = flags.Arg(0), flags.Arg(1), flags.Arg(4)
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	oldBp, _, err := readBlueprintFile(oldPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	newBp, _, err := readBlueprintFile(newPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	changes, err := core.DiffBlueprints(oldBp, newBp)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(changes)
+		return 0
+	}
+
+	if len(changes) != 0 {
+		fmt.Printf("blueprint %s\n", name)
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return 0
+}
+
+// runMerge merges two blueprint files with their common ancestor and writes the result to OURS, so that it can be
+// used as git merge driver "slang merge %O %A %B". As git passes temporary files without extension, the result is
+// written in the format of OURS. It returns 1 if there are conflicts.
+func runMerge(args []string) int {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	out := flags.String("o", "", "File the merged blueprint is written to (defaults to OURS)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "slang merge OPTIONS BASE OURS THEIRS")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 3 {
+		flags.Usage()
+		return 2
+	}
+
+	var bps [3]core.Blueprint
+	var asJSON bool
+	for i := range bps {
+		bp, isJSON, err := readBlueprintFile(flags.Arg(i))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		bps[i] = bp
+		if i == 1 {
+			asJSON = isJSON
+		}
+	}
+
+	merged, conflicts, err := core.MergeBlueprints(bps[0], bps[1], bps[2])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *out == "" {
+		*out = flags.Arg(1)
+	} else if utils.IsJSON(*out) || utils.IsYAML(*out) {
+		asJSON = utils.IsJSON(*out)
+	}
+	if err := writeBlueprintFile(*out, merged, asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	for _, c := range conflicts {
+		fmt.Fprintln(os.Stderr, "conflict:", c)
+	}
+	if len(conflicts) != 0 {
+		return 1
+	}
+	return 0
+}
+
+// readBlueprintFile reads a blueprint from a YAML or JSON file and returns whether it is JSON. Files without .json,
+// .yaml or .yml extension are JSON if their content looks like it. Empty files such as /dev/null yield an empty
+// blueprint.
+func readBlueprintFile(path string) (core.Blueprint, bool, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return core.Blueprint{}, false, err
+	}
+	isJSON := utils.IsJSON(path)
+	if !isJSON && !utils.IsYAML(path) {
+		isJSON = bytes.HasPrefix(bytes.TrimSpace(b), []byte("{"))
+	}
+	if len(b) == 0 {
+		return core.Blueprint{}, isJSON, nil
+	}
+
+	var bp core.Blueprint
+	if isJSON {
+		bp, err = core.ParseJSONOperatorDef(string(b))
+	} else {
+		bp, err = core.ParseYAMLOperatorDef(string(b))
+	}
+	if err != nil {
+		return core.Blueprint{}, isJSON, fmt.Errorf("%s: %s", path, err)
+	}
+	return bp, isJSON, nil
+}
+
+func writeBlueprintFile(path string, bp core.Blueprint, asJSON bool) error {
+	var data []byte
+	var err error
+	if asJSON {
+		data, err = json.MarshalIndent(&bp, "", "  ")
+	} else {
+		data, err = yaml.Marshal(&bp)
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, os.ModePerm)
+}
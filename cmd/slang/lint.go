@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Bitspark/slang/pkg/api"
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/Bitspark/slang/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// runLint checks a blueprint and its dependencies and prints all problems found. It returns the exit code, which is
+// only set for problems other than warnings.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	workspace := flags.String("workspace", "", "Directory containing the blueprints (defaults to the directory of BLUEPRINT if it is a file)")
+	lib := flags.String("lib", "", "Directory containing shared blueprints")
+	gensJSON := flags.String("generics", "", "Generics of the blueprint as JSON")
+	propsJSON := flags.String("properties", "", "Properties of the blueprint as JSON")
+	asJSON := flags.Bool("json", false, "Print problems as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "slang lint OPTIONS BLUEPRINT")
+		fmt.Fprintln(flags.Output(), "BLUEPRINT is either the id of a blueprint or the path of a blueprint file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	bpid, dir, err := lintTarget(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *workspace == "" {
+		*workspace = dir
+	}
+
+	var gens core.Generics
+	var props core.Properties
+	if *gensJSON != "" {
+		if err := json.Unmarshal([]byte(*gensJSON), &gens); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid generics:", err)
+			return 2
+		}
+	}
+	if *propsJSON != "" {
+		if err := json.Unmarshal([]byte(*propsJSON), &props); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid properties:", err)
+			return 2
+		}
+	}
+
+	elem.SafeMode = false
+	elem.Init()
+
+	st := storage.NewStorage().AddBackend(storage.NewReadOnlyFileSystem(*workspace))
+	if *lib != "" {
+		st.AddBackend(storage.NewReadOnlyFileSystem(*lib))
+	}
+
+	problems, err := api.Lint(bpid, gens, props, *st)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(problems)
+	} else {
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+	}
+
+	for _, p := range problems {
+		if !p.Warning {
+			return 1
+		}
+	}
+	return 0
+}
+
+// lintTarget returns the blueprint id and the directory it should be looked up in for target, which is either the
+// id of a blueprint or the path of a blueprint file.
+func lintTarget(target string) (uuid.UUID, string, error) {
+	if id, err := uuid.Parse(target); err == nil {
+		return id, ".", nil
+	}
+
+	bp, _, err := readBlueprintFile(target)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return bp.Id, filepath.Dir(target), nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -22,21 +23,72 @@ import (
 	"github.com/thoas/go-funk"
 )
 
-var SupportedRunModes = []string{"process", "httpPost"}
+var SupportedRunModes = []string{"process", "httpPost", "worker"}
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "merge":
+			os.Exit(runMerge(os.Args[2:]))
+		}
+	}
+
 	runMode := flag.String("mode", SupportedRunModes[0], fmt.Sprintf("Choose run mode for operator: %s", SupportedRunModes))
 	bind := flag.String("bind", "localhost:0", "To which address httpPost should bind")
+	clusterAddr := flag.String("cluster", "", "Address to listen on for workers running remote instances")
+	commander := flag.String("commander", "", "Address of the commander a worker should connect to")
+	workerName := flag.String("name", "", "Name of the worker remote instances refer to")
+	bufferSize := flag.Int("buffer-size", core.DefaultRuntimeConfig().BufferSize, "Capacity of port buffers without declared size")
+	bufferPolicy := flag.String("buffer-policy", string(core.BUFFER_POLICY_BLOCK), "Policy of full port buffers without declared policy: block, drop-oldest, drop-newest or spill")
+	spillDir := flag.String("spill-dir", "", "Directory spilling port buffers write to, defaults to the temp directory")
+	var breakpoints breakpointFlags
+	flag.Var(&breakpoints, "break", `Pause items arriving at a port, either "PORT" or "PORT if CONDITION", can be repeated. The debugger is controlled from the terminal`)
+	record := flag.String("record", "", "Record all items entering and leaving the operator to this ndjson file")
+	recordDelegates := flag.String("record-delegates", "", "Comma separated names of delegates to record as well")
+	replay := flag.String("replay", "", "Feed the recording in this ndjson file into the operator and print the outputs differing from the recorded ones")
+	replayTimeout := flag.Duration("replay-timeout", 5*time.Second, "Time to wait for each recorded output during replays")
 	help := flag.Bool("h", false, "Show help")
 	flag.Parse()
 
 	if *help {
 		fmt.Println("slang OPTIONS SLANG_BUNDLE")
+		fmt.Println("slang lint OPTIONS BLUEPRINT")
+		fmt.Println("slang diff OPTIONS OLD NEW")
+		fmt.Println("slang merge OPTIONS BASE OURS THEIRS")
 		flag.PrintDefaults()
 	}
 
 	// Check cmd args
 
+	if err := core.SetRuntimeConfig(core.RuntimeConfig{
+		BufferSize:   *bufferSize,
+		BufferPolicy: core.BufferPolicy(*bufferPolicy),
+		SpillDir:     *spillDir,
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	// Workers receive their blueprints from the commander
+	if *runMode == "worker" {
+		if *commander == "" || *workerName == "" {
+			log.Fatal("worker mode requires -commander and -name")
+		}
+
+		elem.SafeMode = false
+		elem.Init()
+
+		host, _, err := net.SplitHostPort(*bind)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Fatal(api.NewWorker(*commander).Begin(api.NewWorkerCommands(*workerName, host)))
+	}
+
 	// Expect slang file as 1st arg
 	slangBundlePath := flag.Arg(0)
 	if slangBundlePath == "" {
@@ -66,6 +118,36 @@ func main() {
 
 	log.SetBlueprint(operator.Id(), operator.Name())
 
+	if len(breakpoints) > 0 {
+		if err := startDebugger(operator, breakpoints); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *replay != "" {
+		os.Exit(runReplay(operator, *replay, *replayTimeout))
+	}
+
+	if *record != "" {
+		stopRecording, err := startRecording(operator, *record, *recordDelegates)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := stopRecording(); err != nil {
+				log.Error(err)
+			}
+		}()
+	}
+
+	if *clusterAddr != "" {
+		cl, err := api.ListenForWorkers(*clusterAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("listening for workers on %s", cl.Addr())
+	}
+
 	// Run
 	if err := run(operator, *runMode, *bind); err != nil {
 		log.Fatal(err)
@@ -165,7 +247,7 @@ func runProcess(operator *core.Operator) {
 	loop:
 		for !stopped {
 			jval = <-outgoing
-			if err := jenco.Encode(jval); err != nil {
+			if err := jenco.Encode(core.PlainItem(jval)); err != nil {
 				log.Error("json encode error: ", err)
 				break loop
 			}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+)
+
+// startRecording records the items entering and leaving operator to the ndjson file at path
+func startRecording(operator *core.Operator, path string, delegates string) (func() error, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if delegates != "" {
+		names = strings.Split(delegates, ",")
+	}
+
+	rec, err := core.NewRecorder(operator, f, names)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() error {
+		err := rec.Close()
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}, nil
+}
+
+// runReplay feeds the recording at path into operator and prints all outputs differing from the recorded ones as
+// ndjson. It returns the exit code, which is 1 if there are differences.
+func runReplay(operator *core.Operator, path string, timeout time.Duration) int {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer f.Close()
+
+	operator.Start()
+	defer operator.Stop()
+
+	res, err := core.Replay(operator, f, timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, diff := range res.Diffs {
+		enc.Encode(diff)
+	}
+	fmt.Fprintf(os.Stderr, "replayed %d inputs, %d of %d outputs differ\n", res.Inputs, len(res.Diffs), res.Outputs)
+
+	if len(res.Diffs) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/pkg/elem"
 	"github.com/Bitspark/slang/pkg/env"
 	"github.com/Bitspark/slang/pkg/storage"
@@ -17,6 +18,7 @@ import (
 	"strconv"
 
 	"os"
+	"path/filepath"
 
 	"github.com/Bitspark/browser"
 	"github.com/Bitspark/slang/pkg/daemon"
@@ -36,6 +38,12 @@ var skipChecks bool
 var withoutUI bool
 var safeMode bool
 var credentials string
+var bufferSize int
+var bufferPolicy string
+var spillDir string
+var storageBackend string
+var sqlitePath string
+var gitAuthor string
 
 func main() {
 	flag.BoolVar(&safeMode, "safe", false, "Only support safe operator. Unsafe operators are handled as not existing.")
@@ -43,12 +51,26 @@ func main() {
 	flag.BoolVar(&skipChecks, "skip-checks", false, "Skip checking and updating UI and Lib")
 	flag.BoolVar(&withoutUI, "without-ui", false, "Do not serve the UI found in SLANG_UI")
 	flag.StringVar(&credentials, "basic-auth", "", "Set basic auth for daemon username:password")
+	flag.IntVar(&bufferSize, "buffer-size", core.DefaultRuntimeConfig().BufferSize, "Capacity of port buffers without declared size")
+	flag.StringVar(&bufferPolicy, "buffer-policy", string(core.BUFFER_POLICY_BLOCK), "Policy of full port buffers without declared policy: block, drop-oldest, drop-newest or spill")
+	flag.StringVar(&spillDir, "spill-dir", "", "Directory spilling port buffers write to, defaults to the temp directory")
+	flag.StringVar(&storageBackend, "storage", "fs", "Backend storing your blueprints: fs, sqlite or git")
+	flag.StringVar(&sqlitePath, "sqlite-db", "", "Database file of the sqlite storage, defaults to blueprints.db in SLANG_WORKSPACE")
+	flag.StringVar(&gitAuthor, "git-author", storage.DefaultGitAuthor, "Author of commits of the git storage for which no author is given")
 	flag.Parse()
 
 	if funk.NotEmpty(credentials) && !strings.ContainsRune(credentials, ':') {
 		log.Fatalf("\n\n\t%v\n\n", "Invalid format for credentials. Must be username:password")
 	}
 
+	if err := core.SetRuntimeConfig(core.RuntimeConfig{
+		BufferSize:   bufferSize,
+		BufferPolicy: core.BufferPolicy(bufferPolicy),
+		SpillDir:     spillDir,
+	}); err != nil {
+		log.Fatal(err)
+	}
+
 	// init elementary operators in proper mode (safe mode oder unsafe mode)
 	elem.SafeMode = safeMode
 	elem.Init()
@@ -69,11 +91,17 @@ func main() {
 		loadLocalComponents(env)
 	}
 
+	workspace, workspaceLocation := newWorkspaceBackend(env)
 	st := storage.NewStorage().
-		AddBackend(storage.NewWritableFileSystem(env.SLANG_WORKSPACE)).
+		AddBackend(workspace).
 		AddBackend(storage.NewReadOnlyFileSystem(env.SLANG_LIB))
 
-	fmt.Println("\tYour   blueprints:", env.SLANG_WORKSPACE)
+	// Keep up with blueprints edited outside of slangd
+	if err := st.Watch(); err != nil {
+		log.Printf("Cannot watch blueprints: %s", err)
+	}
+
+	fmt.Println("\tYour   blueprints:", workspaceLocation)
 	fmt.Println("\tShared blueprints:", env.SLANG_LIB)
 
 	ctx := daemon.SetStorage(context.Background(), st)
@@ -87,6 +115,33 @@ func main() {
 	startDaemonServer(srv)
 }
 
+// newWorkspaceBackend returns the backend storing the blueprints of the user as selected by the storage flag and where
+// it stores them
+func newWorkspaceBackend(e *env.Environment) (storage.WriteableBackend, string) {
+	switch storageBackend {
+	case "fs":
+		return storage.NewWritableFileSystem(e.SLANG_WORKSPACE), e.SLANG_WORKSPACE
+	case "sqlite":
+		path := sqlitePath
+		if path == "" {
+			path = filepath.Join(e.SLANG_WORKSPACE, "blueprints.db")
+		}
+		db, err := newSQLiteBackend(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return db, path
+	case "git":
+		repo, err := storage.NewGit(e.SLANG_WORKSPACE, gitAuthor)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return repo, e.SLANG_WORKSPACE
+	}
+	log.Fatalf("unknown storage: %s", storageBackend)
+	return nil, ""
+}
+
 func newBasicAuth(cred string) *daemon.BasicAuth {
 	s := strings.Split(cred, ":")
 
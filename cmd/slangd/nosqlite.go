@@ -0,0 +1,14 @@
+//go:build !sqlite
+
+package main
+
+import (
+	"errors"
+
+	"github.com/Bitspark/slang/pkg/storage"
+)
+
+// The SQLite storage needs cgo, so it is only built with the sqlite build tag
+func newSQLiteBackend(path string) (storage.WriteableBackend, error) {
+	return nil, errors.New("slangd has been built without the sqlite storage, build it with -tags sqlite")
+}
@@ -0,0 +1,13 @@
+//go:build sqlite
+
+package main
+
+import "github.com/Bitspark/slang/pkg/storage"
+
+func newSQLiteBackend(path string) (storage.WriteableBackend, error) {
+	db, err := storage.NewSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
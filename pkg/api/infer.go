@@ -0,0 +1,385 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/storage"
+)
+
+// genericInference infers the generics missing in the instances of a blueprint from the types of the ports they
+// are connected to
+type genericInference struct {
+	blueprint *core.Blueprint
+	// views are the blueprints of the instances with all given generics and properties applied
+	views map[string]*core.Blueprint
+	// inferable holds the generic identifiers of each instance which have not been given
+	inferable map[string]map[string]bool
+	inferred  map[string]core.Generics
+}
+
+// genericCandidate is a type a generic could be specified with. Strong candidates stem from ports feeding the
+// generic port, weak candidates from ports the generic port is connected to.
+type genericCandidate struct {
+	typeDef core.TypeDef
+	strong  bool
+	port    string
+}
+
+// inferGenerics infers the generics of the instances of blueprint which are not given in their instance definitions.
+// The types of connected ports are unified until nothing more can be inferred. Generics for which conflicting types
+// are found or which are only connected to other generics cause an error. Instances whose blueprints have not been
+// loaded yet are loaded from st. props are the properties of blueprint the properties of the instances refer to.
+func inferGenerics(blueprint *core.Blueprint, props core.Properties, st storage.Storage) error {
+	gi := &genericInference{
+		blueprint: blueprint,
+		views:     make(map[string]*core.Blueprint),
+		inferable: make(map[string]map[string]bool),
+		inferred:  make(map[string]core.Generics),
+	}
+
+	for _, insDef := range blueprint.InstanceDefs {
+		gi.addInstance(insDef, props, st)
+	}
+	if len(gi.inferable) == 0 {
+		return nil
+	}
+
+	for {
+		cands, undecided := gi.collect()
+		progress, err := gi.decide(cands)
+		if err != nil {
+			return err
+		}
+		if progress {
+			continue
+		}
+
+		// Generics only connected to other generics cannot be inferred
+		if len(undecided) > 0 {
+			ins, identifier := splitGenericKey(undecided[0])
+			return fmt.Errorf(`instance "%s": generic "%s" is ambiguous as it is only connected to generics, specify it explicitly`, ins, identifier)
+		}
+		break
+	}
+
+	for _, insDef := range blueprint.InstanceDefs {
+		gens, ok := gi.inferred[insDef.Name]
+		if !ok {
+			continue
+		}
+		if insDef.Generics == nil {
+			insDef.Generics = make(core.Generics)
+		}
+		for identifier, td := range gens {
+			insDef.Generics[identifier] = td
+		}
+	}
+
+	return nil
+}
+
+// addInstance prepares the view on the blueprint of insDef. Instances whose blueprints cannot be specified are left
+// to the build to report.
+func (gi *genericInference) addInstance(insDef *core.InstanceDef, parentProps core.Properties, st storage.Storage) {
+	bp := insDef.Blueprint
+	if bp.Id != insDef.Operator {
+		loaded, err := st.LoadVersion(insDef.Operator, insDef.Version)
+		if err != nil {
+			return
+		}
+		bp = *loaded
+	}
+
+	view := bp.Copy(false)
+	if err := view.ResolveTypes(st.Load); err != nil {
+		return
+	}
+
+	// Generics given in terms of other generics are not inferred but left alone
+	given := make(core.Generics)
+	for identifier, td := range insDef.Generics {
+		if td != nil && td.GenericsSpecified() == nil {
+			given[identifier] = td
+		}
+	}
+
+	insProps := make(core.Properties)
+	for prop, propVal := range insDef.Properties {
+		if _, val, err := interpolatePropVal(propVal, parentProps); err == nil {
+			propVal = val
+		}
+		insProps[prop] = propVal
+	}
+
+	props, err := completeProperties(&view, insProps)
+	if err != nil {
+		return
+	}
+	if err := view.SpecifyOperator(given, props); err != nil {
+		return
+	}
+
+	inferable := make(map[string]bool)
+	collectGenerics := func(td core.TypeDef) {
+		walkTypeDef(td, func(sub core.TypeDef) {
+			if sub.Type == "generic" {
+				if _, ok := insDef.Generics[sub.Generic]; !ok {
+					inferable[sub.Generic] = true
+				}
+			}
+		})
+	}
+	for _, srv := range view.ServiceDefs {
+		collectGenerics(srv.In)
+		collectGenerics(srv.Out)
+	}
+	for _, del := range view.DelegateDefs {
+		collectGenerics(del.In)
+		collectGenerics(del.Out)
+	}
+
+	gi.views[insDef.Name] = &view
+	if len(inferable) > 0 {
+		gi.inferable[insDef.Name] = inferable
+	}
+}
+
+// collect unifies the types of all connections and returns the candidates found for the generics not inferred yet
+// as well as the generics which have been connected to other generics only
+func (gi *genericInference) collect() (map[string][]genericCandidate, []string) {
+	cands := make(map[string][]genericCandidate)
+	connectedToGeneric := make(map[string]bool)
+
+	srcRefs := make([]string, 0, len(gi.blueprint.Connections))
+	for srcRef := range gi.blueprint.Connections {
+		srcRefs = append(srcRefs, srcRef)
+	}
+	sort.Strings(srcRefs)
+
+	for _, srcRef := range srcRefs {
+		srcIns, srcType := gi.portType(srcRef)
+		if srcType == nil {
+			continue
+		}
+		for _, dstRef := range gi.blueprint.Connections[srcRef] {
+			dstIns, dstType := gi.portType(dstRef)
+			if dstType == nil {
+				continue
+			}
+			gi.unify(*srcType, srcIns, srcRef, *dstType, dstIns, dstRef, cands, connectedToGeneric)
+		}
+	}
+
+	undecided := make([]string, 0)
+	for key := range connectedToGeneric {
+		if _, ok := cands[key]; !ok {
+			undecided = append(undecided, key)
+		}
+	}
+	sort.Strings(undecided)
+
+	return cands, undecided
+}
+
+// unify walks the types of the source and destination port of a connection in parallel and records candidates for
+// the generics not inferred yet
+func (gi *genericInference) unify(src core.TypeDef, srcIns string, srcRef string, dst core.TypeDef, dstIns string, dstRef string, cands map[string][]genericCandidate, connectedToGeneric map[string]bool) {
+	srcKey, srcInferable := gi.inferableKey(src, srcIns)
+	dstKey, dstInferable := gi.inferableKey(dst, dstIns)
+
+	if srcInferable || dstInferable {
+		if src.Type == "generic" && dst.Type == "generic" {
+			if srcInferable {
+				connectedToGeneric[srcKey] = true
+			}
+			if dstInferable {
+				connectedToGeneric[dstKey] = true
+			}
+			return
+		}
+		if dstInferable && src.GenericsSpecified() == nil {
+			cands[dstKey] = append(cands[dstKey], genericCandidate{candidateType(src, dst), true, srcRef})
+		}
+		if srcInferable && dst.GenericsSpecified() == nil && dst.Type != "trigger" {
+			cands[srcKey] = append(cands[srcKey], genericCandidate{candidateType(dst, src), false, dstRef})
+		}
+		return
+	}
+
+	if src.Type == "stream" && dst.Type == "stream" {
+		gi.unify(*src.Stream, srcIns, srcRef, *dst.Stream, dstIns, dstRef, cands, connectedToGeneric)
+	} else if src.Type == "map" && dst.Type == "map" {
+		for name, srcEntry := range src.Map {
+			if dstEntry, ok := dst.Map[name]; ok {
+				gi.unify(*srcEntry, srcIns, srcRef, *dstEntry, dstIns, dstRef, cands, connectedToGeneric)
+			}
+		}
+	}
+}
+
+// inferableKey returns the key of td if it is a generic of instance ins which has yet to be inferred
+func (gi *genericInference) inferableKey(td core.TypeDef, ins string) (string, bool) {
+	if td.Type != "generic" || !gi.inferable[ins][td.Generic] {
+		return "", false
+	}
+	if _, ok := gi.inferred[ins][td.Generic]; ok {
+		return "", false
+	}
+	return ins + "#" + td.Generic, true
+}
+
+// candidateType returns the type a generic port gen is specified with when connected to a port of type td
+func candidateType(td core.TypeDef, gen core.TypeDef) core.TypeDef {
+	cand := td.Copy()
+	cand.Optional = false
+	if gen.Nullable {
+		cand.Nullable = false
+	}
+	return cand
+}
+
+// decide infers the generics with consistent candidates. It returns true if at least one generic has been inferred.
+func (gi *genericInference) decide(cands map[string][]genericCandidate) (bool, error) {
+	keys := make([]string, 0, len(cands))
+	for key := range cands {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	progress := false
+	for _, key := range keys {
+		ins, identifier := splitGenericKey(key)
+
+		// Ports feeding the generic port decide. Otherwise the ports it is connected to decide, where primitive
+		// ports accept anything specific.
+		var strong, weak, primitive []genericCandidate
+		for _, c := range cands[key] {
+			if c.strong {
+				strong = append(strong, c)
+			} else if c.typeDef.Type == "primitive" {
+				primitive = append(primitive, c)
+			} else {
+				weak = append(weak, c)
+			}
+		}
+
+		decisive := strong
+		if len(decisive) == 0 {
+			decisive = weak
+		}
+		if len(decisive) == 0 {
+			decisive = primitive
+		}
+		for _, c := range decisive[1:] {
+			if !c.typeDef.Equals(decisive[0].typeDef) {
+				return false, fmt.Errorf(`instance "%s": conflicting types inferred for generic "%s": %s from %s and %s from %s`, ins, identifier, decisive[0].typeDef, decisive[0].port, c.typeDef, c.port)
+			}
+		}
+
+		td := decisive[0].typeDef
+		if gi.inferred[ins] == nil {
+			gi.inferred[ins] = make(core.Generics)
+		}
+		gi.inferred[ins][identifier] = &td
+		progress = true
+	}
+
+	return progress, nil
+}
+
+// portType returns the instance the port referenced by ref belongs to and its type with all generics inferred so far
+// applied. The type is nil if it cannot be determined.
+func (gi *genericInference) portType(ref string) (string, *core.TypeDef) {
+	in := strings.Contains(ref, "(")
+	sep := ")"
+	if in {
+		sep = "("
+	}
+	refSplit := strings.Split(ref, sep)
+	if len(refSplit) != 2 {
+		return "", nil
+	}
+	opPart, portPart := refSplit[0], refSplit[1]
+	if in {
+		opPart, portPart = refSplit[1], refSplit[0]
+	}
+
+	ins, srvName, delName := opPart, core.MAIN_SERVICE, ""
+	if i := strings.Index(opPart, "."); i >= 0 {
+		ins, srvName, delName = opPart[:i], "", opPart[i+1:]
+	} else if i := strings.Index(opPart, "@"); i >= 0 {
+		ins, srvName = opPart[i+1:], opPart[:i]
+	}
+
+	bp := gi.blueprint
+	if ins != "" {
+		if bp = gi.views[ins]; bp == nil {
+			return "", nil
+		}
+	}
+
+	var td core.TypeDef
+	if delName != "" {
+		del, ok := bp.DelegateDefs[delName]
+		if !ok {
+			return "", nil
+		}
+		td = del.Out
+		if in {
+			td = del.In
+		}
+	} else {
+		srv, ok := bp.ServiceDefs[srvName]
+		if !ok {
+			return "", nil
+		}
+		td = srv.Out
+		if in {
+			td = srv.In
+		}
+	}
+
+	td = td.Copy()
+	if gens, ok := gi.inferred[ins]; ok && ins != "" {
+		td.SpecifyGenerics(gens)
+	}
+
+	if portPart != "" {
+		for _, seg := range strings.Split(portPart, ".") {
+			var sub *core.TypeDef
+			if seg == "~" && td.Type == "stream" {
+				sub = td.Stream
+			} else if td.Type == "map" {
+				sub = td.Map[seg]
+			}
+			if sub == nil {
+				return "", nil
+			}
+			td = *sub
+		}
+	}
+
+	return ins, &td
+}
+
+func splitGenericKey(key string) (string, string) {
+	i := strings.LastIndex(key, "#")
+	return key[:i], key[i+1:]
+}
+
+// walkTypeDef calls handle for td and all types nested in it
+func walkTypeDef(td core.TypeDef, handle func(td core.TypeDef)) {
+	handle(td)
+	if td.Stream != nil {
+		walkTypeDef(*td.Stream, handle)
+	}
+	for _, e := range td.Map {
+		walkTypeDef(*e, handle)
+	}
+	for _, v := range td.Union {
+		walkTypeDef(*v, handle)
+	}
+}
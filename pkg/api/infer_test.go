@@ -0,0 +1,193 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// inferTestBlueprints returns a blueprint passing items of generic type itemType through as well as a blueprint
+// picking entry a of a map whose entries a and b both are of type itemType
+func inferTestBlueprints() (core.Blueprint, core.Blueprint) {
+	pass := core.Blueprint{
+		Id:   uuid.New(),
+		Meta: core.BlueprintMetaDef{Name: "pass"},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "generic", Generic: "itemType"},
+				Out: core.TypeDef{Type: "generic", Generic: "itemType"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{},
+		Connections:  map[string][]string{"(": {")"}},
+	}
+	first := core.Blueprint{
+		Id:   uuid.New(),
+		Meta: core.BlueprintMetaDef{Name: "first"},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In: core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{
+					"a": {Type: "generic", Generic: "itemType"},
+					"b": {Type: "generic", Generic: "itemType"},
+				}},
+				Out: core.TypeDef{Type: "generic", Generic: "itemType"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{},
+		Connections:  map[string][]string{"a(": {")"}},
+	}
+	return pass, first
+}
+
+func TestInferGenerics__FromConnections(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	pass, _ := inferTestBlueprints()
+	outer := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{"value": {Type: "number"}}},
+				Out: core.TypeDef{Type: "number"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{Name: "pass1", Operator: pass.Id},
+			{Name: "pass2", Operator: pass.Id},
+		},
+		Connections: map[string][]string{
+			"value(": {"(pass1"},
+			"pass1)": {"(pass2"},
+			"pass2)": {")"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{outer, pass})
+
+	op, err := Build(outer.Id, nil, nil, *st)
+	require.NoError(t, err)
+	a.Equal(core.TYPE_NUMBER, op.Main().Out().Type())
+
+	op.Main().Out().Bufferize()
+	op.Start()
+	defer op.Stop()
+
+	op.Main().In().Push(map[string]interface{}{"value": 1.0})
+	a.Equal(1.0, op.Main().Out().Pull())
+}
+
+func TestInferGenerics__FromOutputs(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	pass, _ := inferTestBlueprints()
+	outer := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "primitive"},
+				Out: core.TypeDef{Type: "string"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{Name: "pass", Operator: pass.Id},
+		},
+		Connections: map[string][]string{
+			"pass)": {")"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{pass})
+
+	require.NoError(t, inferGenerics(&outer, nil, *st))
+	a.Equal(core.Generics{"itemType": {Type: "string"}}, outer.InstanceDefs[0].Generics)
+}
+
+func TestInferGenerics__ExplicitGenericsPrecede(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	pass, _ := inferTestBlueprints()
+	outer := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "number"},
+				Out: core.TypeDef{Type: "primitive"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{Name: "pass", Operator: pass.Id, Generics: core.Generics{"itemType": {Type: "primitive"}}},
+		},
+		Connections: map[string][]string{
+			"(":     {"(pass"},
+			"pass)": {")"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{pass})
+
+	require.NoError(t, inferGenerics(&outer, nil, *st))
+	a.Equal(core.Generics{"itemType": {Type: "primitive"}}, outer.InstanceDefs[0].Generics)
+}
+
+func TestInferGenerics__Conflict(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	_, first := inferTestBlueprints()
+	outer := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In: core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{
+					"x": {Type: "number"},
+					"y": {Type: "string"},
+				}},
+				Out: core.TypeDef{Type: "number"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{Name: "first", Operator: first.Id},
+		},
+		Connections: map[string][]string{
+			"x(":     {"a(first"},
+			"y(":     {"b(first"},
+			"first)": {")"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{outer, first})
+
+	_, err := Build(outer.Id, nil, nil, *st)
+	a.EqualError(err, `instance "first": conflicting types inferred for generic "itemType": number from x( and string from y(`)
+}
+
+func TestInferGenerics__Ambiguous(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	pass, _ := inferTestBlueprints()
+	outer := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "trigger"},
+				Out: core.TypeDef{Type: "trigger"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{Name: "pass1", Operator: pass.Id},
+			{Name: "pass2", Operator: pass.Id},
+		},
+		Connections: map[string][]string{
+			"pass1)": {"(pass2"},
+			"pass2)": {"(pass1"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{outer, pass})
+
+	_, err := Build(outer.Id, nil, nil, *st)
+	a.EqualError(err, `instance "pass1": generic "itemType" is ambiguous as it is only connected to generics, specify it explicitly`)
+}
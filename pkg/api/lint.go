@@ -0,0 +1,309 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/Bitspark/slang/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/thoas/go-funk"
+)
+
+// LintProblem describes a single problem found in a blueprint by Lint. Warnings do not keep the blueprint from
+// being built.
+type LintProblem struct {
+	Blueprint uuid.UUID `json:"blueprint"`
+	File      string    `json:"file,omitempty"`
+	Instance  string    `json:"instance,omitempty"`
+	Port      string    `json:"port,omitempty"`
+	Message   string    `json:"message"`
+	Warning   bool      `json:"warning,omitempty"`
+}
+
+func (p LintProblem) String() string {
+	var sb strings.Builder
+	if p.File != "" {
+		sb.WriteString(p.File)
+	} else {
+		sb.WriteString(p.Blueprint.String())
+	}
+	if p.Instance != "" {
+		sb.WriteString(fmt.Sprintf(`: instance "%s"`, p.Instance))
+	}
+	if p.Port != "" {
+		sb.WriteString(fmt.Sprintf(`: port "%s"`, p.Port))
+	}
+	sb.WriteString(": ")
+	if p.Warning {
+		sb.WriteString("warning: ")
+	}
+	sb.WriteString(p.Message)
+	return sb.String()
+}
+
+var lintRefSeparators = regexp.MustCompile(`[()~.@]`)
+
+type linter struct {
+	st       storage.Storage
+	problems []*LintProblem
+	reported map[string]bool
+}
+
+type lintConnection struct {
+	src, dst       *core.Port
+	srcRef, dstRef string
+}
+
+// Lint checks the blueprint with id bpid and all of its dependencies loaded from st like Build would, but without
+// stopping at the first problem. Type references, properties, generics, property expressions and the types of all
+// connections are checked. The returned error is only set if the blueprint itself cannot be loaded.
+func Lint(bpid uuid.UUID, gens core.Generics, props core.Properties, st storage.Storage) ([]*LintProblem, error) {
+	if !elem.Initalized {
+		return nil, fmt.Errorf("call elem.Init() before api.Lint()")
+	}
+
+	blueprint, err := st.Load(bpid)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{st, make([]*LintProblem, 0), make(map[string]bool)}
+	l.lint(blueprint, gens, props, []uuid.UUID{}, blueprint, "")
+
+	return l.problems, nil
+}
+
+func (l *linter) report(bp *core.Blueprint, insName string, port string, err error) {
+	l.add(&LintProblem{bp.Id, l.st.Location(bp.Id), insName, port, err.Error(), false})
+}
+
+func (l *linter) warn(bp *core.Blueprint, insName string, port string, err error) {
+	l.add(&LintProblem{bp.Id, l.st.Location(bp.Id), insName, port, err.Error(), true})
+}
+
+func (l *linter) add(p *LintProblem) {
+	if l.reported[p.String()] {
+		return
+	}
+	l.reported[p.String()] = true
+	l.problems = append(l.problems, p)
+}
+
+// lint creates the operator for blueprint, reporting problems in the instance insName of the parent blueprint.
+// It returns nil if the operator cannot be created.
+func (l *linter) lint(blueprint *core.Blueprint, gens core.Generics, props core.Properties, dependencyChain []uuid.UUID, parent *core.Blueprint, insName string) *core.Operator {
+	if err := blueprint.ResolveTypes(l.st.Load); err != nil {
+		l.report(blueprint, "", "", err)
+		return nil
+	}
+
+	if completedProps, err := completeProperties(blueprint, props); err == nil {
+		props = completedProps
+	} else {
+		l.report(parent, insName, "", err)
+	}
+
+	// Null is accepted for properties which are not nullable for compatibility, but most likely not intended
+	propKeys := make([]string, 0, len(blueprint.PropertyDefs))
+	for propKey := range blueprint.PropertyDefs {
+		propKeys = append(propKeys, propKey)
+	}
+	sort.Strings(propKeys)
+	for _, propKey := range propKeys {
+		propDef := blueprint.PropertyDefs[propKey]
+		if propVal, ok := props[propKey]; ok && !(propVal == nil && propDef.Optional) {
+			if err := propDef.VerifyNulls(propVal); err != nil {
+				l.warn(parent, insName, "", fmt.Errorf("property %s: %s", propKey, err))
+			}
+		}
+	}
+
+	if err := blueprint.SpecifyOperator(gens, props); err != nil {
+		l.report(parent, insName, "", err)
+		return nil
+	}
+
+	if err := blueprint.GenericsSpecified(); err != nil {
+		l.report(parent, insName, "", err)
+		return nil
+	}
+
+	if blueprint.Elementary != uuid.Nil {
+		o, err := elem.MakeOperator(core.InstanceDef{
+			Name:       insName,
+			Operator:   blueprint.Id,
+			Properties: props,
+			Generics:   gens,
+			Blueprint:  *blueprint,
+		})
+		if err != nil {
+			l.report(parent, insName, "", err)
+			return nil
+		}
+		return o
+	}
+
+	o, err := core.NewOperator(insName, nil, nil, nil, nil, *blueprint)
+	if err != nil {
+		l.report(parent, insName, "", err)
+		return nil
+	}
+
+	dependencyChain = append(dependencyChain, blueprint.Id)
+	failed := make(map[string]bool)
+
+	if err := inferGenerics(blueprint, props, l.st); err != nil {
+		l.report(blueprint, "", "", err)
+	}
+
+	for _, childInsDef := range blueprint.InstanceDefs {
+		if !l.lintInstance(o, blueprint, childInsDef, props, gens, dependencyChain) {
+			failed[childInsDef.Name] = true
+		}
+	}
+
+	l.lintConnections(o, blueprint, failed)
+
+	return o
+}
+
+// lintInstance creates the child operator for insDef and attaches it to o. It returns false if that failed.
+func (l *linter) lintInstance(o *core.Operator, blueprint *core.Blueprint, insDef *core.InstanceDef, props core.Properties, gens core.Generics, dependencyChain []uuid.UUID) bool {
+	childBlueprint, err := l.st.LoadVersion(insDef.Operator, insDef.Version)
+	if err != nil {
+		l.report(blueprint, insDef.Name, "", err)
+		return false
+	}
+
+	if funk.Contains(dependencyChain, insDef.Operator) {
+		l.report(blueprint, insDef.Name, "", fmt.Errorf("recursion in %s", blueprint.Id))
+		return false
+	}
+
+	childProps := make(core.Properties)
+	for prop, propVal := range insDef.Properties {
+		childProps[prop] = propVal
+
+		updated, newPropVal, err := interpolatePropVal(propVal, props)
+		if err != nil {
+			l.report(blueprint, insDef.Name, "", fmt.Errorf("property %s: %s", prop, err))
+			continue
+		}
+		if updated {
+			childProps[prop] = newPropVal
+		}
+	}
+
+	childGens := make(core.Generics)
+	for genId, gen := range insDef.Generics {
+		genCpy := gen.Copy()
+		genCpy.SpecifyGenerics(gens)
+		childGens[genId] = &genCpy
+	}
+
+	oc := l.lint(childBlueprint, childGens, childProps, dependencyChain, blueprint, insDef.Name)
+	if oc == nil {
+		return false
+	}
+
+	oc.SetParent(o)
+	return true
+}
+
+// lintConnections connects the ports of o and its children in the same order Build does and reports every
+// connection which cannot be established. Connections of instances which could not be created are skipped.
+func (l *linter) lintConnections(o *core.Operator, blueprint *core.Blueprint, failed map[string]bool) {
+	refersToFailed := func(ref string) bool {
+		for _, name := range lintRefSeparators.Split(ref, -1) {
+			if failed[name] {
+				return true
+			}
+		}
+		return false
+	}
+
+	srcRefs := make([]string, 0, len(blueprint.Connections))
+	for srcRef := range blueprint.Connections {
+		srcRefs = append(srcRefs, srcRef)
+	}
+	sort.Strings(srcRefs)
+
+	conns := make([]*lintConnection, 0)
+	for _, srcRef := range srcRefs {
+		if refersToFailed(srcRef) {
+			continue
+		}
+
+		pSrc, err := core.ParsePortReference(srcRef, o)
+		if err != nil {
+			l.report(blueprint, "", srcRef, err)
+			continue
+		}
+
+		for _, dstRef := range blueprint.Connections[srcRef] {
+			if refersToFailed(dstRef) {
+				continue
+			}
+
+			pDst, err := core.ParsePortReference(dstRef, o)
+			if err != nil {
+				l.report(blueprint, "", dstRef, err)
+				continue
+			}
+
+			conns = append(conns, &lintConnection{pSrc, pDst, srcRef, dstRef})
+		}
+	}
+
+	// Follow the connections starting at o just like connectDestinations
+	visited := map[*core.Operator]bool{o: true}
+	queue := []*core.Operator{o}
+	done := make(map[*lintConnection]bool)
+
+	for len(queue) > 0 {
+		op := queue[0]
+		queue = queue[1:]
+
+		for _, c := range conns {
+			if done[c] || c.src.Operator() != op {
+				continue
+			}
+			done[c] = true
+
+			if err := c.src.Connect(c.dst); err != nil {
+				l.report(blueprint, "", c.dstRef, fmt.Errorf("%s -> %s: %s", c.srcRef, c.dstRef, err))
+			}
+
+			if next := c.dst.Operator(); !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	for _, c := range conns {
+		if !done[c] {
+			l.report(blueprint, "", c.srcRef, fmt.Errorf("%s -> %s: source is never reached from the in ports of the operator", c.srcRef, c.dstRef))
+		}
+	}
+
+	for dstRef, bufDef := range blueprint.Buffers {
+		if refersToFailed(dstRef) {
+			continue
+		}
+
+		pDst, err := core.ParsePortReference(dstRef, o)
+		if err != nil {
+			l.report(blueprint, "", dstRef, err)
+			continue
+		}
+
+		if err := pDst.SetBuffer(*bufDef); err != nil {
+			l.report(blueprint, "", dstRef, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func findLintProblem(problems []*LintProblem, insName string, port string, msg string) *LintProblem {
+	for _, p := range problems {
+		if p.Instance == insName && p.Port == port && strings.Contains(p.Message, msg) {
+			return p
+		}
+	}
+	return nil
+}
+
+func TestLint__Valid(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	outer, inner := remoteTestBlueprints("")
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner})
+
+	problems, err := Lint(outer.Id, nil, nil, *st)
+	require.NoError(t, err)
+	a.Empty(problems)
+}
+
+func TestLint__ReportsAllProblems(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	_, inner := remoteTestBlueprints("")
+	bp := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "string"},
+				Out: core.TypeDef{Type: "number"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{Name: "pass", Operator: inner.Id},
+			{Name: "unknown", Operator: uuid.New()},
+			{
+				Name:       "eval",
+				Operator:   uuid.MustParse("37ccdc28-67b0-4bb1-8591-4e0e813e3ec1"),
+				Properties: core.Properties{"expression": "$missing", "variables": []interface{}{"a"}},
+			},
+		},
+		Connections: map[string][]string{
+			"(":        {"(pass"},
+			"pass)":    {")"},
+			"unknown)": {")"},
+			"nothere)": {")"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{bp, inner})
+
+	problems, err := Lint(bp.Id, nil, nil, *st)
+	require.NoError(t, err)
+
+	a.NotNil(findLintProblem(problems, "unknown", "", "unknown operator"))
+	a.NotNil(findLintProblem(problems, "eval", "", `unknown property "missing"`))
+	a.NotNil(findLintProblem(problems, "", "(pass", "types don't match"))
+	a.NotNil(findLintProblem(problems, "", "nothere)", `no child "nothere"`))
+	a.Nil(findLintProblem(problems, "", "unknown)", ""))
+
+	for _, p := range problems {
+		a.Equal(bp.Id, p.Blueprint)
+	}
+}
+
+func TestLint__MissingGenerics(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	bp := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "generic", Generic: "itemType"},
+				Out: core.TypeDef{Type: "generic", Generic: "itemType"},
+			},
+		},
+		Connections: map[string][]string{
+			"(": {")"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{bp})
+
+	problems, err := Lint(bp.Id, nil, nil, *st)
+	require.NoError(t, err)
+	a.Len(problems, 1)
+
+	problems, err = Lint(bp.Id, core.Generics{"itemType": {Type: "number"}}, nil, *st)
+	require.NoError(t, err)
+	a.Empty(problems)
+}
+
+func TestLint__UnknownBlueprint(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	_, err := Lint(uuid.New(), nil, nil, *newSlangBundleStorage(nil))
+	a.Error(err)
+}
+
+func TestLint__NullPropertyWarning(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	bp := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "number"},
+				Out: core.TypeDef{Type: "number"},
+			},
+		},
+		PropertyDefs: core.PropertyMap{
+			"limit": {Type: "number"},
+			"label": {Type: "string", Nullable: true},
+		},
+		Connections: map[string][]string{
+			"(": {")"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{bp})
+
+	problems, err := Lint(bp.Id, nil, core.Properties{"limit": nil, "label": nil}, *st)
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	a.True(problems[0].Warning)
+	a.Contains(problems[0].String(), "warning: property limit: expected *number*, got null")
+
+	problems, err = Lint(bp.Id, nil, core.Properties{"limit": 1.0, "label": nil}, *st)
+	require.NoError(t, err)
+	a.Empty(problems)
+}
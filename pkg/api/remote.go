@@ -0,0 +1,562 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/Bitspark/slang/pkg/log"
+	"github.com/google/uuid"
+)
+
+// remoteStopTimeout is the time a worker is given to halt its operator
+const remoteStopTimeout = 5 * time.Second
+
+// remoteBlueprint is a specified blueprint together with the specified blueprints of all of its instances, which
+// are not serialized as part of the blueprint.
+type remoteBlueprint struct {
+	Blueprint core.Blueprint              `json:"blueprint"`
+	Instances map[string]*remoteBlueprint `json:"instances,omitempty"`
+}
+
+// remoteInstance holds everything a worker needs to build an instance annotated as remote.
+type remoteInstance struct {
+	Worker    string           `json:"worker"`
+	Blueprint *remoteBlueprint `json:"blueprint"`
+}
+
+// remoteFrame is sent over the port sockets. It either carries an item or a BOS or EOS marker. Markers sent by the
+// worker name the in port their stream has been received on.
+type remoteFrame struct {
+	Item   interface{} `json:"item,omitempty"`
+	Marker string      `json:"marker,omitempty"`
+	Source string      `json:"source,omitempty"`
+}
+
+var remoteMutex = &sync.Mutex{}
+var remoteInstances = make(map[uuid.UUID]*remoteInstance)
+
+var clusterMutex = &sync.Mutex{}
+var cluster *Cluster
+
+func newRemoteBlueprint(bp core.Blueprint) *remoteBlueprint {
+	rb := &remoteBlueprint{Blueprint: bp}
+	for _, insDef := range bp.InstanceDefs {
+		if rb.Instances == nil {
+			rb.Instances = make(map[string]*remoteBlueprint)
+		}
+		rb.Instances[insDef.Name] = newRemoteBlueprint(insDef.Blueprint)
+	}
+	return rb
+}
+
+// restore reverts newRemoteBlueprint. Remote annotations of nested instances are dropped, they run on the same worker.
+func (rb *remoteBlueprint) restore() core.Blueprint {
+	bp := rb.Blueprint
+	for _, insDef := range bp.InstanceDefs {
+		insDef.Remote = ""
+		if insRb, ok := rb.Instances[insDef.Name]; ok {
+			insDef.Blueprint = insRb.restore()
+		}
+		if elem.IsRegistered(insDef.Operator) {
+			insDef.Blueprint.Elementary = insDef.Operator
+		}
+	}
+	return bp
+}
+
+// remotePortRefs returns references to all service and delegate ports of the blueprint relative to its operator.
+func remotePortRefs(bp core.Blueprint) []string {
+	var refs []string
+	for srvName := range bp.ServiceDefs {
+		if srvName == core.MAIN_SERVICE {
+			refs = append(refs, "(", ")")
+		} else {
+			refs = append(refs, "("+srvName+"@", srvName+"@)")
+		}
+	}
+	for dlgName := range bp.DelegateDefs {
+		refs = append(refs, "(."+dlgName, "."+dlgName+")")
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// newRemoteOperator creates a proxy operator for an instance annotated as remote. The proxy has the same services and
+// delegates as the instance and bridges them to a worker of the cluster as soon as it is started.
+func newRemoteOperator(insDef core.InstanceDef) (*core.Operator, error) {
+	if elem.IsRegistered(insDef.Operator) {
+		return nil, fmt.Errorf("%s: elementary operators cannot run remotely", insDef.Name)
+	}
+
+	id := uuid.New()
+	remoteMutex.Lock()
+	remoteInstances[id] = &remoteInstance{insDef.Remote, newRemoteBlueprint(insDef.Blueprint)}
+	remoteMutex.Unlock()
+
+	return makeRemoteOperator(insDef.Name, id)
+}
+
+// makeRemoteOperator creates the proxy operator for the remote instance registered with id.
+func makeRemoteOperator(name string, id uuid.UUID) (*core.Operator, error) {
+	remoteMutex.Lock()
+	ri, ok := remoteInstances[id]
+	remoteMutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown remote instance: %s", id)
+	}
+
+	bp := ri.Blueprint.Blueprint.Copy(false)
+	bp.InstanceDefs = nil
+	bp.Connections = nil
+	bp.PropertyDefs = nil
+	bp.Elementary = id
+
+	return core.NewOperator(name, func(op *core.Operator) {
+		err := ri.run(op)
+
+		remoteMutex.Lock()
+		delete(remoteInstances, id)
+		remoteMutex.Unlock()
+
+		if err != nil {
+			op.Fail(err)
+		}
+	}, nil, nil, nil, bp)
+}
+
+func isRemoteInstance(id uuid.UUID) bool {
+	remoteMutex.Lock()
+	defer remoteMutex.Unlock()
+	_, ok := remoteInstances[id]
+	return ok
+}
+
+// run acquires a worker, initializes the instance on it and bridges the ports of op until op is stopped.
+func (ri *remoteInstance) run(op *core.Operator) error {
+	cl := getCluster()
+	if cl == nil {
+		return errors.New("not listening for workers, call ListenForWorkers first")
+	}
+
+	c, err := cl.acquire(op.Context(), ri.Worker)
+	if err != nil {
+		return err
+	}
+	defer cl.release(ri.Worker, c)
+
+	a, err := json.Marshal(ri)
+	if err != nil {
+		return err
+	}
+	if _, err := c.Init(string(a)); err != nil {
+		return fmt.Errorf("worker %s: %s", ri.Worker, err)
+	}
+
+	prtCfg, err := c.PrtCfg()
+	if err != nil {
+		return fmt.Errorf("worker %s: %s", ri.Worker, err)
+	}
+	var pmap map[string]string
+	if err := json.Unmarshal([]byte(prtCfg), &pmap); err != nil {
+		return err
+	}
+
+	b := &remoteBridge{op: op, markers: make(map[string][]interface{})}
+	pch := NewPortConnHandler(pmap)
+	defer pch.Close()
+	for _, ref := range remotePortRefs(ri.Blueprint.Blueprint) {
+		p, err := core.ParsePortReference(ref, op)
+		if err != nil {
+			return err
+		}
+		if err := pch.ConnectTo(ref, b.handler(ref, p)); err != nil {
+			return err
+		}
+	}
+
+	op.WaitForStop()
+	return nil
+}
+
+// remoteBridge bridges the ports of a proxy operator to the sockets of a worker. Markers sent to the worker are
+// replaced by markers of the worker, which are mapped back to the original markers in order. There is one queue of
+// markers per in port, as the streams of different ports are not ordered among each other.
+type remoteBridge struct {
+	op      *core.Operator
+	mutex   sync.Mutex
+	markers map[string][]interface{}
+}
+
+func (b *remoteBridge) pushMarker(ref string, m interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.markers[ref] = append(b.markers[ref], m)
+}
+
+func (b *remoteBridge) popMarker(ref string, kind string) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	markers := b.markers[ref]
+	if len(markers) == 0 || markerKind(markers[0]) != kind {
+		return nil, fmt.Errorf("unexpected %s of %s from worker", kind, ref)
+	}
+	b.markers[ref] = markers[1:]
+	return markers[0], nil
+}
+
+// handler returns a connection handler for port p of the proxy operator, which is referenced by ref. In ports are
+// pulled and sent to the worker, out ports are pushed with the items received from the worker.
+func (b *remoteBridge) handler(ref string, p *core.Port) func(conn net.Conn) bool {
+	return func(conn net.Conn) bool {
+		ctx := b.op.Context()
+		defer conn.Close()
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+
+		var err error
+		if p.Direction() == core.DIRECTION_IN {
+			err = b.send(ctx, ref, p, bufio.NewWriter(conn))
+		} else {
+			err = b.receive(ctx, p, bufio.NewReader(conn))
+		}
+
+		if ctx.Err() != nil {
+			return false
+		}
+		log.Warnf("%s: connection to worker lost: %s", p, err)
+		return true
+	}
+}
+
+func (b *remoteBridge) send(ctx context.Context, ref string, p *core.Port, wr *bufio.Writer) error {
+	for {
+		i, err := p.PullContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		var frame remoteFrame
+		if core.IsMarker(i) {
+			b.pushMarker(ref, i)
+			frame.Marker = markerKind(i)
+		} else {
+			frame.Item = core.EncodeItem(i)
+		}
+
+		if err := JsonWrbuf(wr, &frame); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *remoteBridge) receive(ctx context.Context, p *core.Port, rd *bufio.Reader) error {
+	for {
+		frame, err := readFrame(rd)
+		if err != nil {
+			return err
+		}
+
+		var i interface{}
+		if frame.Marker != "" {
+			if i, err = b.popMarker(frame.Source, frame.Marker); err != nil {
+				return err
+			}
+		} else {
+			i = core.DecodeItem(frame.Item)
+		}
+
+		if err := p.PushContext(ctx, i); err != nil {
+			return err
+		}
+	}
+}
+
+func readFrame(rd *bufio.Reader) (*remoteFrame, error) {
+	j, err := JsonRdbuf(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := &remoteFrame{}
+	if m, ok := j.(map[string]interface{}); ok {
+		frame.Item = m["item"]
+		frame.Marker, _ = m["marker"].(string)
+		frame.Source, _ = m["source"].(string)
+	}
+	return frame, nil
+}
+
+func markerKind(m interface{}) string {
+	if core.IsBOS(m) {
+		return "bos"
+	}
+	return "eos"
+}
+
+// Cluster hands out workers which connect to its commander to the remote instances which request them by name.
+type Cluster struct {
+	cmdr    Commander
+	mutex   sync.Mutex
+	workers map[string]chan Commands
+}
+
+// ListenForWorkers starts a commander at addr which workers connect to. Instances annotated as remote are run on
+// these workers.
+func ListenForWorkers(addr string) (*Cluster, error) {
+	cmdr, err := NewCommander(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cl := &Cluster{cmdr: cmdr, workers: make(map[string]chan Commands)}
+	go func() {
+		if err := cmdr.Begin(cl.accept); err != nil {
+			log.Error(err)
+		}
+	}()
+
+	clusterMutex.Lock()
+	cluster = cl
+	clusterMutex.Unlock()
+
+	return cl, nil
+}
+
+func getCluster() *Cluster {
+	clusterMutex.Lock()
+	defer clusterMutex.Unlock()
+	return cluster
+}
+
+// Addr returns the address workers have to connect to.
+func (cl *Cluster) Addr() string {
+	return cl.cmdr.Addr()
+}
+
+func (cl *Cluster) queue(name string) chan Commands {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+	q, ok := cl.workers[name]
+	if !ok {
+		q = make(chan Commands, 16)
+		cl.workers[name] = q
+	}
+	return q
+}
+
+func (cl *Cluster) accept(c Commands) error {
+	name, err := c.Hello()
+	if err != nil {
+		return err
+	}
+	cl.queue(name) <- c
+	return nil
+}
+
+// acquire waits until a worker with the given name is available.
+func (cl *Cluster) acquire(ctx context.Context, name string) (Commands, error) {
+	select {
+	case c := <-cl.queue(name):
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release stops the instance running on worker c and hands c out again. Workers which cannot be stopped are dropped.
+func (cl *Cluster) release(name string, c Commands) {
+	if _, err := c.Stop(); err != nil {
+		log.Warnf("worker %s: %s", name, err)
+		return
+	}
+	cl.queue(name) <- c
+}
+
+// workerCmds implements the commands of a worker which builds and runs remote instances.
+type workerCmds struct {
+	name string
+	host string
+
+	mutex sync.Mutex
+	op    *core.Operator
+	lns   map[string]net.Listener
+}
+
+// NewWorkerCommands returns a constructor for the commands of a worker with the given name. Port sockets are opened
+// on host, which has to be reachable by the commander.
+func NewWorkerCommands(name string, host string) func() Commands {
+	return func() Commands {
+		return &workerCmds{name: name, host: host, lns: make(map[string]net.Listener)}
+	}
+}
+
+func (w *workerCmds) Hello() (string, error) {
+	return w.name, nil
+}
+
+func (w *workerCmds) Init(a string) (string, error) {
+	var ri remoteInstance
+	if err := json.Unmarshal([]byte(a), &ri); err != nil {
+		return "", err
+	}
+
+	bp := ri.Blueprint.restore()
+	op, err := CreateAndConnectOperator("", bp, false)
+	if err != nil {
+		return "", err
+	}
+	if op, err = Compile(op); err != nil {
+		return "", err
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.op != nil {
+		return "", errors.New("worker already initialized")
+	}
+
+	// Each in port gets a marker source of its own so that markers can be mapped back to the port they came from
+	markerSrcs := make(map[string]*core.Port)
+	ports := make(map[string]*core.Port)
+	for _, ref := range remotePortRefs(bp) {
+		p, err := core.ParsePortReference(ref, op)
+		if err != nil {
+			w.closeListeners()
+			return "", err
+		}
+		if p.Direction() == core.DIRECTION_OUT {
+			p.Bufferize()
+		} else {
+			markerSrcs[ref], _ = core.NewPort(nil, nil, core.TypeDef{Type: "trigger"}, core.DIRECTION_IN)
+		}
+
+		ln, err := net.Listen("tcp", net.JoinHostPort(w.host, "0"))
+		if err != nil {
+			w.closeListeners()
+			return "", err
+		}
+		w.lns[ref] = ln
+		ports[ref] = p
+	}
+
+	w.op = op
+	op.Start()
+	for ref, p := range ports {
+		go w.serve(w.lns[ref], p, markerSrcs[ref], markerSrcs)
+	}
+	return "ok", nil
+}
+
+func (w *workerCmds) PrtCfg() (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	pmap := make(map[string]string)
+	for ref, ln := range w.lns {
+		pmap[ref] = ln.Addr().String()
+	}
+
+	b, err := json.Marshal(pmap)
+	return string(b), err
+}
+
+func (w *workerCmds) Stop() (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.closeListeners()
+	if w.op == nil {
+		return "ok", nil
+	}
+	op := w.op
+	w.op = nil
+	if err := op.HaltTimeout(remoteStopTimeout); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+func (w *workerCmds) Action() error {
+	return nil
+}
+
+func (w *workerCmds) closeListeners() {
+	for ref, ln := range w.lns {
+		ln.Close()
+		delete(w.lns, ref)
+	}
+}
+
+// serve accepts connections of the commander for port p. Items received for in ports are pushed into p, items pulled
+// from out ports are sent. Markers received are replaced by markers of markerSrc, markers sent name the in port whose
+// marker source they belong to.
+func (w *workerCmds) serve(ln net.Listener, p *core.Port, markerSrc *core.Port, markerSrcs map[string]*core.Port) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		if p.Direction() == core.DIRECTION_IN {
+			rd := bufio.NewReader(conn)
+			for {
+				frame, err := readFrame(rd)
+				if err != nil {
+					break
+				}
+				switch frame.Marker {
+				case "bos":
+					p.Push(markerSrc.NewBOS())
+				case "eos":
+					p.Push(markerSrc.NewEOS())
+				default:
+					p.Push(core.DecodeItem(frame.Item))
+				}
+			}
+		} else {
+			wr := bufio.NewWriter(conn)
+			ctx := p.Operator().Context()
+			for {
+				i, err := p.PullContext(ctx)
+				if err != nil || ctx.Err() != nil {
+					// closed buffers of stopped operators yield nil items
+					break
+				}
+				var frame remoteFrame
+				if core.IsMarker(i) {
+					frame.Marker = markerKind(i)
+					frame.Source = markerSource(i, markerSrcs)
+				} else {
+					frame.Item = core.EncodeItem(i)
+				}
+				if err := JsonWrbuf(wr, &frame); err != nil {
+					break
+				}
+			}
+		}
+
+		conn.Close()
+	}
+}
+
+// markerSource returns the reference of the in port whose marker source m belongs to.
+func markerSource(m interface{}, markerSrcs map[string]*core.Port) string {
+	for ref, src := range markerSrcs {
+		if src.OwnBOS(m) || src.OwnEOS(m) {
+			return ref
+		}
+	}
+	return ""
+}
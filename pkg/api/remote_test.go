@@ -0,0 +1,199 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain runs the test binary as worker if it has been started by startWorker.
+func TestMain(m *testing.M) {
+	if addr := os.Getenv("SLANG_TEST_COMMANDER"); addr != "" {
+		elem.Init()
+		err := NewWorker(addr).Begin(NewWorkerCommands(os.Getenv("SLANG_TEST_WORKER"), "localhost"))
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// startWorker starts a worker in a separate process which connects to the commander at addr.
+func startWorker(t *testing.T, addr string, name string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), "SLANG_TEST_COMMANDER="+addr, "SLANG_TEST_WORKER="+name)
+	cmd.Stderr = os.Stderr
+	require.NoError(t, cmd.Start())
+	return cmd
+}
+
+func remoteTestBlueprints(worker string) (core.Blueprint, core.Blueprint) {
+	inner := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{"a": {Type: "number"}}},
+				Out: core.TypeDef{Type: "number"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{
+				Name:       "double",
+				Operator:   uuid.MustParse("37ccdc28-67b0-4bb1-8591-4e0e813e3ec1"),
+				Properties: core.Properties{"expression": "a*2", "variables": []interface{}{"a"}},
+			},
+		},
+		Connections: map[string][]string{
+			"(":       {"(double"},
+			"double)": {")"},
+		},
+	}
+
+	outer := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{"a": {Type: "number"}}}},
+				Out: core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "number"}},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{
+				Name:     "remote",
+				Operator: inner.Id,
+				Remote:   worker,
+			},
+		},
+		Connections: map[string][]string{
+			"~(":      {"(remote"},
+			"remote)": {")~"},
+		},
+	}
+
+	return outer, inner
+}
+
+func TestRemote__Stream(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	cl, err := ListenForWorkers("localhost:0")
+	require.NoError(t, err)
+
+	worker := startWorker(t, cl.Addr(), "w1")
+	defer worker.Process.Kill()
+
+	outer, inner := remoteTestBlueprints("w1")
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner})
+
+	op, err := BuildAndCompile(outer.Id, nil, nil, *st)
+	require.NoError(t, err)
+
+	op.Main().Out().Bufferize()
+	op.Start()
+
+	op.Main().In().Push([]interface{}{
+		map[string]interface{}{"a": 1.0},
+		map[string]interface{}{"a": 2.0},
+	})
+	op.Main().In().Push([]interface{}{})
+	op.Main().In().Push([]interface{}{map[string]interface{}{"a": 5.0}})
+
+	a.Equal([]interface{}{2.0, 4.0}, op.Main().Out().Pull())
+	a.Equal([]interface{}{}, op.Main().Out().Pull())
+	a.Equal([]interface{}{10.0}, op.Main().Out().Pull())
+
+	a.NoError(op.HaltTimeout(5 * time.Second))
+	a.NoError(op.Err())
+}
+
+func TestRemote__Backpressure(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	cl, err := ListenForWorkers("localhost:0")
+	require.NoError(t, err)
+
+	worker := startWorker(t, cl.Addr(), "w2")
+	defer worker.Process.Kill()
+
+	outer, inner := remoteTestBlueprints("w2")
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner})
+
+	op, err := BuildAndCompile(outer.Id, nil, nil, *st)
+	require.NoError(t, err)
+
+	op.Main().Out().Bufferize()
+	op.Start()
+
+	// More items than fit into a single port buffer have to be passed through the sockets
+	n := core.CurrentRuntimeConfig().BufferSize + 100
+	items := make([]interface{}, n)
+	expected := make([]interface{}, n)
+	for i := range items {
+		items[i] = map[string]interface{}{"a": float64(i)}
+		expected[i] = float64(2 * i)
+	}
+
+	go op.Main().In().Push(items)
+	a.Equal(expected, op.Main().Out().Pull())
+
+	a.NoError(op.HaltTimeout(5 * time.Second))
+}
+
+func TestRemote__UnknownWorkerStops(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	_, err := ListenForWorkers("localhost:0")
+	require.NoError(t, err)
+
+	outer, inner := remoteTestBlueprints("nobody")
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner})
+
+	op, err := BuildAndCompile(outer.Id, nil, nil, *st)
+	require.NoError(t, err)
+
+	op.Start()
+	a.NoError(op.HaltTimeout(time.Second))
+}
+
+func TestRemote__WorkerReused(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	cl, err := ListenForWorkers("localhost:0")
+	require.NoError(t, err)
+
+	worker := startWorker(t, cl.Addr(), "w3")
+	defer worker.Process.Kill()
+
+	outer, inner := remoteTestBlueprints("w3")
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner})
+
+	// The single worker has to be handed out again after the first operator has been stopped
+	for _, n := range []float64{1.0, 2.0} {
+		op, err := BuildAndCompile(outer.Id, nil, nil, *st)
+		require.NoError(t, err)
+
+		op.Main().Out().Bufferize()
+		op.Start()
+
+		op.Main().In().Push([]interface{}{map[string]interface{}{"a": n}})
+		a.Equal([]interface{}{2 * n}, op.Main().Out().Pull())
+
+		a.NoError(op.HaltTimeout(5 * time.Second))
+		a.NoError(op.Err())
+	}
+
+	remoteMutex.Lock()
+	a.Empty(remoteInstances)
+	remoteMutex.Unlock()
+}
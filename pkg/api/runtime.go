@@ -3,6 +3,7 @@ package api
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -10,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Bitspark/slang/pkg/log"
 	"github.com/thoas/go-funk"
 )
 
@@ -27,6 +29,7 @@ type Commands interface {
 	Hello() (string, error)
 	Init(a string) (string, error)
 	PrtCfg() (string, error)
+	Stop() (string, error)
 	Action() error
 }
 
@@ -45,9 +48,12 @@ type cmdrCmdsImpl struct {
 	action func(c Commands) error
 }
 
-func NewCommander(addr string) Commander {
-	ln, _ := net.Listen("tcp", addr)
-	return &cmdr{ln.Addr().String(), ln}
+func NewCommander(addr string) (Commander, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &cmdr{ln.Addr().String(), ln}, nil
 }
 
 func NewWorker(addr string) Worker {
@@ -62,29 +68,25 @@ func (m *wrkr) Addr() string {
 	return m.addr
 }
 
+// Begin accepts workers and runs action for each of them. It returns as soon as the listener fails.
 func (m *cmdr) Begin(action func(c Commands) error) error {
 	ln := m.ln
 
-	errors := make(chan error, 1)
-
-	go func() {
-		for {
-			conn, err := ln.Accept()
-
-			if err != nil {
-				errors <- err
-			}
+	for {
+		conn, err := ln.Accept()
 
-			c := &cmdrCmdsImpl{bufio.NewWriter(conn), bufio.NewReader(conn), action}
-			go func() {
-				if err := c.Action(); err != nil {
-					errors <- err
-				}
-			}()
+		if err != nil {
+			return err
 		}
-	}()
 
-	return <-errors
+		c := &cmdrCmdsImpl{bufio.NewWriter(conn), bufio.NewReader(conn), action}
+		go func() {
+			if err := c.Action(); err != nil {
+				log.Errorf("worker %s: %s", conn.RemoteAddr(), err)
+				conn.Close()
+			}
+		}()
+	}
 }
 
 func (m *wrkr) Begin(newWorkerCmds func() Commands) error {
@@ -140,13 +142,15 @@ func (m *wrkr) dispatch(conn net.Conn, c Commands, errors chan error, wg *sync.W
 			rmsg, err = c.Init(s[1])
 		case "/ports":
 			rmsg, err = c.PrtCfg()
+		case "/stop":
+			rmsg, err = c.Stop()
 		default:
 			continue
 		}
 
 		if err != nil {
-			errors <- err
-			break
+			// report the error to the commander instead of dropping the connection
+			rmsg = "/error " + err.Error()
 		}
 
 		err = Wrbuf(wr, rmsg)
@@ -195,7 +199,7 @@ func JsonWrbuf(wr *bufio.Writer, j interface{}) error {
 func JsonRdbuf(rd *bufio.Reader) (interface{}, error) {
 	msg, err := Rdbuf(rd)
 
-	if err != nil && err != io.EOF {
+	if err != nil && (err != io.EOF || len(msg) == 0) {
 		return nil, err
 	}
 
@@ -215,48 +219,65 @@ func (c *cmdrCmdsImpl) Action() error {
 }
 
 func (c *cmdrCmdsImpl) Hello() (string, error) {
-	err := Wrbuf(c.wr, "/hello")
+	return c.send("/hello")
+}
 
-	if err != nil {
-		return "", err
-	}
+func (c *cmdrCmdsImpl) Init(a string) (string, error) {
+	return c.send("/init " + a)
+}
+
+func (c *cmdrCmdsImpl) PrtCfg() (string, error) {
+	return c.send("/ports")
+}
 
-	return Rdbuf(c.rd)
+func (c *cmdrCmdsImpl) Stop() (string, error) {
+	return c.send("/stop")
 }
 
-func (c *cmdrCmdsImpl) Init(a string) (string, error) {
-	if err := Wrbuf(c.wr, "/init "+a); err != nil {
+// send sends a command to the worker and returns its reply. Errors reported by the worker are returned as error.
+func (c *cmdrCmdsImpl) send(cmd string) (string, error) {
+	if err := Wrbuf(c.wr, cmd); err != nil {
 		return "", err
 	}
-	return Rdbuf(c.rd)
-}
 
-func (c *cmdrCmdsImpl) PrtCfg() (string, error) {
-	if err := Wrbuf(c.wr, "/ports"); err != nil {
+	msg, err := Rdbuf(c.rd)
+	if err != nil {
 		return "", err
 	}
-	return Rdbuf(c.rd)
+
+	if strings.HasPrefix(msg, "/error ") {
+		return "", errors.New(strings.TrimPrefix(msg, "/error "))
+	}
+	return msg, nil
 }
 
 type PortConnHandler interface {
 	ListPortRefs() []string
 	ConnectTo(p string, hndl func(c net.Conn) bool) error
+	Close()
 }
 
 type prtScktMap struct {
 	pmap map[string]string
+	done chan bool
+	once sync.Once
 }
 
 func NewPortConnHandler(pmap map[string]string) PortConnHandler {
-	return &prtScktMap{pmap}
+	return &prtScktMap{pmap: pmap, done: make(chan bool)}
 }
 
 func (ps *prtScktMap) ListPortRefs() []string {
 	return funk.Keys(ps.pmap).([]string)
 }
 
+// ConnectTo connects to the socket of port p and calls hndl with the connection. hndl is called again with a new
+// connection as long as it returns true.
 func (ps *prtScktMap) ConnectTo(p string, hndl func(c net.Conn) bool) error {
 	addr, ok := ps.pmap[p]
+	if !ok {
+		return fmt.Errorf("unknown port: %s", p)
+	}
 
 	go func() {
 		var wg sync.WaitGroup
@@ -278,13 +299,22 @@ func (ps *prtScktMap) ConnectTo(p string, hndl func(c net.Conn) bool) error {
 
 				wg.Add(1)
 			}
-			time.Sleep(100 * time.Millisecond)
+
+			select {
+			case <-ps.done:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
 		}
 
 	}()
 
-	if !ok {
-		return fmt.Errorf("unknown port: %s", p)
-	}
 	return nil
 }
+
+// Close stops reconnecting. Established connections are not affected.
+func (ps *prtScktMap) Close() {
+	ps.once.Do(func() {
+		close(ps.done)
+	})
+}
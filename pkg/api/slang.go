@@ -30,10 +30,27 @@ func BuildOperator(bundle *core.SlangBundle) (*core.Operator, error) {
 
 func gatherDependencies(def *core.Blueprint, bundle *core.SlangBundle, store *storage.Storage) error {
 	bundle.Blueprints[def.Id] = *def
+	for _, id := range def.TypeRefs() {
+		if _, ok := bundle.Blueprints[id]; ok {
+			continue
+		}
+		typesDef, err := store.Load(id)
+		if err != nil {
+			return err
+		}
+		if err := gatherDependencies(typesDef, bundle, store); err != nil {
+			return err
+		}
+	}
 	for _, dep := range def.InstanceDefs {
 		id := dep.Operator
-		if _, ok := bundle.Blueprints[id]; !ok {
-			depDef, err := store.Load(id)
+		if depDef, ok := bundle.Blueprints[id]; ok {
+			// A bundle can only hold one version of each blueprint
+			if err := checkVersion(dep, &depDef); err != nil {
+				return err
+			}
+		} else {
+			depDef, err := store.LoadVersion(id, dep.Version)
 			if err != nil {
 				return err
 			}
@@ -60,6 +77,76 @@ func CreateBundle(bp *core.Blueprint, st *storage.Storage) (*core.SlangBundle, e
 	return bundle, bundle.Validate()
 }
 
+// Dependencies returns the ids of all blueprints the blueprint with id bpid is built from, including bpid itself and
+// blueprints whose types are referenced.
+func Dependencies(bpid uuid.UUID, st storage.Storage) ([]uuid.UUID, error) {
+	bp, err := st.Load(bpid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Different versions of the same blueprint may be used by different instances
+	visited := map[string]bool{bpid.String() + "@" + bp.Meta.Version: true}
+	deps := []uuid.UUID{bpid}
+
+	var gather func(bp *core.Blueprint) error
+	visit := func(depDef *core.Blueprint) error {
+		key := depDef.Id.String() + "@" + depDef.Meta.Version
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+
+		if !funk.Contains(deps, depDef.Id) {
+			deps = append(deps, depDef.Id)
+		}
+		return gather(depDef)
+	}
+	gather = func(bp *core.Blueprint) error {
+		for _, id := range bp.TypeRefs() {
+			typesDef, err := st.Load(id)
+			if err != nil {
+				return err
+			}
+			if err := visit(typesDef); err != nil {
+				return err
+			}
+		}
+
+		for _, insDef := range bp.InstanceDefs {
+			depDef, err := st.LoadVersion(insDef.Operator, insDef.Version)
+			if err != nil {
+				return err
+			}
+			if err := visit(depDef); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := gather(bp); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+// checkVersion returns an error if the version of blueprint does not match the version range of insDef.
+func checkVersion(insDef *core.InstanceDef, blueprint *core.Blueprint) error {
+	if insDef.Version == "" || elem.IsRegistered(blueprint.Id) {
+		return nil
+	}
+
+	r, err := core.ParseVersionRange(insDef.Version)
+	if err != nil {
+		return err
+	}
+	if !r.ContainsString(blueprint.Meta.Version) {
+		return fmt.Errorf(`instance "%s" requires version %s of operator %s but version "%s" is used`, insDef.Name, insDef.Version, blueprint.Id, blueprint.Meta.Version)
+	}
+	return nil
+}
+
 type slangBundleLoader struct {
 	blueprintById map[uuid.UUID]core.Blueprint
 }
@@ -107,6 +194,24 @@ func CreateAndConnectOperator(insName string, def core.Blueprint, ordered bool)
 
 	// Recursively create all child operators from top to bottom
 	for _, childOpInsDef := range def.InstanceDefs {
+		if childOpInsDef.Remote != "" {
+			// Instance is run on a worker, create a proxy for it
+			remoteOp, err := newRemoteOperator(*childOpInsDef)
+			if err != nil {
+				return nil, err
+			}
+			remoteOp.SetParent(o)
+			continue
+		} else if isRemoteInstance(childOpInsDef.Operator) {
+			// Proxy has been created before compiling
+			remoteOp, err := makeRemoteOperator(childOpInsDef.Name, childOpInsDef.Operator)
+			if err != nil {
+				return nil, err
+			}
+			remoteOp.SetParent(o)
+			continue
+		}
+
 		if builtinOp, err := elem.MakeOperator(*childOpInsDef); err == nil {
 			// Builtin operator has been found
 			builtinOp.SetParent(o)
@@ -145,6 +250,17 @@ func CreateAndConnectOperator(insName string, def core.Blueprint, ordered bool)
 		return nil, err
 	}
 
+	// Declare buffers after connecting so that they reach the buffers of the child operators
+	for dstConnDef, bufDef := range def.Buffers {
+		pDst, err := core.ParsePortReference(dstConnDef, o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", err.Error(), dstConnDef)
+		}
+		if err := pDst.SetBuffer(*bufDef); err != nil {
+			return nil, err
+		}
+	}
+
 	return o, nil
 }
 
@@ -275,6 +391,10 @@ func completeProperties(blueprint *core.Blueprint, givenProps core.Properties) (
 func specifyOperator(blueprint *core.Blueprint, gens core.Generics, props core.Properties, st storage.Storage, dependencyChain []uuid.UUID) error {
 	var err error
 
+	if err := blueprint.ResolveTypes(st.Load); err != nil {
+		return err
+	}
+
 	if props, err = completeProperties(blueprint, props); err != nil {
 		return err
 	}
@@ -289,7 +409,7 @@ func specifyOperator(blueprint *core.Blueprint, gens core.Generics, props core.P
 		// Load Blueprint for childInsDef
 		if childInsDef.Blueprint.Id == uuid.Nil {
 			childOpId := childInsDef.Operator
-			if childBlueprint, err := st.Load(childOpId); err == nil {
+			if childBlueprint, err := st.LoadVersion(childOpId, childInsDef.Version); err == nil {
 				childInsDef.Blueprint = *childBlueprint
 			} else {
 				return err
@@ -318,6 +438,17 @@ func specifyOperator(blueprint *core.Blueprint, gens core.Generics, props core.P
 		for _, gen := range childInsDef.Generics {
 			gen.SpecifyGenerics(gens)
 		}
+	}
+
+	// Generics not given are inferred from the connections
+	if err := inferGenerics(blueprint, props, st); err != nil {
+		return err
+	}
+
+	for _, childInsDef := range blueprint.InstanceDefs {
+		if err := childInsDef.Blueprint.CheckGenerics(childInsDef.Generics); err != nil {
+			return fmt.Errorf(`instance "%s": %s`, childInsDef.Name, err)
+		}
 
 		err := specifyOperator(&childInsDef.Blueprint, childInsDef.Generics, childInsDef.Properties, st, dependencyChain)
 
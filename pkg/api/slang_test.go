@@ -0,0 +1,213 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/Bitspark/slang/pkg/storage"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencies(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	outer, inner := remoteTestBlueprints("")
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner})
+
+	deps, err := Dependencies(outer.Id, *st)
+	require.NoError(t, err)
+	a.ElementsMatch([]uuid.UUID{outer.Id, inner.Id, uuid.MustParse("37ccdc28-67b0-4bb1-8591-4e0e813e3ec1")}, deps)
+
+	deps, err = Dependencies(inner.Id, *st)
+	require.NoError(t, err)
+	a.NotContains(deps, outer.Id)
+}
+
+func TestDependencies__Unknown(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	_, err := Dependencies(uuid.New(), *newSlangBundleStorage(nil))
+	a.Error(err)
+}
+
+func TestBuild__PinnedVersion(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	outer, inner := remoteTestBlueprints("")
+
+	st := storage.NewStorage().AddBackend(storage.NewWritableFileSystem(t.TempDir()))
+	inner.Meta.Version = "1.0.0"
+	_, err := st.Save(inner)
+	require.NoError(t, err)
+	inner.Meta.Version = "2.0.0"
+	inner.InstanceDefs[0].Properties = core.Properties{"expression": "a*3", "variables": []interface{}{"a"}}
+	_, err = st.Save(inner)
+	require.NoError(t, err)
+
+	for version, expected := range map[string]float64{"": 3, "^1": 2, "2.0.0": 3} {
+		outer.InstanceDefs[0].Version = version
+		_, err = st.Save(outer)
+		require.NoError(t, err)
+
+		op, err := BuildAndCompile(outer.Id, nil, nil, *st)
+		require.NoError(t, err)
+
+		op.Main().Out().Bufferize()
+		op.Start()
+		op.Main().In().Push([]interface{}{map[string]interface{}{"a": 1.0}})
+		a.Equal([]interface{}{expected}, op.Main().Out().Pull(), version)
+		op.Stop()
+	}
+
+	outer.InstanceDefs[0].Version = "^3"
+	_, err = st.Save(outer)
+	require.NoError(t, err)
+	_, err = BuildAndCompile(outer.Id, nil, nil, *st)
+	a.Error(err)
+}
+
+func TestBuild__Buffers(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	outer, inner := remoteTestBlueprints("")
+	outer.Buffers = map[string]*core.BufferDef{"(remote": {Size: 4, Policy: core.BUFFER_POLICY_SPILL}}
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner})
+
+	op, err := BuildAndCompile(outer.Id, nil, nil, *st)
+	require.NoError(t, err)
+
+	// The buffer declared for the instance reaches the elementary operator inside
+	p := op.Child("remote#double").Main().In().Map("a")
+	a.Equal(core.BufferDef{Size: 4, Policy: core.BUFFER_POLICY_SPILL}, p.Buffer())
+	a.Equal(4, p.Metrics().BufferCapacity)
+
+	items := make([]interface{}, 20)
+	expected := make([]interface{}, 20)
+	for i := range items {
+		items[i] = map[string]interface{}{"a": float64(i)}
+		expected[i] = float64(2 * i)
+	}
+
+	op.Main().Out().Bufferize()
+	op.Main().In().Push(items)
+	op.Start()
+	a.Equal(expected, op.Main().Out().Pull())
+	op.Stop()
+}
+
+// typeRefTestBlueprints returns the blueprints of remoteTestBlueprints with the input type of the inner blueprint
+// declared in a separate blueprint and referenced by both
+func typeRefTestBlueprints() (core.Blueprint, core.Blueprint, core.Blueprint) {
+	outer, inner := remoteTestBlueprints("")
+
+	types := core.Blueprint{
+		Id: uuid.New(),
+		Types: core.TypeDefMap{
+			"Input": {Type: "map", Map: map[string]*core.TypeDef{"a": {Type: "ref", Ref: "#Value"}}},
+			"Value": {Type: "number"},
+		},
+	}
+
+	ref := core.TypeDef{Type: "ref", Ref: types.Id.String() + "#Input"}
+	inner.ServiceDefs[core.MAIN_SERVICE].In = ref
+	outer.ServiceDefs[core.MAIN_SERVICE].In = core.TypeDef{Type: "stream", Stream: &ref}
+
+	return outer, inner, types
+}
+
+func TestBuild__TypeRefs(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	outer, inner, types := typeRefTestBlueprints()
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner, types})
+
+	op, err := BuildAndCompile(outer.Id, nil, nil, *st)
+	require.NoError(t, err)
+	a.Equal(core.TYPE_NUMBER, op.Main().In().Stream().Map("a").Type())
+
+	op.Main().Out().Bufferize()
+	op.Main().In().Push([]interface{}{map[string]interface{}{"a": 1.0}, map[string]interface{}{"a": 2.0}})
+	op.Start()
+	a.Equal([]interface{}{2.0, 4.0}, op.Main().Out().Pull())
+	op.Stop()
+
+	deps, err := Dependencies(outer.Id, *st)
+	require.NoError(t, err)
+	a.Contains(deps, types.Id)
+}
+
+func TestBuild__TypeRefsUnknown(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	outer, inner, types := typeRefTestBlueprints()
+	delete(types.Types, "Value")
+	st := newSlangBundleStorage([]core.Blueprint{outer, inner, types})
+
+	_, err := Build(outer.Id, nil, nil, *st)
+	a.Error(err)
+
+	_, err = Build(outer.Id, nil, nil, *newSlangBundleStorage([]core.Blueprint{outer, inner}))
+	a.Error(err)
+}
+
+func TestBuild__GenericConstraints(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+
+	numeric := core.Blueprint{
+		Id:       uuid.New(),
+		Meta:     core.BlueprintMetaDef{Name: "numeric"},
+		Generics: core.GenericConstraints{"itemType": {Types: []string{"number", "decimal"}}},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "generic", Generic: "itemType"},
+				Out: core.TypeDef{Type: "generic", Generic: "itemType"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{},
+		Connections:  map[string][]string{"(": {")"}},
+	}
+	outer := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "generic", Generic: "itemType"},
+				Out: core.TypeDef{Type: "generic", Generic: "itemType"},
+			},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{
+				Name:     "pass",
+				Operator: numeric.Id,
+				Generics: core.Generics{"itemType": {Type: "generic", Generic: "itemType"}},
+			},
+		},
+		Connections: map[string][]string{
+			"(":     {"(pass"},
+			"pass)": {")"},
+		},
+	}
+	st := newSlangBundleStorage([]core.Blueprint{outer, numeric})
+
+	op, err := Build(numeric.Id, core.Generics{"itemType": {Type: "decimal"}}, nil, *st)
+	require.NoError(t, err)
+	a.Equal(core.TYPE_DECIMAL, op.Main().In().Type())
+
+	_, err = Build(numeric.Id, core.Generics{"itemType": {Type: "string"}}, nil, *st)
+	a.EqualError(err, `operator numeric: generic "itemType" must be of type number or decimal, not string`)
+
+	_, err = Build(outer.Id, core.Generics{"itemType": {Type: "number"}}, nil, *st)
+	a.NoError(err)
+
+	_, err = Build(outer.Id, core.Generics{"itemType": {Type: "boolean"}}, nil, *st)
+	a.EqualError(err, `instance "pass": operator numeric: generic "itemType" must be of type number or decimal, not boolean`)
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"reflect"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/pkg/storage"
@@ -14,10 +15,17 @@ import (
 
 type TestBench struct {
 	stor *storage.Storage
+	seed *int64
 }
 
 func NewTestBench(stor *storage.Storage) *TestBench {
-	return &TestBench{stor}
+	return &TestBench{stor: stor}
+}
+
+// SetSeed makes the test bench run the operators with a deterministic scheduler seeded with seed, so that repeated
+// runs yield the same results.
+func (t *TestBench) SetSeed(seed int64) {
+	t.seed = &seed
 }
 
 // TestOperator reads a file with test data and its corresponding operator and performs the tests.
@@ -54,6 +62,10 @@ func (t TestBench) Run(opId uuid.UUID, writer io.Writer, failFast bool) (int, in
 			return 0, 0, err
 		}
 
+		if t.seed != nil {
+			o.SetScheduler(core.NewScheduler(*t.seed))
+		}
+
 		o.Main().Out().Bufferize()
 		o.Start()
 
@@ -132,6 +144,35 @@ func testEqual(a, b interface{}) bool {
 		return true
 	}
 
+	// Datetimes, durations and decimals are given by their text encodings or as numbers in test cases
+	switch bv := b.(type) {
+	case time.Time:
+		if as, ok := a.(string); ok {
+			at, err := core.ParseDateTime(as)
+			return err == nil && at.Equal(bv)
+		}
+		if at, ok := a.(time.Time); ok {
+			return at.Equal(bv)
+		}
+	case time.Duration:
+		if as, ok := a.(string); ok {
+			ad, err := core.ParseDuration(as)
+			return err == nil && ad == bv
+		}
+	case core.Decimal:
+		switch av := a.(type) {
+		case string:
+			ad, err := core.ParseDecimal(av)
+			return err == nil && ad.Equal(bv)
+		case float64:
+			return core.NewDecimal(av).Equal(bv)
+		case int:
+			return core.NewDecimal(float64(av)).Equal(bv)
+		case core.Decimal:
+			return av.Equal(bv)
+		}
+	}
+
 	if ai, ok := a.(int); ok {
 		a = float64(ai)
 	}
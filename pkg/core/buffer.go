@@ -0,0 +1,400 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// BufferPolicy decides what happens when an item is pushed into a full port buffer.
+type BufferPolicy string
+
+const (
+	// Block the pushing operator until there is space in the buffer. This is the default.
+	BUFFER_POLICY_BLOCK BufferPolicy = "block"
+	// Drop the oldest item in the buffer to make space for the pushed item. Stream markers are kept.
+	BUFFER_POLICY_DROP_OLDEST BufferPolicy = "drop-oldest"
+	// Drop the pushed item unless it is a stream marker.
+	BUFFER_POLICY_DROP_NEWEST BufferPolicy = "drop-newest"
+	// Write items to a temporary file until there is space in the buffer again.
+	BUFFER_POLICY_SPILL BufferPolicy = "spill"
+)
+
+func (bp BufferPolicy) Validate() error {
+	switch bp {
+	case "", BUFFER_POLICY_BLOCK, BUFFER_POLICY_DROP_OLDEST, BUFFER_POLICY_DROP_NEWEST, BUFFER_POLICY_SPILL:
+		return nil
+	}
+	return fmt.Errorf(`unknown buffer policy "%s"`, bp)
+}
+
+// BufferDef declares the buffer of a connection. It is applied to all buffers the items sent over the connection end
+// up in. Empty fields are taken from the runtime config.
+type BufferDef struct {
+	Size   int          `json:"size,omitempty" yaml:"size,omitempty"`
+	Policy BufferPolicy `json:"policy,omitempty" yaml:"policy,omitempty"`
+}
+
+func (d BufferDef) Validate() error {
+	if d.Size < 0 {
+		return fmt.Errorf("buffer size must not be negative: %d", d.Size)
+	}
+	return d.Policy.Validate()
+}
+
+// RuntimeConfig holds the settings applying to all operators of this process.
+type RuntimeConfig struct {
+	// BufferSize is the capacity of port buffers without declared size
+	BufferSize int
+	// BufferPolicy is applied to port buffers without declared policy
+	BufferPolicy BufferPolicy
+	// SpillDir is the directory spilling buffers write their items to. The default directory for temporary files
+	// is used if it is empty.
+	SpillDir string
+}
+
+func DefaultRuntimeConfig() RuntimeConfig {
+	return RuntimeConfig{
+		BufferSize:   1 << 15,
+		BufferPolicy: BUFFER_POLICY_BLOCK,
+	}
+}
+
+var runtimeConfig atomic.Value
+
+func init() {
+	runtimeConfig.Store(DefaultRuntimeConfig())
+}
+
+// CurrentRuntimeConfig returns the runtime config in effect.
+func CurrentRuntimeConfig() RuntimeConfig {
+	return runtimeConfig.Load().(RuntimeConfig)
+}
+
+// SetRuntimeConfig replaces the runtime config. It only affects buffers created afterwards.
+func SetRuntimeConfig(cfg RuntimeConfig) error {
+	if cfg.BufferSize <= 0 {
+		return fmt.Errorf("buffer size must be positive: %d", cfg.BufferSize)
+	}
+	if err := cfg.BufferPolicy.Validate(); err != nil {
+		return err
+	}
+	if cfg.BufferPolicy == "" {
+		cfg.BufferPolicy = BUFFER_POLICY_BLOCK
+	}
+	runtimeConfig.Store(cfg)
+	return nil
+}
+
+// SetBuffer declares the buffer of this port and all buffers items pushed into this port end up in. Buffers which
+// have been declared closer to them keep their declaration.
+func (p *Port) SetBuffer(def BufferDef) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	return p.setBuffer(def, true)
+}
+
+func (p *Port) setBuffer(def BufferDef, declared bool) error {
+	if !declared && p.bufDef != nil {
+		return nil
+	}
+
+	p.bufDef = &def
+	if p.buf != nil {
+		p.resizeBuffer()
+	}
+
+	if p.sub != nil {
+		if err := p.sub.setBuffer(def, declared); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range p.subs {
+		if err := sub.setBuffer(def, declared); err != nil {
+			return err
+		}
+	}
+
+	// Follow the connections into child operators
+	if p.direction == DIRECTION_IN {
+		for dest := range p.dests {
+			if dest.direction == DIRECTION_IN && dest.operator != nil && dest.operator.parent == p.operator {
+				if err := dest.setBuffer(def, false); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Buffer returns the buffer declaration of this port completed by the runtime config.
+func (p *Port) Buffer() BufferDef {
+	cfg := CurrentRuntimeConfig()
+
+	def := BufferDef{cfg.BufferSize, cfg.BufferPolicy}
+
+	if p.bufDef != nil {
+		if p.bufDef.Size != 0 {
+			def.Size = p.bufDef.Size
+		}
+		if p.bufDef.Policy != "" {
+			def.Policy = p.bufDef.Policy
+		}
+	}
+
+	return def
+}
+
+func (p *Port) makeBuffer() {
+	p.buf = make(chan interface{}, p.Buffer().Size)
+}
+
+// resizeBuffer replaces the buffer by one of the declared size keeping the buffered items
+func (p *Port) resizeBuffer() {
+	size := p.Buffer().Size
+	if cap(p.buf) == size {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	newBuf := make(chan interface{}, size)
+	for {
+		select {
+		case i := <-p.buf:
+			select {
+			case newBuf <- i:
+			default:
+				atomic.AddUint64(&p.dropped, 1)
+			}
+		default:
+			p.buf = newBuf
+			return
+		}
+	}
+}
+
+// dropItem pushes item into the buffer without blocking by dropping either item or the oldest buffered item. Stream
+// markers are never dropped, pushing them blocks if there is no other item to drop.
+func (p *Port) dropItem(ctx context.Context, policy BufferPolicy, item interface{}) error {
+	if policy == BUFFER_POLICY_DROP_NEWEST {
+		select {
+		case p.buf <- item:
+		default:
+			if isMarker(item) {
+				return p.pushBlocking(ctx, item)
+			}
+			atomic.AddUint64(&p.dropped, 1)
+		}
+		return nil
+	}
+
+	p.mutex.Lock()
+	for {
+		select {
+		case p.buf <- item:
+			p.mutex.Unlock()
+			return nil
+		default:
+		}
+		if !p.dropOldest() {
+			break
+		}
+	}
+	// Only stream markers are left, wait for space without blocking the other users of the mutex
+	p.mutex.Unlock()
+	return p.pushBlocking(ctx, item)
+}
+
+// dropOldest removes the oldest buffered item which is not a stream marker. It returns false if there is none.
+func (p *Port) dropOldest() bool {
+	var oldest interface{}
+	select {
+	case oldest = <-p.buf:
+	default:
+		return true
+	}
+
+	if !isMarker(oldest) {
+		atomic.AddUint64(&p.dropped, 1)
+		return true
+	}
+
+	// Take out all items to drop one in the middle
+	kept := []interface{}{oldest}
+	dropped := false
+	for taking := true; taking; {
+		select {
+		case i := <-p.buf:
+			if !dropped && !isMarker(i) {
+				atomic.AddUint64(&p.dropped, 1)
+				dropped = true
+				continue
+			}
+			kept = append(kept, i)
+		default:
+			taking = false
+		}
+	}
+	for _, i := range kept {
+		p.buf <- i
+	}
+	return dropped
+}
+
+func isMarker(item interface{}) bool {
+	switch item.(type) {
+	case BOS, EOS:
+		return true
+	}
+	return false
+}
+
+// spillItem writes item to the spill file unless the buffer has space and nothing has been spilled before
+func (p *Port) spillItem(item interface{}) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.spill == nil || p.spill.count == 0 {
+		select {
+		case p.buf <- item:
+			return nil
+		default:
+		}
+	}
+
+	if p.spill == nil {
+		var err error
+		if p.spill, err = newSpillFile(CurrentRuntimeConfig().SpillDir); err != nil {
+			return err
+		}
+	}
+	return p.spill.push(item)
+}
+
+// unspill moves spilled items back into the buffer as long as it has space
+func (p *Port) unspill() {
+	if p.Buffer().Policy != BUFFER_POLICY_SPILL {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.spill == nil {
+		return
+	}
+
+	for p.spill.count > 0 {
+		item, err := p.spill.front()
+		if err != nil {
+			atomic.AddUint64(&p.dropped, uint64(p.spill.count))
+			p.spill.remove()
+			p.spill = nil
+			return
+		}
+
+		select {
+		case p.buf <- item:
+			p.spill.pop()
+		default:
+			return
+		}
+	}
+}
+
+func (p *Port) removeSpill() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.spill != nil {
+		p.spill.remove()
+		p.spill = nil
+	}
+}
+
+// spillFile stores the items which do not fit into the buffer of a port in the order they have been pushed
+type spillFile struct {
+	w, r    *os.File
+	dec     *json.Decoder
+	count   int
+	next    interface{}
+	peeked  bool
+	markers map[string]*Port
+}
+
+func newSpillFile(dir string) (*spillFile, error) {
+	w, err := os.CreateTemp(dir, "slang-spill-")
+	if err != nil {
+		return nil, err
+	}
+	r, err := os.Open(w.Name())
+	if err != nil {
+		w.Close()
+		os.Remove(w.Name())
+		return nil, err
+	}
+	return &spillFile{w: w, r: r, dec: json.NewDecoder(r), markers: make(map[string]*Port)}, nil
+}
+
+func (s *spillFile) push(item interface{}) error {
+	switch m := item.(type) {
+	case BOS:
+		s.markers[m.src.String()] = m.src
+	case EOS:
+		s.markers[m.src.String()] = m.src
+	case *PH:
+		return errors.New("placeholders cannot be spilled")
+	}
+
+	data, err := json.Marshal(encodeItem(item))
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.count++
+	return nil
+}
+
+// front returns the oldest spilled item without removing it
+func (s *spillFile) front() (interface{}, error) {
+	if !s.peeked {
+		var item interface{}
+		if err := s.dec.Decode(&item); err != nil {
+			return nil, err
+		}
+		s.next = decodeItem(item, s.markers)
+		s.peeked = true
+	}
+	return s.next, nil
+}
+
+func (s *spillFile) pop() {
+	s.next = nil
+	s.peeked = false
+	s.count--
+
+	if s.count == 0 {
+		// Start over to keep the file from growing
+		s.w.Truncate(0)
+		s.w.Seek(0, 0)
+		s.r.Seek(0, 0)
+		s.dec = json.NewDecoder(s.r)
+	}
+}
+
+func (s *spillFile) remove() {
+	s.w.Close()
+	s.r.Close()
+	os.Remove(s.w.Name())
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GenericConstraints maps generic identifiers of a blueprint to the constraints on the types they may be specified
+// with.
+type GenericConstraints map[string]*GenericConstraint
+
+// GenericConstraint restricts the types a generic identifier may be specified with. All given restrictions must
+// hold, an empty constraint accepts any type.
+type GenericConstraint struct {
+	// Types lists the accepted types, e.g. ["number", "decimal"]. "primitive" accepts all primitive types.
+	Types []string `json:"types,omitempty" yaml:"types,omitempty"`
+	// Entries requires a map containing these entries, whose types must satisfy the given constraints in turn
+	Entries map[string]*GenericConstraint `json:"entries,omitempty" yaml:"entries,omitempty"`
+	// Stream requires a stream whose elements satisfy the given constraint
+	Stream *GenericConstraint `json:"stream,omitempty" yaml:"stream,omitempty"`
+}
+
+var primitiveTypes = []string{"primitive", "trigger", "number", "string", "binary", "boolean", "datetime", "duration", "decimal", "enum"}
+
+// Validate checks that the constraint only names known types and does not contradict itself.
+func (c *GenericConstraint) Validate() error {
+	for _, t := range c.Types {
+		if !c.known(t) {
+			return fmt.Errorf(`unknown type "%s"`, t)
+		}
+	}
+
+	if c.Entries != nil && c.Stream != nil {
+		return errors.New("entries and stream cannot both be required")
+	}
+	if c.Entries != nil && len(c.Types) > 0 && !c.accepts("map") {
+		return errors.New("entries require type map")
+	}
+	if c.Stream != nil && len(c.Types) > 0 && !c.accepts("stream") {
+		return errors.New("stream requires type stream")
+	}
+
+	for name, e := range c.Entries {
+		if e == nil {
+			continue
+		}
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	}
+	if c.Stream != nil {
+		if err := c.Stream.Validate(); err != nil {
+			return fmt.Errorf("stream: %s", err)
+		}
+	}
+	return nil
+}
+
+// Check returns an error describing the first violation of the constraint by td. Types which still contain
+// generics or unresolved references are not checked.
+func (c *GenericConstraint) Check(td TypeDef) error {
+	if c == nil || td.Type == "generic" || td.Type == "ref" {
+		return nil
+	}
+
+	if len(c.Types) > 0 && !c.accepts(td.Type) {
+		return fmt.Errorf("must be of type %s, not %s", strings.Join(c.Types, " or "), td.Type)
+	}
+
+	if c.Entries != nil {
+		if td.Type != "map" {
+			return fmt.Errorf("must be a map, not %s", td.Type)
+		}
+		names := make([]string, 0, len(c.Entries))
+		for name := range c.Entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			e, ok := td.Map[name]
+			if !ok {
+				return fmt.Errorf(`must contain entry "%s"`, name)
+			}
+			if err := c.Entries[name].Check(*e); err != nil {
+				return fmt.Errorf(`entry "%s" %s`, name, err)
+			}
+		}
+	}
+
+	if c.Stream != nil {
+		if td.Type != "stream" {
+			return fmt.Errorf("must be a stream, not %s", td.Type)
+		}
+		if err := c.Stream.Check(*td.Stream); err != nil {
+			return fmt.Errorf("stream %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *GenericConstraint) known(typ string) bool {
+	for _, t := range primitiveTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return typ == "stream" || typ == "map" || typ == "union"
+}
+
+func (c *GenericConstraint) accepts(typ string) bool {
+	for _, t := range c.Types {
+		if t == typ {
+			return true
+		}
+		if t == "primitive" {
+			for _, pt := range primitiveTypes {
+				if pt == typ {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Copy returns a deep copy of the constraint.
+func (c GenericConstraint) Copy() GenericConstraint {
+	cpy := GenericConstraint{}
+	if c.Types != nil {
+		cpy.Types = append([]string{}, c.Types...)
+	}
+	if c.Entries != nil {
+		cpy.Entries = make(map[string]*GenericConstraint)
+		for name, e := range c.Entries {
+			if e == nil {
+				cpy.Entries[name] = nil
+				continue
+			}
+			eCpy := e.Copy()
+			cpy.Entries[name] = &eCpy
+		}
+	}
+	if c.Stream != nil {
+		sCpy := c.Stream.Copy()
+		cpy.Stream = &sCpy
+	}
+	return cpy
+}
+
+// Check returns an error if one of the generics violates the constraint declared for its identifier. Identifiers
+// missing in generics are not checked.
+func (gc GenericConstraints) Check(generics map[string]*TypeDef) error {
+	identifiers := make([]string, 0, len(gc))
+	for identifier := range gc {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	for _, identifier := range identifiers {
+		td, ok := generics[identifier]
+		if !ok || td == nil {
+			continue
+		}
+		if err := gc[identifier].Check(*td); err != nil {
+			return fmt.Errorf(`generic "%s" %s`, identifier, err)
+		}
+	}
+	return nil
+}
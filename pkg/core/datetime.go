@@ -0,0 +1,49 @@
+package core
+
+import (
+	"time"
+)
+
+// Items of type "datetime" are time.Time and items of type "duration" time.Duration values. Their text encodings are
+// RFC 3339 timestamps such as "2021-03-04T05:06:07Z" and Go durations such as "1h30m".
+
+// ParseDateTime parses an RFC 3339 timestamp.
+func ParseDateTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// FormatDateTime returns the text encoding of a datetime.
+func FormatDateTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// ParseDuration parses a duration such as "300ms" or "1h30m".
+func ParseDuration(s string) (time.Duration, error) {
+	return time.ParseDuration(s)
+}
+
+// toDateTime converts datetimes and their text encodings into time.Time
+func toDateTime(item interface{}) (time.Time, bool) {
+	switch v := item.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		if t, err := ParseDateTime(v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// toDuration converts durations and their text encodings into time.Duration
+func toDuration(item interface{}) (time.Duration, bool) {
+	switch v := item.(type) {
+	case time.Duration:
+		return v, true
+	case string:
+		if d, err := ParseDuration(v); err == nil {
+			return d, true
+		}
+	}
+	return 0, false
+}
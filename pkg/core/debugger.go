@@ -0,0 +1,326 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Knetic/govaluate"
+)
+
+// Breakpoint pauses goroutines pushing items into a port. Like taps, breakpoints on maps and streams apply to all
+// primitive ports below.
+type Breakpoint struct {
+	Id   int    `json:"id"`
+	Port string `json:"port"`
+	// Condition is an expression which has to evaluate to true for the breakpoint to pause. The item is available
+	// as variable item, entries of map items are available by their names.
+	Condition string `json:"condition,omitempty"`
+
+	expr  *govaluate.EvaluableExpression
+	ports []*Port
+}
+
+// Pause describes an item which has arrived at a port and waits to be pushed on.
+type Pause struct {
+	// Breakpoint is the id of the breakpoint which has been hit, it is 0 when stepping
+	Breakpoint int         `json:"breakpoint,omitempty"`
+	Port       string      `json:"port"`
+	Item       interface{} `json:"item"`
+
+	port   *Port
+	resume chan bool
+}
+
+// Debugger pauses the goroutines of an operator tree when items arrive at ports with breakpoints. Only the
+// goroutine pushing the item is paused, the others run on until they wait for it. Paused items can be inspected and
+// edited before continuing. When several goroutines have been paused, they are resumed one after the other.
+type Debugger struct {
+	mutex       sync.Mutex
+	root        *Operator
+	nextId      int
+	breakpoints map[int]*Breakpoint
+	ports       map[*Port][]*Breakpoint
+	stepping    bool
+	pauses      []*Pause
+	onPause     func(Pause)
+
+	// active is 1 when there are breakpoints or the debugger is stepping, so that pushes can skip the debugger
+	active int32
+}
+
+// NewDebugger returns a debugger without breakpoints.
+func NewDebugger() *Debugger {
+	return &Debugger{
+		breakpoints: make(map[int]*Breakpoint),
+		ports:       make(map[*Port][]*Breakpoint),
+	}
+}
+
+// SetDebugger attaches d to this operator and all of its children. Port references of breakpoints are resolved
+// relative to this operator.
+func (o *Operator) SetDebugger(d *Debugger) {
+	if d != nil {
+		d.root = o
+	}
+	o.setDebugger(d)
+}
+
+func (o *Operator) setDebugger(d *Debugger) {
+	o.debugger = d
+	for _, c := range o.children {
+		c.setDebugger(d)
+	}
+}
+
+// Debugger returns the debugger attached to this operator or nil.
+func (o *Operator) Debugger() *Debugger {
+	return o.debugger
+}
+
+// OnPause sets the function called whenever an item is paused. It is called from the paused goroutine.
+func (d *Debugger) OnPause(f func(Pause)) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.onPause = f
+}
+
+// SetBreakpoint sets a breakpoint on the port referenced by portRef as parsed by ParsePortReference. If condition is
+// not empty, the breakpoint only pauses items for which it evaluates to true.
+func (d *Debugger) SetBreakpoint(portRef string, condition string) (*Breakpoint, error) {
+	if d.root == nil {
+		return nil, errors.New("debugger not attached to an operator")
+	}
+
+	p, err := ParsePortReference(portRef, d.root)
+	if err != nil {
+		return nil, err
+	}
+
+	bp := &Breakpoint{Port: portRef, Condition: condition}
+	if condition != "" {
+		if bp.expr, err = govaluate.NewEvaluableExpression(condition); err != nil {
+			return nil, fmt.Errorf(`invalid condition "%s": %s`, condition, err)
+		}
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.nextId++
+	bp.Id = d.nextId
+	p.WalkPrimitivePorts(func(sub *Port) {
+		bp.ports = append(bp.ports, sub)
+		d.ports[sub] = append(d.ports[sub], bp)
+	})
+	d.breakpoints[bp.Id] = bp
+	d.updateActive()
+
+	return bp, nil
+}
+
+// RemoveBreakpoint removes the breakpoint with the given id. Items paused by it stay paused.
+func (d *Debugger) RemoveBreakpoint(id int) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	bp, ok := d.breakpoints[id]
+	if !ok {
+		return fmt.Errorf("unknown breakpoint %d", id)
+	}
+
+	for _, p := range bp.ports {
+		bps := d.ports[p]
+		for i, b := range bps {
+			if b == bp {
+				bps = append(bps[:i], bps[i+1:]...)
+				break
+			}
+		}
+		if len(bps) == 0 {
+			delete(d.ports, p)
+		} else {
+			d.ports[p] = bps
+		}
+	}
+	delete(d.breakpoints, id)
+	d.updateActive()
+
+	return nil
+}
+
+// Breakpoints returns all breakpoints ordered by their ids.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	bps := make([]Breakpoint, 0, len(d.breakpoints))
+	for _, bp := range d.breakpoints {
+		bps = append(bps, Breakpoint{Id: bp.Id, Port: bp.Port, Condition: bp.Condition})
+	}
+	sort.Slice(bps, func(i, j int) bool { return bps[i].Id < bps[j].Id })
+	return bps
+}
+
+// Paused returns the item currently paused. It returns false if no item is paused.
+func (d *Debugger) Paused() (Pause, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.pauses) == 0 {
+		return Pause{}, false
+	}
+	return *d.pauses[0], true
+}
+
+// SetItem replaces the item currently paused by item. It returns an error if item does not match the type of the
+// port.
+func (d *Debugger) SetItem(item interface{}) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.pauses) == 0 {
+		return errors.New("not paused")
+	}
+
+	pause := d.pauses[0]
+	item = pause.port.parseItem(CleanValue(item))
+	if err := pause.port.Define().VerifyData(item); err != nil {
+		return err
+	}
+	pause.Item = item
+	return nil
+}
+
+// Continue resumes the item currently paused. The goroutines run until the next breakpoint is hit.
+func (d *Debugger) Continue() error {
+	return d.resume(false)
+}
+
+// Step resumes the item currently paused, if any, and pauses the next item arriving at any port.
+func (d *Debugger) Step() {
+	if d.resume(true) != nil {
+		// Not paused, pause as soon as possible
+		d.mutex.Lock()
+		d.stepping = true
+		d.updateActive()
+		d.mutex.Unlock()
+	}
+}
+
+func (d *Debugger) resume(step bool) error {
+	d.mutex.Lock()
+	if len(d.pauses) == 0 {
+		d.mutex.Unlock()
+		return errors.New("not paused")
+	}
+
+	pause := d.pauses[0]
+	d.pauses = d.pauses[1:]
+	d.stepping = step
+	d.updateActive()
+	next, onPause := d.current()
+	d.mutex.Unlock()
+
+	pause.resume <- true
+	if next != nil && onPause != nil {
+		onPause(*next)
+	}
+	return nil
+}
+
+// current returns the item currently paused and the function to announce it
+func (d *Debugger) current() (*Pause, func(Pause)) {
+	if len(d.pauses) == 0 {
+		return nil, nil
+	}
+	return d.pauses[0], d.onPause
+}
+
+func (d *Debugger) updateActive() {
+	if len(d.breakpoints) > 0 || d.stepping {
+		atomic.StoreInt32(&d.active, 1)
+	} else {
+		atomic.StoreInt32(&d.active, 0)
+	}
+}
+
+// arrive is called for every item pushed into primitive port p. It blocks while the item is paused and returns the
+// item to be pushed on. If the operator of p is stopped while paused, the item is dropped and errOperatorStopped is
+// returned.
+func (d *Debugger) arrive(p *Port, item interface{}) (interface{}, error) {
+	if atomic.LoadInt32(&d.active) == 0 || IsMarker(item) {
+		return item, nil
+	}
+
+	d.mutex.Lock()
+	bpId := 0
+	if d.stepping {
+		d.stepping = false
+		d.updateActive()
+	} else if bpId = d.hit(p, item); bpId == 0 {
+		d.mutex.Unlock()
+		return item, nil
+	}
+
+	pause := &Pause{Breakpoint: bpId, Port: p.String(), Item: item, port: p, resume: make(chan bool, 1)}
+	d.pauses = append(d.pauses, pause)
+	first := len(d.pauses) == 1
+	onPause := d.onPause
+	d.mutex.Unlock()
+
+	if first && onPause != nil {
+		onPause(*pause)
+	}
+
+	select {
+	case <-pause.resume:
+	case <-p.context().Done():
+		d.drop(pause)
+		return nil, errOperatorStopped
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return pause.Item, nil
+}
+
+// hit returns the id of the first breakpoint on p whose condition holds for item or 0
+func (d *Debugger) hit(p *Port, item interface{}) int {
+	for _, bp := range d.ports[p] {
+		if bp.expr == nil {
+			return bp.Id
+		}
+
+		params := map[string]interface{}{"item": item}
+		if m, ok := item.(map[string]interface{}); ok {
+			for k, v := range m {
+				params[k] = v
+			}
+		}
+		if ok, err := bp.expr.Evaluate(params); err == nil && ok == true {
+			return bp.Id
+		}
+	}
+	return 0
+}
+
+// drop removes a pause whose operator has been stopped
+func (d *Debugger) drop(pause *Pause) {
+	d.mutex.Lock()
+	wasCurrent := len(d.pauses) > 0 && d.pauses[0] == pause
+	for i, p := range d.pauses {
+		if p == pause {
+			d.pauses = append(d.pauses[:i], d.pauses[i+1:]...)
+			break
+		}
+	}
+	next, onPause := d.current()
+	d.mutex.Unlock()
+
+	if wasCurrent && next != nil && onPause != nil {
+		onPause(*next)
+	}
+}
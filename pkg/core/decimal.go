@@ -0,0 +1,36 @@
+package core
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// Decimal is the item type of ports of type "decimal". Decimals represent numbers such as money values exactly,
+// their text encoding is the plain decimal notation, e.g. "12.30".
+type Decimal = decimal.Decimal
+
+// ParseDecimal parses a number in decimal notation.
+func ParseDecimal(s string) (Decimal, error) {
+	return decimal.NewFromString(s)
+}
+
+// NewDecimal returns the decimal closest to f.
+func NewDecimal(f float64) Decimal {
+	return decimal.NewFromFloat(f)
+}
+
+// toDecimal converts decimals, their text encodings and numbers into Decimal
+func toDecimal(item interface{}) (Decimal, bool) {
+	switch v := item.(type) {
+	case Decimal:
+		return v, true
+	case string:
+		if d, err := ParseDecimal(v); err == nil {
+			return d, true
+		}
+	case float64:
+		return decimal.NewFromFloat(v), true
+	case int:
+		return decimal.NewFromInt(int64(v)), true
+	}
+	return Decimal{}, false
+}
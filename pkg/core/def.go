@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -36,9 +38,15 @@ type InstanceDef struct {
 	Name     string    `json:"-" yaml:"-"`
 	Operator uuid.UUID `json:"operator" yaml:"operator"`
 
+	// Version restricts the versions of the blueprint which may be used, e.g. "^1.2". Any version is used if empty.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+
 	Properties Properties `json:"properties,omitempty" yaml:"properties,omitempty"`
 	Generics   Generics   `json:"generics,omitempty" yaml:"generics,omitempty"`
 
+	// Remote is the name of the worker this instance is run on. It is run locally if empty.
+	Remote string `json:"remote,omitempty" yaml:"remote,omitempty"`
+
 	Geometry *struct {
 		Position struct {
 			X float32 `json:"x" yaml:"x"`
@@ -81,6 +89,9 @@ type BlueprintMetaDef struct {
 	Description      string   `json:"description" yaml:"description"`
 	DocURL           string   `json:"docUrl" yaml:"docUrl"`
 	Tags             []string `json:"tags" yaml:"tags"`
+	Version          string   `json:"version,omitempty" yaml:"version,omitempty"`
+	// Namespace is derived from the folder a blueprint is stored in and not stored itself
+	Namespace string `json:"namespace,omitempty" yaml:"-"`
 
 	valid bool
 }
@@ -92,7 +103,11 @@ type Blueprint struct {
 	DelegateDefs map[string]*DelegateDef `json:"delegates,omitempty" yaml:"delegates,omitempty"`
 	InstanceDefs InstanceDefList         `json:"operators,omitempty" yaml:"operators,omitempty"`
 	PropertyDefs PropertyMap             `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Generics     GenericConstraints      `json:"generics,omitempty" yaml:"generics,omitempty"`
 	Connections  map[string][]string     `json:"connections,omitempty" yaml:"connections,omitempty"`
+	Buffers      map[string]*BufferDef   `json:"buffers,omitempty" yaml:"buffers,omitempty"`
+	Types        TypeDefMap              `json:"types,omitempty" yaml:"types,omitempty"`
+	ErrorPolicy  ErrorPolicy             `json:"errorPolicy,omitempty" yaml:"errorPolicy,omitempty"`
 	Elementary   uuid.UUID               `json:"-" yaml:"-"`
 
 	Meta      BlueprintMetaDef `json:"meta" yaml:"meta"`
@@ -127,14 +142,26 @@ type ServiceDef struct {
 }
 
 type TypeDef struct {
-	// Type is one of "primitive", "number", "string", "boolean", "stream", "map", "generic"
+	// Type is one of "primitive", "number", "string", "boolean", "binary", "datetime", "duration", "decimal", "stream",
+	// "map", "enum", "union", "generic", "ref"
 	Type    string     `json:"type" yaml:"type"`
 	Stream  *TypeDef   `json:"stream,omitempty" yaml:"stream,omitempty"`
 	Map     TypeDefMap `json:"map,omitempty" yaml:"map,omitempty"`
 	Generic string     `json:"generic,omitempty" yaml:"generic,omitempty"`
-
-	// XXX this doesn't belong to here... makes only sense for PropertyDef
+	// Ref names a type declared in the types section of a blueprint as "<blueprint-id>#Name". The blueprint id may
+	// be omitted to refer to the blueprint the ref is used in.
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+	// Enum holds the strings values of type "enum" are restricted to
+	Enum []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	// Union maps the tags of type "union" to the types of their values. Union values are maps with the tag as their
+	// only key, e.g. {"error": "timeout"}.
+	Union TypeDefMap `json:"union,omitempty" yaml:"union,omitempty"`
+
+	// Nullable values may be null
+	Nullable bool `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	// Optional map entries and properties may be missing
 	Optional bool `json:"optional,omitempty" yaml:"optional,omitempty"`
+	// XXX this doesn't belong to here... makes only sense for PropertyDef
 	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
 
 	valid bool
@@ -159,6 +186,10 @@ func (d *InstanceDef) Validate() error {
 		return errors.New(`operator may not be unset`)
 	}
 
+	if _, err := ParseVersionRange(d.Version); err != nil {
+		return fmt.Errorf(`instance "%s": %s`, d.Name, err)
+	}
+
 	d.valid = true
 	return nil
 }
@@ -189,8 +220,10 @@ func (d InstanceDef) Copy(recursive bool) InstanceDef {
 	cpy := InstanceDef{
 		d.Name,
 		d.Operator,
+		d.Version,
 		properties,
 		generics,
+		d.Remote,
 		d.Geometry,
 		d.valid,
 		blueprint,
@@ -217,6 +250,58 @@ func (d *Blueprint) Validate() error {
 		}
 	}
 
+	if err := d.ErrorPolicy.Validate(); err != nil {
+		return err
+	}
+
+	for identifier, c := range d.Generics {
+		if c == nil {
+			return fmt.Errorf(`constraint of generic "%s" must not be null`, identifier)
+		}
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf(`constraint of generic "%s": %s`, identifier, err)
+		}
+	}
+
+	for name, td := range d.Types {
+		if name == "" || strings.ContainsAny(name, "#") {
+			return fmt.Errorf(`invalid type name "%s"`, name)
+		}
+		if td == nil {
+			return fmt.Errorf(`type "%s" must not be null`, name)
+		}
+		if err := td.Validate(); err != nil {
+			return fmt.Errorf(`type "%s": %s`, name, err)
+		}
+	}
+
+	if len(d.Buffers) > 0 {
+		dsts := make(map[string]bool)
+		for _, conns := range d.Connections {
+			for _, dst := range conns {
+				dsts[dst] = true
+			}
+		}
+		for dst, buf := range d.Buffers {
+			if !dsts[dst] {
+				return fmt.Errorf(`buffer declared for unconnected port "%s"`, dst)
+			}
+			if err := buf.Validate(); err != nil {
+				return fmt.Errorf(`buffer of port "%s": %s`, dst, err)
+			}
+		}
+	}
+
+	if d.Meta.Version != "" {
+		if _, err := ParseVersion(d.Meta.Version); err != nil {
+			return err
+		}
+	}
+
+	if errSrv, ok := d.ServiceDefs[ERROR_SERVICE]; ok && !errSrv.Out.Equals(ERROR_TYPEDEF) {
+		return fmt.Errorf(`out port of service "%s" must be of type map{operator: string, error: string}`, ERROR_SERVICE)
+	}
+
 	for _, del := range d.DelegateDefs {
 		if err := del.Validate(); err != nil {
 			return err
@@ -247,8 +332,12 @@ func (d *Blueprint) Validate() error {
 
 // SpecifyGenerics replaces generic types in the operator definition with the types given in the generics map.
 // The values of the map are the according identifiers. It does not touch referenced values such as *TypeDef but
-// replaces them with a reference on a copy.
+// replaces them with a reference on a copy. It returns an error if generics violate the constraints of the blueprint.
 func (d *Blueprint) SpecifyGenericPorts(generics map[string]*TypeDef) error {
+	if err := d.CheckGenerics(generics); err != nil {
+		return err
+	}
+
 	srvs := make(map[string]*ServiceDef)
 	for srvName := range d.ServiceDefs {
 		srv := d.ServiceDefs[srvName].Copy()
@@ -284,6 +373,19 @@ func (d *Blueprint) SpecifyGenericPorts(generics map[string]*TypeDef) error {
 	return nil
 }
 
+// CheckGenerics returns an error if generics violate the constraints declared for the generic identifiers of the
+// blueprint.
+func (d Blueprint) CheckGenerics(generics map[string]*TypeDef) error {
+	if err := d.Generics.Check(generics); err != nil {
+		name := d.Meta.Name
+		if name == "" {
+			name = d.Id.String()
+		}
+		return fmt.Errorf("operator %s: %s", name, err)
+	}
+	return nil
+}
+
 func (d Blueprint) GenericsSpecified() error {
 	for _, srv := range d.ServiceDefs {
 		if err := srv.In.GenericsSpecified(); err != nil {
@@ -330,7 +432,26 @@ func (d Blueprint) Copy(recursive bool) Blueprint {
 		propDefs[k] = &c
 	}
 
+	var typeDefs TypeDefMap = nil
+	if d.Types != nil {
+		typeDefs = make(TypeDefMap)
+		for k, v := range d.Types {
+			c := v.Copy()
+			typeDefs[k] = &c
+		}
+	}
+
+	var genDefs GenericConstraints = nil
+	if d.Generics != nil {
+		genDefs = make(GenericConstraints)
+		for k, v := range d.Generics {
+			c := v.Copy()
+			genDefs[k] = &c
+		}
+	}
+
 	var connDefs map[string][]string = nil
+	var bufDefs map[string]*BufferDef = nil
 	var insDefs InstanceDefList = nil
 
 	if d.Elementary == uuid.Nil {
@@ -343,6 +464,14 @@ func (d Blueprint) Copy(recursive bool) Blueprint {
 			connDefs[k] = c
 		}
 
+		if d.Buffers != nil {
+			bufDefs = make(map[string]*BufferDef)
+			for k, v := range d.Buffers {
+				c := *v
+				bufDefs[k] = &c
+			}
+		}
+
 		insDefs = InstanceDefList{}
 		for _, v := range d.InstanceDefs {
 			insCpy := v.Copy(recursive)
@@ -356,7 +485,11 @@ func (d Blueprint) Copy(recursive bool) Blueprint {
 		dlgDefs,
 		insDefs,
 		propDefs,
+		genDefs,
 		connDefs,
+		bufDefs,
+		typeDefs,
+		d.ErrorPolicy,
 		d.Elementary,
 		d.Meta,
 		d.TestCases,
@@ -373,6 +506,10 @@ func (def *Blueprint) SpecifyOperator(gens Generics, props Properties) error {
 		}
 	}
 
+	if err := def.CheckGenerics(gens); err != nil {
+		return err
+	}
+
 	def.specifyGenericsOnPortGroups(gens)
 	if err := def.applyPropertiesOnPortGroups(props); err != nil {
 		return err
@@ -405,6 +542,11 @@ func (def *Blueprint) applyPropertiesOnPortGroups(props Properties) error {
 			return err
 		}
 
+		// Optional properties may be left unset
+		if propVal == nil && propDef.Optional {
+			continue
+		}
+
 		if err := propDef.VerifyData(propVal); err != nil {
 			return err
 		}
@@ -545,7 +687,7 @@ func (d DelegateDef) Copy() DelegateDef {
 // TYPE DEFINITION
 
 func (d TypeDef) Equals(p TypeDef) bool {
-	if d.Type != p.Type {
+	if d.Type != p.Type || d.Nullable != p.Nullable || d.Optional != p.Optional {
 		return false
 	}
 
@@ -567,11 +709,49 @@ func (d TypeDef) Equals(p TypeDef) bool {
 		if !d.Stream.Equals(*p.Stream) {
 			return false
 		}
+	} else if d.Type == "enum" {
+		if !enumSubset(d.Enum, p.Enum) || !enumSubset(p.Enum, d.Enum) {
+			return false
+		}
+	} else if d.Type == "ref" {
+		if d.Ref != p.Ref {
+			return false
+		}
+	} else if d.Type == "union" {
+		if len(d.Union) != len(p.Union) {
+			return false
+		}
+
+		for tag, v := range d.Union {
+			pv, ok := p.Union[tag]
+			if !ok || !v.Equals(*pv) {
+				return false
+			}
+		}
 	}
 
 	return true
 }
 
+// enumSubset returns true if all values of sub are contained in values
+func enumSubset(sub []string, values []string) bool {
+	for _, v := range sub {
+		if !enumContains(values, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func enumContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *TypeDef) Valid() bool {
 	return d.valid
 }
@@ -582,7 +762,7 @@ func (d *TypeDef) Validate() error {
 	}
 
 	// type "unspecified" is only allowed when defining blueprint port types
-	validTypes := []string{"generic", "primitive", "trigger", "number", "string", "binary", "boolean", "stream", "map", "unspecified"}
+	validTypes := []string{"generic", "primitive", "trigger", "number", "string", "binary", "boolean", "datetime", "duration", "decimal", "stream", "map", "enum", "union", "ref", "unspecified"}
 	found := false
 	for _, t := range validTypes {
 		if t == d.Type {
@@ -613,6 +793,33 @@ func (d *TypeDef) Validate() error {
 				return err
 			}
 		}
+	} else if d.Type == "enum" {
+		if len(d.Enum) == 0 {
+			return errors.New("enum values missing")
+		}
+		seen := make(map[string]bool)
+		for _, v := range d.Enum {
+			if seen[v] {
+				return fmt.Errorf(`duplicate enum value "%s"`, v)
+			}
+			seen[v] = true
+		}
+	} else if d.Type == "ref" {
+		if _, _, err := ParseTypeRef(d.Ref); err != nil {
+			return err
+		}
+	} else if d.Type == "union" {
+		if len(d.Union) == 0 {
+			return errors.New("union variants missing")
+		}
+		for tag, v := range d.Union {
+			if v == nil {
+				return fmt.Errorf(`union variant "%s" must not be null`, tag)
+			}
+			if err := v.Validate(); err != nil {
+				return fmt.Errorf("%s: %s", tag, err.Error())
+			}
+		}
 	}
 
 	d.valid = true
@@ -635,12 +842,30 @@ func (d TypeDef) Copy() TypeDef {
 		}
 	}
 
+	var tEnum []string = nil
+	if d.Enum != nil {
+		tEnum = append([]string{}, d.Enum...)
+	}
+
+	var tUnion map[string]*TypeDef = nil
+	if d.Union != nil {
+		tUnion = make(map[string]*TypeDef)
+		for k, v := range d.Union {
+			cpy := v.Copy()
+			tUnion[k] = &cpy
+		}
+	}
+
 	return TypeDef{
 		d.Type,
 		tStr,
 		tMap,
 		d.Generic,
-		d.Optional, // only relevant for PropertyDef
+		d.Ref,
+		tEnum,
+		tUnion,
+		d.Nullable,
+		d.Optional,
 		d.Default, // only relevant for PropertyDef
 		d.valid,
 	}
@@ -652,7 +877,10 @@ func (d TypeDef) Copy() TypeDef {
 func (d *TypeDef) SpecifyGenerics(generics map[string]*TypeDef) error {
 	for identifier, pd := range generics {
 		if d.Generic == identifier {
+			nullable, optional := d.Nullable, d.Optional
 			*d = pd.Copy()
+			d.Nullable = d.Nullable || nullable
+			d.Optional = d.Optional || optional
 			return nil
 		}
 
@@ -670,6 +898,16 @@ func (d *TypeDef) SpecifyGenerics(generics map[string]*TypeDef) error {
 				mapCpy[k] = &eCpy
 			}
 			d.Map = mapCpy
+		} else if d.Type == "union" {
+			unionCpy := make(map[string]*TypeDef)
+			for k, v := range d.Union {
+				vCpy := v.Copy()
+				if err := vCpy.SpecifyGenerics(generics); err != nil {
+					return err
+				}
+				unionCpy[k] = &vCpy
+			}
+			d.Union = unionCpy
 		}
 	}
 	return nil
@@ -688,31 +926,77 @@ func (d TypeDef) GenericsSpecified() error {
 				return err
 			}
 		}
+	} else if d.Type == "union" {
+		for _, v := range d.Union {
+			if err := v.GenericsSpecified(); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// VerifyData returns an error if data does not match this type. Only optional map entries may be missing. Null is
+// accepted by nullable types and, as before nullable types existed, by streams, maps, primitives, triggers, strings,
+// numbers and booleans. Use VerifyNulls to find nulls where the type is not nullable.
 func (d TypeDef) VerifyData(data interface{}) error {
 	switch v := data.(type) {
 	case nil:
-		if d.Type == "stream" || d.Type == "primitive" || d.Type == "trigger" || d.Type == "string" || d.Type == "number" || d.Type == "boolean" || d.Type == "map" {
+		if d.Nullable || d.Type == "stream" || d.Type == "primitive" || d.Type == "trigger" || d.Type == "string" || d.Type == "number" || d.Type == "boolean" || d.Type == "map" {
 			return nil
 		}
+		return fmt.Errorf("expected *%s*, got null", d.Type)
 	case string:
 		if d.Type == "string" || d.Type == "primitive" || d.Type == "trigger" {
 			return nil
 		}
+		if d.Type == "datetime" {
+			if _, err := ParseDateTime(v); err != nil {
+				return fmt.Errorf(`expected *datetime*, got "%s": %s`, v, err)
+			}
+			return nil
+		}
+		if d.Type == "duration" {
+			if _, err := ParseDuration(v); err != nil {
+				return fmt.Errorf(`expected *duration*, got "%s": %s`, v, err)
+			}
+			return nil
+		}
+		if d.Type == "decimal" {
+			if _, err := ParseDecimal(v); err != nil {
+				return fmt.Errorf(`expected *decimal*, got "%s": %s`, v, err)
+			}
+			return nil
+		}
+		if d.Type == "enum" {
+			if enumContains(d.Enum, v) {
+				return nil
+			}
+			return fmt.Errorf(`expected one of %s, got "%s"`, strings.Join(d.Enum, ", "), v)
+		}
 	case Binary:
 		if d.Type == "binary" {
 			return nil
 		}
 	case int:
-		if d.Type == "number" || d.Type == "primitive" || d.Type == "trigger" {
+		if d.Type == "number" || d.Type == "decimal" || d.Type == "primitive" || d.Type == "trigger" {
 			return nil
 		}
 	case float64:
-		if d.Type == "number" || d.Type == "primitive" || d.Type == "trigger" {
+		if d.Type == "number" || d.Type == "decimal" || d.Type == "primitive" || d.Type == "trigger" {
+			return nil
+		}
+	case time.Time:
+		if d.Type == "datetime" || d.Type == "primitive" || d.Type == "trigger" {
+			return nil
+		}
+	case time.Duration:
+		if d.Type == "duration" || d.Type == "primitive" || d.Type == "trigger" {
+			return nil
+		}
+	case Decimal:
+		if d.Type == "decimal" || d.Type == "primitive" || d.Type == "trigger" {
 			return nil
 		}
 	case bool:
@@ -723,14 +1007,32 @@ func (d TypeDef) VerifyData(data interface{}) error {
 		if d.Type == "trigger" {
 			return nil
 		}
+		if d.Type == "union" {
+			if len(v) != 1 {
+				return fmt.Errorf("expected union value with a single tag, got %d entries", len(v))
+			}
+			for tag, value := range v {
+				variant, ok := d.Union[tag]
+				if !ok {
+					return fmt.Errorf(`unknown union tag "%s"`, tag)
+				}
+				if err := variant.VerifyData(value); err != nil {
+					return fmt.Errorf("%s: %s", tag, err.Error())
+				}
+			}
+			return nil
+		}
 		if d.Type == "map" {
 			for k, sub := range d.Map {
 				e, ok := v[k]
 				if !ok {
+					if sub.Optional {
+						continue
+					}
 					return errors.New("missing entry " + k)
 				}
 				if err := sub.VerifyData(e); err != nil {
-					return err
+					return fmt.Errorf("%s: %s", k, err.Error())
 				}
 			}
 			return nil
@@ -751,11 +1053,109 @@ func (d TypeDef) VerifyData(data interface{}) error {
 	return fmt.Errorf("expected *%s*, got *%v*", d.Type, data)
 }
 
+// VerifyNulls returns an error if data contains null where this type is neither nullable, a primitive nor a trigger.
+// It only looks for nulls, the rest of data is checked by VerifyData.
+func (d TypeDef) VerifyNulls(data interface{}) error {
+	switch v := data.(type) {
+	case nil:
+		if d.Nullable || d.Type == "primitive" || d.Type == "trigger" {
+			return nil
+		}
+		return fmt.Errorf("expected *%s*, got null", d.Type)
+	case map[string]interface{}:
+		if d.Type == "union" {
+			for tag, value := range v {
+				if variant, ok := d.Union[tag]; ok {
+					if err := variant.VerifyNulls(value); err != nil {
+						return fmt.Errorf("%s: %s", tag, err.Error())
+					}
+				}
+			}
+		}
+		if d.Type == "map" {
+			for k, sub := range d.Map {
+				if e, ok := v[k]; ok {
+					if err := sub.VerifyNulls(e); err != nil {
+						return fmt.Errorf("%s: %s", k, err.Error())
+					}
+				}
+			}
+		}
+	case []interface{}:
+		if d.Type == "stream" && d.Stream != nil {
+			for _, v := range v {
+				if err := d.Stream.VerifyNulls(v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// String returns a short notation of the type, e.g. "map{a: number?, b?: stream[string]}". Nullable types are
+// marked by a trailing and optional map entries by a leading question mark.
+func (d TypeDef) String() string {
+	var s string
+	switch d.Type {
+	case "stream":
+		if d.Stream != nil {
+			s = "stream[" + d.Stream.String() + "]"
+		} else {
+			s = "stream"
+		}
+	case "map":
+		keys := make([]string, 0, len(d.Map))
+		for k := range d.Map {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		entries := make([]string, len(keys))
+		for i, k := range keys {
+			if d.Map[k].Optional {
+				entries[i] = k + "?: " + d.Map[k].String()
+			} else {
+				entries[i] = k + ": " + d.Map[k].String()
+			}
+		}
+		s = "map{" + strings.Join(entries, ", ") + "}"
+	case "enum":
+		s = "enum(" + strings.Join(d.Enum, "|") + ")"
+	case "union":
+		tags := make([]string, 0, len(d.Union))
+		for tag := range d.Union {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+
+		variants := make([]string, len(tags))
+		for i, tag := range tags {
+			variants[i] = tag + ": " + d.Union[tag].String()
+		}
+		s = "union{" + strings.Join(variants, ", ") + "}"
+	case "generic":
+		s = "$" + d.Generic
+	case "ref":
+		s = d.Ref
+	default:
+		s = d.Type
+	}
+
+	if d.Nullable {
+		s += "?"
+	}
+	return s
+}
+
 // TYPE DEF MAP
 
 func (t TypeDefMap) VerifyData(data map[string]interface{}) error {
 	for k, v := range t {
 		if _, ok := data[k]; !ok {
+			if v.Optional {
+				continue
+			}
 			return errors.New("missing entry " + k)
 		}
 		if err := v.VerifyData(data[k]); err != nil {
@@ -790,7 +1190,8 @@ func (t TypeDefMap) GenericsSpecified() error {
 }
 
 func (d *TypeDef) ApplyProperties(props Properties, propDefs PropertyMap) error {
-	if d.Type == "primitive" || d.Type == "string" || d.Type == "number" || d.Type == "boolean" || d.Type == "trigger" {
+	if d.Type == "primitive" || d.Type == "string" || d.Type == "number" || d.Type == "boolean" || d.Type == "trigger" || d.Type == "enum" || d.Type == "union" ||
+		d.Type == "datetime" || d.Type == "duration" || d.Type == "decimal" {
 		return nil
 	}
 	var parsed []string
@@ -0,0 +1,417 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type ChangeKind string
+
+const (
+	CHANGE_ADDED    ChangeKind = "added"
+	CHANGE_REMOVED  ChangeKind = "removed"
+	CHANGE_MODIFIED ChangeKind = "modified"
+)
+
+// BlueprintChange is a single semantic difference between two blueprints. Path addresses the changed part of the
+// blueprint, e.g. "operators/parser/properties/expression" or "connections/(parser -> )".
+type BlueprintChange struct {
+	Kind ChangeKind  `json:"kind"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+func (c BlueprintChange) String() string {
+	switch c.Kind {
+	case CHANGE_ADDED:
+		return fmt.Sprintf("+ %s: %s", c.Path, diffValueString(c.New))
+	case CHANGE_REMOVED:
+		return fmt.Sprintf("- %s: %s", c.Path, diffValueString(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, diffValueString(c.Old), diffValueString(c.New))
+	}
+}
+
+// MergeConflict is a part of a blueprint which has been changed differently in both merged blueprints.
+type MergeConflict struct {
+	Path   string      `json:"path"`
+	Base   interface{} `json:"base,omitempty"`
+	Ours   interface{} `json:"ours,omitempty"`
+	Theirs interface{} `json:"theirs,omitempty"`
+}
+
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("%s: base %s, ours %s, theirs %s", c.Path, diffValueString(c.Base), diffValueString(c.Ours), diffValueString(c.Theirs))
+}
+
+// blueprintEntry is a part of a blueprint which is compared as a whole
+type blueprintEntry struct {
+	path     []string
+	value    interface{}
+	geometry bool
+	// item entries represent named items such as instances and services, their value is only used for display
+	item bool
+}
+
+type blueprintEntries map[string]*blueprintEntry
+
+// DiffBlueprints returns the semantic differences between the blueprints old and new. Changes which only move
+// items in the editor, i.e. geometry changes, are ignored. Instances, services, delegates and test cases which have
+// been added or removed are reported as a single change.
+func DiffBlueprints(old Blueprint, new Blueprint) ([]BlueprintChange, error) {
+	oldEntries, err := flattenBlueprint(old)
+	if err != nil {
+		return nil, err
+	}
+	newEntries, err := flattenBlueprint(new)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]BlueprintChange, 0)
+	for _, key := range oldEntries.keys(newEntries) {
+		oe, ne := oldEntries[key], newEntries[key]
+
+		switch {
+		case (oe != nil && oe.geometry) || (ne != nil && ne.geometry):
+			continue
+		case oe == nil:
+			if newEntries.itemAdded(ne, oldEntries) {
+				continue
+			}
+			changes = append(changes, BlueprintChange{CHANGE_ADDED, diffPath(ne.path), nil, ne.value})
+		case ne == nil:
+			if oldEntries.itemAdded(oe, newEntries) {
+				continue
+			}
+			changes = append(changes, BlueprintChange{CHANGE_REMOVED, diffPath(oe.path), oe.value, nil})
+		case !oe.item && !reflect.DeepEqual(oe.value, ne.value):
+			changes = append(changes, BlueprintChange{CHANGE_MODIFIED, diffPath(oe.path), oe.value, ne.value})
+		}
+	}
+
+	return changes, nil
+}
+
+// MergeBlueprints merges the changes made to base in ours and theirs. Parts which have been changed differently in
+// both blueprints are taken from ours and reported as conflicts. Conflicting geometry changes are resolved silently.
+func MergeBlueprints(base Blueprint, ours Blueprint, theirs Blueprint) (Blueprint, []MergeConflict, error) {
+	baseEntries, err := flattenBlueprint(base)
+	if err != nil {
+		return Blueprint{}, nil, err
+	}
+	ourEntries, err := flattenBlueprint(ours)
+	if err != nil {
+		return Blueprint{}, nil, err
+	}
+	theirEntries, err := flattenBlueprint(theirs)
+	if err != nil {
+		return Blueprint{}, nil, err
+	}
+
+	merged := make(blueprintEntries)
+	conflicts := make([]MergeConflict, 0)
+
+	for _, key := range baseEntries.keys(ourEntries, theirEntries) {
+		be, oe, te := baseEntries[key], ourEntries[key], theirEntries[key]
+
+		var e *blueprintEntry
+		switch {
+		case oe.equals(te) || te.equals(be):
+			e = oe
+		case oe.equals(be):
+			e = te
+		default:
+			e = oe
+			if !(oe != nil && oe.geometry || te != nil && te.geometry) {
+				path := oe.anyPath(te, be)
+				conflicts = append(conflicts, MergeConflict{diffPath(path), be.displayValue(), oe.displayValue(), te.displayValue()})
+			}
+		}
+
+		if e != nil {
+			merged[key] = e
+		}
+	}
+
+	// Drop entries and connections of instances which have been removed on one side
+	removed := make(map[string]bool)
+	for _, key := range baseEntries.keys(ourEntries, theirEntries) {
+		if e := merged[key]; e == nil {
+			if e = ourEntries[key]; e == nil {
+				e = theirEntries[key]
+			}
+			if e != nil && e.item && e.path[0] == "operators" {
+				removed[e.path[1]] = true
+			}
+		}
+	}
+
+	for _, key := range merged.keys() {
+		e := merged[key]
+		if e.item {
+			continue
+		}
+
+		if e.path[0] == "connections" {
+			if !refersToAny(e.path[1], removed) && !refersToAny(e.path[2], removed) {
+				continue
+			}
+		} else if e.path[0] == "buffers" {
+			if !refersToAny(e.path[1], removed) {
+				continue
+			}
+		} else if parent := merged.parentItem(e); parent == "" || merged[parent] != nil {
+			continue
+		}
+
+		delete(merged, key)
+		if !baseEntries[key].equals(e) {
+			conflicts = append(conflicts, MergeConflict{diffPath(e.path), baseEntries[key].displayValue(), ourEntries[key].displayValue(), theirEntries[key].displayValue()})
+		}
+	}
+
+	bp, err := merged.blueprint()
+	if err != nil {
+		return Blueprint{}, nil, err
+	}
+	return bp, conflicts, nil
+}
+
+func (e *blueprintEntry) equals(f *blueprintEntry) bool {
+	if e == nil || f == nil {
+		return e == f
+	}
+	if e.item && f.item {
+		return true
+	}
+	return reflect.DeepEqual(e.value, f.value)
+}
+
+func (e *blueprintEntry) displayValue() interface{} {
+	if e == nil {
+		return nil
+	}
+	return e.value
+}
+
+func (e *blueprintEntry) anyPath(others ...*blueprintEntry) []string {
+	for _, o := range append([]*blueprintEntry{e}, others...) {
+		if o != nil {
+			return o.path
+		}
+	}
+	return nil
+}
+
+// keys returns the sorted keys of all given entries
+func (es blueprintEntries) keys(others ...blueprintEntries) []string {
+	keySet := make(map[string]bool)
+	for _, entries := range append([]blueprintEntries{es}, others...) {
+		for key := range entries {
+			keySet[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parentItem returns the key of the item e belongs to or an empty string if it does not belong to an item
+func (es blueprintEntries) parentItem(e *blueprintEntry) string {
+	if len(e.path) < 2 {
+		return ""
+	}
+	switch e.path[0] {
+	case "services", "delegates", "operators":
+		return entryKey(e.path[:2])
+	}
+	return ""
+}
+
+// itemAdded returns true if e belongs to an item which does not exist in others, so that e is covered by the
+// change of the whole item
+func (es blueprintEntries) itemAdded(e *blueprintEntry, others blueprintEntries) bool {
+	parent := es.parentItem(e)
+	return parent != "" && !e.item && others[parent] == nil
+}
+
+// blueprint reassembles a blueprint from its entries
+func (es blueprintEntries) blueprint() (Blueprint, error) {
+	doc := make(map[string]interface{})
+	connections := make(map[string][]interface{})
+	var tests []interface{}
+
+	for _, key := range es.keys() {
+		e := es[key]
+		switch e.path[0] {
+		case "connections":
+			connections[e.path[1]] = append(connections[e.path[1]], e.path[2])
+			continue
+		case "tests":
+			tests = append(tests, e.value)
+			continue
+		}
+
+		if e.item {
+			setDocValue(doc, e.path, make(map[string]interface{}), false)
+			continue
+		}
+		setDocValue(doc, e.path, e.value, true)
+	}
+
+	if len(connections) > 0 {
+		doc["connections"] = connections
+	}
+	if tests != nil {
+		doc["tests"] = tests
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return Blueprint{}, err
+	}
+
+	var bp Blueprint
+	if err := json.Unmarshal(data, &bp); err != nil {
+		return Blueprint{}, err
+	}
+	return bp, nil
+}
+
+func setDocValue(doc map[string]interface{}, path []string, value interface{}, overwrite bool) {
+	for _, seg := range path[:len(path)-1] {
+		sub, ok := doc[seg].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			doc[seg] = sub
+		}
+		doc = sub
+	}
+	if _, ok := doc[path[len(path)-1]]; ok && !overwrite {
+		return
+	}
+	doc[path[len(path)-1]] = value
+}
+
+// flattenBlueprint splits the blueprint into entries which are compared as a whole
+func flattenBlueprint(bp Blueprint) (blueprintEntries, error) {
+	data, err := json.Marshal(&bp)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	es := make(blueprintEntries)
+	add := func(e *blueprintEntry) {
+		es[entryKey(e.path)] = e
+	}
+
+	for key, value := range doc {
+		switch key {
+		case "services", "delegates", "operators":
+			items, _ := value.(map[string]interface{})
+			for name, item := range items {
+				add(&blueprintEntry{[]string{key, name}, withoutGeometry(item), false, true})
+				fields, _ := item.(map[string]interface{})
+				for field, fieldValue := range fields {
+					subs, isMap := fieldValue.(map[string]interface{})
+					if key == "operators" && (field == "properties" || field == "generics") && isMap {
+						for subName, subValue := range subs {
+							add(&blueprintEntry{[]string{key, name, field, subName}, subValue, false, false})
+						}
+						continue
+					}
+					add(&blueprintEntry{[]string{key, name, field}, fieldValue, field == "geometry", false})
+				}
+			}
+		case "properties", "meta", "buffers", "types", "generics":
+			fields, _ := value.(map[string]interface{})
+			for field, fieldValue := range fields {
+				add(&blueprintEntry{[]string{key, field}, fieldValue, false, false})
+			}
+		case "connections":
+			conns, _ := value.(map[string]interface{})
+			for src, dsts := range conns {
+				dstList, _ := dsts.([]interface{})
+				for _, dst := range dstList {
+					add(&blueprintEntry{[]string{key, src, fmt.Sprint(dst)}, true, false, false})
+				}
+			}
+		case "tests":
+			tests, _ := value.([]interface{})
+			for i, tc := range tests {
+				name := fmt.Sprint(i)
+				if m, ok := tc.(map[string]interface{}); ok && m["name"] != nil && m["name"] != "" {
+					name = fmt.Sprint(m["name"])
+				}
+				add(&blueprintEntry{[]string{key, name}, tc, false, false})
+			}
+		default:
+			add(&blueprintEntry{[]string{key}, value, key == "geometry", false})
+		}
+	}
+
+	return es, nil
+}
+
+var portRefSeparators = regexp.MustCompile(`[()~.@]`)
+
+// refersToAny returns true if the port reference ref mentions one of the given instance names
+func refersToAny(ref string, instances map[string]bool) bool {
+	for _, name := range portRefSeparators.Split(ref, -1) {
+		if instances[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutGeometry(value interface{}) interface{} {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	cpy := make(map[string]interface{})
+	for k, v := range m {
+		if k != "geometry" {
+			cpy[k] = v
+		}
+	}
+	return cpy
+}
+
+func entryKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+func diffPath(path []string) string {
+	if len(path) == 3 && path[0] == "connections" {
+		return fmt.Sprintf("connections/%s -> %s", path[1], path[2])
+	}
+	return strings.Join(path, "/")
+}
+
+func diffValueString(value interface{}) string {
+	if value == nil {
+		return "nil"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Bitspark/slang/pkg/log"
+)
+
+// ERROR_SERVICE is the name of the optional service an operator can define to receive errors of its children.
+// Its out port must be of type ERROR_TYPEDEF.
+var ERROR_SERVICE = "error"
+
+// ERROR_TYPEDEF is the type of items emitted by the error service.
+var ERROR_TYPEDEF = TypeDef{
+	Type: "map",
+	Map: map[string]*TypeDef{
+		"operator": {Type: "string"},
+		"error":    {Type: "string"},
+	},
+}
+
+// ErrorPolicy decides what happens when an elementary operator fails while processing an item.
+type ErrorPolicy string
+
+const (
+	// Stop the whole operator tree.
+	ERROR_POLICY_FAIL ErrorPolicy = "fail"
+	// Log the error and emit nil in place of the item which could not be processed. This is the default, as
+	// operators used to behave like this before error policies existed.
+	ERROR_POLICY_SKIP ErrorPolicy = "skip"
+	// Like skip, but additionally push the error to the error service of the nearest ancestor defining one.
+	ERROR_POLICY_ROUTE ErrorPolicy = "route"
+)
+
+func (ep ErrorPolicy) Validate() error {
+	switch ep {
+	case "", ERROR_POLICY_FAIL, ERROR_POLICY_SKIP, ERROR_POLICY_ROUTE:
+		return nil
+	}
+	return fmt.Errorf(`unknown error policy "%s"`, ep)
+}
+
+// ErrorHandler is called for every error raised within an operator tree, regardless of the error policy.
+type ErrorHandler func(err *SlangError)
+
+// SlangError is an error raised by an operator at runtime.
+type SlangError struct {
+	// Operator is the path of instance names from the root operator down to the failing operator.
+	Operator []string
+	Cause    error
+}
+
+func NewSlangError(o *Operator, cause error) *SlangError {
+	var path []string
+	for op := o; op != nil && op.parent != nil; op = op.parent {
+		path = append([]string{op.name}, path...)
+	}
+	return &SlangError{path, cause}
+}
+
+// Path returns the operator path separated by slashes.
+func (e *SlangError) Path() string {
+	return strings.Join(e.Operator, "/")
+}
+
+func (e *SlangError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path(), e.Cause)
+}
+
+func (e *SlangError) Unwrap() error {
+	return e.Cause
+}
+
+// Item returns the error as item of type ERROR_TYPEDEF.
+func (e *SlangError) Item() map[string]interface{} {
+	return map[string]interface{}{
+		"operator": e.Path(),
+		"error":    e.Cause.Error(),
+	}
+}
+
+// SetErrorPolicy sets the error policy of this operator. An empty policy means the policy is inherited.
+func (o *Operator) SetErrorPolicy(ep ErrorPolicy) {
+	o.errorPolicy = ep
+}
+
+// ErrorPolicy returns the effective error policy of this operator, which is inherited from the nearest ancestor
+// setting one.
+func (o *Operator) ErrorPolicy() ErrorPolicy {
+	for op := o; op != nil; op = op.parent {
+		if op.errorPolicy != "" {
+			return op.errorPolicy
+		}
+	}
+	return ERROR_POLICY_SKIP
+}
+
+// SetErrorHandler sets a handler which is notified about all errors raised by this operator and its descendants.
+func (o *Operator) SetErrorHandler(h ErrorHandler) {
+	o.errorHandler = h
+}
+
+// Err returns the error which made this operator tree fail, if any.
+func (o *Operator) Err() error {
+	root := o.root()
+	root.stopMutex.Lock()
+	defer root.stopMutex.Unlock()
+	return root.err
+}
+
+// Fail reports that the operator could not process the current item. It returns true if the operator should go on
+// with the next item, in which case it has to emit nil in place of the failed item. It returns false if the operator
+// tree is being stopped, in which case the operator function should return.
+func (o *Operator) Fail(err error) bool {
+	serr := o.slangError(err)
+
+	switch o.ErrorPolicy() {
+	case ERROR_POLICY_SKIP, ERROR_POLICY_ROUTE:
+		o.Report(serr)
+		return true
+	}
+
+	o.notifyError(serr)
+	log.Error(serr)
+	o.fail(serr)
+	return false
+}
+
+// Report reports an error the operator has handled itself, e.g. by emitting it on one of its out ports. In contrast
+// to Fail, it never stops the operator tree. Handlers are notified and, if the error policy is ERROR_POLICY_ROUTE,
+// the error is pushed to the error service.
+func (o *Operator) Report(err error) {
+	serr := o.slangError(err)
+	o.notifyError(serr)
+
+	if o.ErrorPolicy() == ERROR_POLICY_ROUTE {
+		if errSrv := o.errorService(); errSrv != nil {
+			errSrv.Out().PushContext(o.Context(), serr.Item())
+			return
+		}
+	}
+	log.Warn(serr)
+}
+
+func (o *Operator) slangError(err error) *SlangError {
+	if serr, ok := err.(*SlangError); ok {
+		return serr
+	}
+	return NewSlangError(o, err)
+}
+
+func (o *Operator) fail(err *SlangError) {
+	root := o.root()
+	root.stopMutex.Lock()
+	if root.err == nil {
+		root.err = err
+	}
+	root.stopMutex.Unlock()
+	o.Stop()
+}
+
+func (o *Operator) notifyError(err *SlangError) {
+	for op := o; op != nil; op = op.parent {
+		if op.errorHandler != nil {
+			op.errorHandler(err)
+		}
+	}
+}
+
+func (o *Operator) errorService() *Service {
+	if o.errorSrv != nil {
+		return o.errorSrv
+	}
+	for op := o.parent; op != nil; op = op.parent {
+		if srv := op.Service(ERROR_SERVICE); srv != nil {
+			return srv
+		}
+	}
+	return nil
+}
+
+func (o *Operator) root() *Operator {
+	root := o
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"strings"
+	"time"
 )
 
 func (b *Binary) UnmarshalJSON(bytes []byte) error {
@@ -23,3 +24,29 @@ func (b *Binary) UnmarshalJSON(bytes []byte) error {
 func (b Binary) MarshalJSON() ([]byte, error) {
 	return []byte("\"base64:" + base64.StdEncoding.EncodeToString(b) + "\""), nil
 }
+
+// PlainItem replaces the datetimes, durations and decimals within item by their text encodings. Use it to serialize
+// items to plain JSON, which has no representation of these types.
+func PlainItem(item interface{}) interface{} {
+	switch v := item.(type) {
+	case time.Time:
+		return FormatDateTime(v)
+	case time.Duration:
+		return v.String()
+	case Decimal:
+		return v.String()
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, el := range v {
+			items[i] = PlainItem(el)
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, el := range v {
+			m[k] = PlainItem(el)
+		}
+		return m
+	}
+	return item
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PortMetrics is a snapshot of the counters of a primitive port.
+type PortMetrics struct {
+	Pushed         uint64
+	Pulled         uint64
+	Buffered       int
+	BufferCapacity int
+	PullBlocked    time.Duration
+	// Dropped counts the items dropped because the buffer was full
+	Dropped uint64
+	// Spilled is the number of items currently written to disk because the buffer is full
+	Spilled int
+}
+
+// OperatorMetrics is a snapshot of the goroutine accounting of an operator.
+type OperatorMetrics struct {
+	Goroutines      int64
+	GoroutineTime   time.Duration
+	GoroutinesTotal uint64
+}
+
+// Metrics returns the current counters of this port. Only primitive ports carry counters.
+func (p *Port) Metrics() PortMetrics {
+	m := PortMetrics{
+		Pushed:      atomic.LoadUint64(&p.pushed),
+		Pulled:      atomic.LoadUint64(&p.pulled),
+		PullBlocked: time.Duration(atomic.LoadInt64(&p.pullBlocked)),
+		Dropped:     atomic.LoadUint64(&p.dropped),
+	}
+
+	p.mutex.Lock()
+	if p.buf != nil {
+		m.Buffered = len(p.buf)
+		m.BufferCapacity = cap(p.buf)
+	}
+	if p.spill != nil {
+		m.Spilled = p.spill.count
+	}
+	p.mutex.Unlock()
+
+	return m
+}
+
+// Metrics returns the goroutine accounting of this operator. Goroutine time contains the lifetime of all finished
+// goroutines plus the time the currently running ones have been alive.
+func (o *Operator) Metrics() OperatorMetrics {
+	m := OperatorMetrics{
+		Goroutines:      atomic.LoadInt64(&o.goroutines),
+		GoroutineTime:   time.Duration(atomic.LoadInt64(&o.goroutineTime)),
+		GoroutinesTotal: atomic.LoadUint64(&o.goroutinesTotal),
+	}
+
+	if m.Goroutines > 0 {
+		// sum of the lifetimes of all running goroutines: n * now - sum of their start times
+		m.GoroutineTime += time.Duration(m.Goroutines*time.Now().UnixNano() - atomic.LoadInt64(&o.goroutineStarts))
+	}
+
+	return m
+}
+
+func (o *Operator) goroutineStarted() time.Time {
+	now := time.Now()
+	atomic.AddInt64(&o.goroutineStarts, now.UnixNano())
+	atomic.AddInt64(&o.goroutines, 1)
+	atomic.AddUint64(&o.goroutinesTotal, 1)
+	return now
+}
+
+func (o *Operator) goroutineStopped(started time.Time) {
+	atomic.AddInt64(&o.goroutineTime, int64(time.Since(started)))
+	atomic.AddInt64(&o.goroutineStarts, -started.UnixNano())
+	atomic.AddInt64(&o.goroutines, -1)
+}
+
+func (p *Port) countPull(blockedSince time.Time) {
+	atomic.AddUint64(&p.pulled, 1)
+	if !blockedSince.IsZero() {
+		atomic.AddInt64(&p.pullBlocked, int64(time.Since(blockedSince)))
+	}
+}
@@ -1,8 +1,13 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/log"
 	"github.com/google/uuid"
@@ -13,6 +18,9 @@ type CFunc func(op *Operator, dst, src *Port) error
 
 var MAIN_SERVICE = "main"
 
+// errOperatorStopped unwinds operator goroutines blocked in Port.Pull once their operator has been stopped.
+var errOperatorStopped = errors.New("operator stopped")
+
 type Operator struct {
 	name        string
 	defId       uuid.UUID
@@ -27,8 +35,29 @@ type Operator struct {
 	properties  Properties
 	connectFunc CFunc
 	elementary  uuid.UUID
-	stopChannel chan bool
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
 	stopped     bool
+	stopMutex   sync.Mutex
+	err         error
+
+	errorPolicy  ErrorPolicy
+	errorHandler ErrorHandler
+	// errorSrv is the error service of the nearest ancestor which has been removed by Compile
+	errorSrv *Service
+
+	scheduler *Scheduler
+	debugger  *Debugger
+
+	snapshotFunc  SnapshotFunc
+	restoredState json.RawMessage
+	stateMutex    sync.Mutex
+
+	goroutineStarts int64
+	goroutines      int64
+	goroutinesTotal uint64
+	goroutineTime   int64
 }
 
 type Delegate struct {
@@ -58,6 +87,7 @@ func NewOperator(name string, f OFunc, c CFunc, gens Generics, props Properties,
 	o.generics = gens
 	o.properties = props
 	o.children = make(map[string]*Operator)
+	o.errorPolicy = def.ErrorPolicy
 
 	var err error
 	for propKey := range def.PropertyDefs {
@@ -127,6 +157,8 @@ func (o *Operator) SetParent(par *Operator) {
 	o.parent = par
 	if par != nil {
 		par.children[o.name] = o
+		o.setScheduler(par.scheduler)
+		o.setDebugger(par.debugger)
 	}
 }
 
@@ -143,9 +175,28 @@ func (o *Operator) Child(name string) *Operator {
 	return c
 }
 
+// Start starts the operator with a background context.
 func (o *Operator) Start() {
-	o.stopChannel = make(chan bool, 1)
+	o.StartContext(context.Background())
+}
+
+// StartContext starts the operator. Cancelling ctx stops the operator and all of its children.
+func (o *Operator) StartContext(ctx context.Context) {
+	if s := o.scheduler; s != nil && s.root == o {
+		s.resume()
+		// Hold the token while starting so that no goroutine runs before all have been registered
+		if t, _, err := s.enter(ctx, nil); err == nil {
+			defer s.finish(t)
+		}
+	}
+
+	// Goroutines of other operators may already reach the ports of this operator and read its context
+	ctx, cancel := context.WithCancel(ctx)
+	o.stopMutex.Lock()
+	o.ctx, o.cancel = ctx, cancel
 	o.stopped = false
+	o.err = nil
+	o.stopMutex.Unlock()
 
 	for _, srv := range o.services {
 		srv.outPort.Open()
@@ -155,29 +206,95 @@ func (o *Operator) Start() {
 	}
 
 	if o.function != nil {
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Errorf("%s:%s panicked: %s", o.Id(), o.Name(), r)
-					o.Stop()
-				}
-			}()
+		o.Go(func() {
 			o.function(o)
+		})
+
+		go func() {
+			<-ctx.Done()
+			o.Stop()
 		}()
 	} else {
 		for _, c := range o.children {
-			c.Start()
+			c.StartContext(ctx)
+		}
+	}
+}
+
+// Go runs f in a new goroutine which is accounted to this operator. Elementary operators should use Go instead of
+// plain goroutines so that pulling from a port of a stopped operator unwinds f and halting can wait for f.
+func (o *Operator) Go(f func()) {
+	var task *schedulerTask
+	s := o.scheduler
+	if s != nil {
+		task = s.register(o)
+	}
+
+	o.wg.Add(1)
+	go func() {
+		started := o.goroutineStarted()
+		defer o.wg.Done()
+		defer o.goroutineStopped(started)
+		if task != nil {
+			defer s.finish(task)
+			if s.acquire(task, o.Context(), nil) != nil {
+				return
+			}
 		}
+		defer func() {
+			if r := recover(); r != nil {
+				if r == errOperatorStopped {
+					return
+				}
+				serr := NewSlangError(o, fmt.Errorf("panic: %v", r))
+				log.Error(serr)
+				o.notifyError(serr)
+				o.fail(serr)
+			}
+		}()
+		f()
+	}()
+}
+
+// Context returns the context of the running operator. It is done as soon as the operator has been stopped.
+func (o *Operator) Context() context.Context {
+	o.stopMutex.Lock()
+	defer o.stopMutex.Unlock()
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// WalkPorts calls handle for the in and out ports of all services and delegates of this operator.
+func (o *Operator) WalkPorts(handle func(p *Port)) {
+	for _, srv := range o.services {
+		handle(srv.inPort)
+		handle(srv.outPort)
+	}
+	for _, dlg := range o.delegates {
+		handle(dlg.inPort)
+		handle(dlg.outPort)
 	}
 }
 
 func (o *Operator) Stop() {
+	o.stopMutex.Lock()
 	if o.stopped {
+		o.stopMutex.Unlock()
 		return
 	}
-
-	o.stopChannel <- true
 	o.stopped = true
+	cancel := o.cancel
+	o.stopMutex.Unlock()
+
+	if s := o.scheduler; s != nil && s.root == o {
+		s.halt()
+	}
+
+	if cancel != nil {
+		cancel()
+	}
 
 	for _, srv := range o.services {
 		srv.outPort.Close()
@@ -195,15 +312,94 @@ func (o *Operator) Stop() {
 	}
 }
 
+// Halt stops the operator and waits until the goroutines of the operator and all of its children have returned.
+// It returns an error if they are still running when ctx is done.
+func (o *Operator) Halt(ctx context.Context) error {
+	if s := o.scheduler; s != nil && s.root == o {
+		// Stop once all goroutines are blocked, so that halting is a step of the schedule
+		if t, _, err := s.enter(ctx, nil); err == nil {
+			defer s.finish(t)
+		}
+	}
+	o.Stop()
+
+	done := make(chan bool)
+	go func() {
+		o.wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %d goroutines still running: %s", o.Name(), o.runningGoroutines(), ctx.Err())
+	}
+}
+
+// HaltTimeout is like Halt but waits at most timeout.
+func (o *Operator) HaltTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return o.Halt(ctx)
+}
+
+func (o *Operator) wait() {
+	o.wg.Wait()
+	for _, c := range o.children {
+		c.wait()
+	}
+}
+
+func (o *Operator) runningGoroutines() int64 {
+	n := atomic.LoadInt64(&o.goroutines)
+	for _, c := range o.children {
+		n += c.runningGoroutines()
+	}
+	return n
+}
+
 func (o *Operator) WaitForStop() {
-	<-o.stopChannel
-	o.stopChannel <- true
+	if o.scheduler != nil {
+		// Let the other goroutines run
+		o.scheduler.wait(func() bool { return false }, o.Context(), nil)
+		return
+	}
+	<-o.Context().Done()
+}
+
+// Await waits for an item from done, which has to be sent by another goroutine of this operator. It returns false if
+// the operator is stopped before. Under a scheduler the other goroutines run meanwhile, so done has to be buffered.
+func (o *Operator) Await(done <-chan bool) bool {
+	if s := o.scheduler; s != nil {
+		err := s.wait(func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, o.Context(), nil)
+		return err == nil
+	}
+
+	select {
+	case <-done:
+		return true
+	case <-o.Context().Done():
+		return false
+	}
 }
 
+// CheckStop returns true if the operator has been stopped. Under a scheduler, the other goroutines are given the
+// chance to run, so that goroutines polling for a condition with CheckStop do not starve them.
 func (o *Operator) CheckStop() bool {
+	if o.scheduler != nil {
+		o.scheduler.yield(o, o.Context())
+	}
+
 	select {
-	case <-o.stopChannel:
-		o.stopChannel <- true
+	case <-o.Context().Done():
 		return true
 	default:
 		return false
@@ -211,6 +407,8 @@ func (o *Operator) CheckStop() bool {
 }
 
 func (o *Operator) Stopped() bool {
+	o.stopMutex.Lock()
+	defer o.stopMutex.Unlock()
 	return o.stopped
 }
 
@@ -247,8 +445,19 @@ func (o *Operator) Compile() (compiled int, depth int) {
 		dlg.Out().Merge()
 	}
 
-	// Move children to parent and rename instances
+	// Move children to parent and rename instances. Children keep the error policy and error service they have
+	// inherited from this operator.
 	for _, c := range o.children {
+		if c.errorPolicy == "" {
+			c.errorPolicy = o.errorPolicy
+		}
+		if c.errorSrv == nil {
+			if srv := o.Service(ERROR_SERVICE); srv != nil {
+				c.errorSrv = srv
+			} else {
+				c.errorSrv = o.errorSrv
+			}
+		}
 		c.name = o.name + "#" + c.name
 		c.parent = o.parent
 		o.parent.children[c.name] = c
@@ -296,7 +505,9 @@ func (o *Operator) Define() (Blueprint, error) {
 	def.ServiceDefs = make(map[string]*ServiceDef)
 	def.DelegateDefs = make(map[string]*DelegateDef)
 	def.Connections = make(map[string][]string)
+	def.Buffers = make(map[string]*BufferDef)
 	def.InstanceDefs = InstanceDefList{}
+	def.ErrorPolicy = o.errorPolicy
 
 	for insName, child := range o.children {
 		insDef := &InstanceDef{}
@@ -327,6 +538,9 @@ func (o *Operator) Define() (Blueprint, error) {
 		}
 	}
 	def.Connections = nonemptyConns
+	if len(def.Buffers) == 0 {
+		def.Buffers = nil
+	}
 
 	if err := def.Validate(); err != nil {
 		return def, err
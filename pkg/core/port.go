@@ -1,9 +1,13 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,6 +21,11 @@ const (
 	TYPE_BOOLEAN   = iota	// 6
 	TYPE_STREAM    = iota	// 7
 	TYPE_MAP       = iota	// 8
+	TYPE_ENUM      = iota	// 9
+	TYPE_UNION     = iota	// 10
+	TYPE_DATETIME  = iota	// 11
+	TYPE_DURATION  = iota	// 12
+	TYPE_DECIMAL   = iota	// 13
 )
 
 const (
@@ -25,9 +34,6 @@ const (
 	DIRECTION_OUT = iota
 )
 
-var CHANNEL_SIZE = 1 << 15
-var CHANNEL_DYNAMIC = false
-
 type BOS struct {
 	src *Port
 }
@@ -48,21 +54,39 @@ type Port struct {
 	service   *Service
 	delegate  *Delegate
 	dests     map[*Port]bool
+	destList  []*Port
 	src       *Port
 	strSrc    *Port
 	direction int
 
 	itemType int
+	nullable bool
+	optional bool
+	enum     []string
+	union    TypeDefMap
 
 	parStr *Port
 	parMap *Port
 
-	sub  *Port
-	subs map[string]*Port
+	sub      *Port
+	subs     map[string]*Port
+	subNames []string
 
 	buf    chan interface{}
+	bufDef *BufferDef
+	spill  *spillFile
 	mutex  sync.Mutex
 	closed bool
+	// closeMutex keeps the buffer from being closed while items are pushed into it
+	closeMutex sync.RWMutex
+
+	taps     map[*Tap]bool
+	tapMutex sync.RWMutex
+
+	pushed      uint64
+	pulled      uint64
+	pullBlocked int64
+	dropped     uint64
 }
 
 // Makes a new port.
@@ -89,6 +113,8 @@ func NewPort(srv *Service, del *Delegate, def TypeDef, dir int) (*Port, error) {
 	p.service = srv
 	p.delegate = del
 	p.dests = make(map[*Port]bool)
+	p.nullable = def.Nullable
+	p.optional = def.Optional
 
 	var err error
 	switch def.Type {
@@ -102,7 +128,9 @@ func NewPort(srv *Service, del *Delegate, def TypeDef, dir int) (*Port, error) {
 			}
 			p.subs[k].parStr = p.parStr
 			p.subs[k].parMap = p
+			p.subNames = append(p.subNames, k)
 		}
+		sort.Strings(p.subNames)
 	case "stream":
 		p.itemType = TYPE_STREAM
 		p.sub, err = NewPort(srv, del, *def.Stream, dir)
@@ -122,10 +150,26 @@ func NewPort(srv *Service, del *Delegate, def TypeDef, dir int) (*Port, error) {
 		p.itemType = TYPE_BINARY
 	case "boolean":
 		p.itemType = TYPE_BOOLEAN
+	case "datetime":
+		p.itemType = TYPE_DATETIME
+	case "duration":
+		p.itemType = TYPE_DURATION
+	case "decimal":
+		p.itemType = TYPE_DECIMAL
+	case "enum":
+		p.itemType = TYPE_ENUM
+		p.enum = append([]string{}, def.Enum...)
+	case "union":
+		p.itemType = TYPE_UNION
+		p.union = make(TypeDefMap)
+		for tag, v := range def.Union {
+			vCpy := v.Copy()
+			p.union[tag] = &vCpy
+		}
 	}
 
 	if p.PrimitiveType() && dir == DIRECTION_IN && p.operator != nil && p.operator.function != nil {
-		p.buf = make(chan interface{}, CHANNEL_SIZE)
+		p.makeBuffer()
 	}
 
 	return p, nil
@@ -136,6 +180,16 @@ func (p *Port) Type() int {
 	return p.itemType
 }
 
+// Nullable returns true if null may be pushed into this port.
+func (p *Port) Nullable() bool {
+	return p.nullable
+}
+
+// Optional returns true if this port is an entry of a map which may be missing.
+func (p *Port) Optional() bool {
+	return p.optional
+}
+
 // Returns the direction of the port.
 func (p *Port) Direction() int {
 	return p.direction
@@ -210,10 +264,33 @@ func (p *Port) Connect(q *Port) (err error) {
 		return p.connect(q, true)
 	}
 
-	if p.itemType != TYPE_PRIMITIVE && p.itemType != q.itemType || p.itemType == TYPE_PRIMITIVE && !q.PrimitiveType() {
+	// Enum values are strings
+	enumToString := p.itemType == TYPE_ENUM && q.itemType == TYPE_STRING
+
+	if p.itemType != TYPE_PRIMITIVE && p.itemType != q.itemType && !enumToString || p.itemType == TYPE_PRIMITIVE && !q.PrimitiveType() {
 		return fmt.Errorf("%s -> %s: types don't match - %d != %d", p.Name(), q.Name(), p.itemType, q.itemType)
 	}
 
+	if p.itemType == TYPE_ENUM && q.itemType == TYPE_ENUM && !enumSubset(p.enum, q.enum) {
+		return fmt.Errorf("%s -> %s: enums are incompatible - %s not contained in %s", p.Name(), q.Name(), strings.Join(p.enum, ", "), strings.Join(q.enum, ", "))
+	}
+
+	if p.itemType == TYPE_UNION && q.itemType == TYPE_UNION {
+		for tag, v := range p.union {
+			if qv, ok := q.union[tag]; !ok || !v.Equals(*qv) {
+				return fmt.Errorf("%s -> %s: unions are incompatible - variant %s not present or different", p.Name(), q.Name(), tag)
+			}
+		}
+	}
+
+	if p.nullable && !q.nullable {
+		return fmt.Errorf("%s -> %s: nullable port connected to non-nullable port", p.Name(), q.Name())
+	}
+
+	if p.optional && !q.optional {
+		return fmt.Errorf("%s -> %s: optional entry connected to required entry", p.Name(), q.Name())
+	}
+
 	if p.PrimitiveType() {
 		return p.connect(q, true)
 	}
@@ -261,6 +338,7 @@ func (p *Port) Disconnect(q *Port) error {
 	}
 	q.src = nil
 	delete(p.dests, q)
+	p.destList = nil
 	return nil
 }
 
@@ -274,15 +352,19 @@ func (p *Port) Connected(q *Port) bool {
 
 // Opens the port by opening all channels
 func (p *Port) Open() {
+	p.closeMutex.Lock()
 	if !p.closed {
+		p.closeMutex.Unlock()
 		return
 	}
 
 	p.closed = false
 
 	if p.buf != nil {
-		p.buf = make(chan interface{}, CHANNEL_SIZE)
+		p.removeSpill()
+		p.makeBuffer()
 	}
+	p.closeMutex.Unlock()
 
 	if p.sub != nil {
 		p.sub.Open()
@@ -296,16 +378,21 @@ func (p *Port) Open() {
 }
 
 // Closes the port by closing all channels
+// Pushes blocked on the buffer must have been cancelled by stopping the operator, as closing waits for them.
 func (p *Port) Close() {
+	p.closeMutex.Lock()
 	if p.closed {
+		p.closeMutex.Unlock()
 		return
 	}
 
 	p.closed = true
 
 	if p.buf != nil {
+		p.removeSpill()
 		close(p.buf)
 	}
+	p.closeMutex.Unlock()
 
 	if p.sub != nil {
 		p.sub.Close()
@@ -401,25 +488,6 @@ func (p *Port) DirectlyConnected() error {
 	return nil
 }
 
-func (p *Port) assertChannelSpace() {
-	c := cap(p.buf)
-	if len(p.buf) > c/2 {
-		newChan := make(chan interface{}, 2*c)
-		p.mutex.Lock()
-		for {
-			select {
-			case i := <-p.buf:
-				newChan <- i
-			default:
-				goto end
-			}
-		}
-	end:
-		p.buf = newChan
-		p.mutex.Unlock()
-	}
-}
-
 func (p *Port) WalkPrimitivePorts(handle func(p *Port)) {
 	if p.PrimitiveType() {
 		handle(p)
@@ -434,68 +502,207 @@ func (p *Port) WalkPrimitivePorts(handle func(p *Port)) {
 	}
 }
 func (p *Port) Closed() bool {
+	p.closeMutex.RLock()
+	defer p.closeMutex.RUnlock()
 	return p.closed
 }
 
 // Push an item to this port.
 func (p *Port) Push(item interface{}) {
-	if p.closed {
-		return
+	p.PushContext(context.Background(), item)
+}
+
+// PushContext pushes an item to this port. What happens when the buffer is full depends on its buffer policy, see
+// SetBuffer. Blocking pushes wait until there is space, ctx is done or the operator owning the buffer is stopped. In
+// the latter cases the item is dropped and an error is returned.
+func (p *Port) PushContext(ctx context.Context, item interface{}) error {
+	if p.Closed() || p.context().Err() != nil {
+		return errOperatorStopped
 	}
 
-	if p.buf != nil {
-		if CHANNEL_DYNAMIC {
-			p.assertChannelSpace()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-			p.mutex.Lock()
-			p.buf <- item
-			p.mutex.Unlock()
-		} else {
-			p.buf <- item
+	if s := p.scheduler(); s != nil && p.direction == DIRECTION_IN && p.operator == s.root && !entered(ctx) {
+		t, ectx, err := s.enter(ctx, p.context())
+		if err != nil {
+			return err
+		}
+		defer s.finish(t)
+		ctx = ectx
+	}
+
+	if p.PrimitiveType() {
+		item = p.parseItem(item)
+		if p.operator != nil && p.operator.debugger != nil {
+			var err error
+			if item, err = p.operator.debugger.arrive(p, item); err != nil {
+				return err
+			}
 		}
+		atomic.AddUint64(&p.pushed, 1)
+		p.notifyTaps(item)
 	}
 
-	for dest := range p.dests {
+	if p.buf != nil {
+		if err := p.pushBuffer(ctx, item); err != nil {
+			return err
+		}
+	}
+
+	for _, dest := range p.destinations() {
 		if dest.Type() == TYPE_TRIGGER || p.PrimitiveType() {
-			dest.Push(item)
+			if err := dest.PushContext(ctx, item); err != nil && ctx.Err() != nil {
+				return err
+			}
 		}
 	}
 
 	if p.PrimitiveType() {
-		return
+		return nil
 	}
 
 	if p.itemType == TYPE_MAP {
 		m, ok := item.(map[string]interface{})
 
 		if !ok {
-			for _, sub := range p.subs {
-				sub.Push(item)
+			for _, k := range p.subNames {
+				if err := p.subs[k].PushContext(ctx, item); err != nil && ctx.Err() != nil {
+					return err
+				}
 			}
-			return
+			return nil
 		}
 
-		for k, i := range m {
-			if sub, ok := p.subs[k]; ok {
-				sub.Push(i)
+		for _, k := range p.subNames {
+			sub := p.subs[k]
+			i, ok := m[k]
+			if !ok && !sub.optional {
+				continue
+			}
+			// Missing optional entries are pushed as null to keep the entries in step
+			if err := sub.PushContext(ctx, i); err != nil && ctx.Err() != nil {
+				return err
 			}
 		}
-		return
+		return nil
 	}
 
 	if p.itemType == TYPE_STREAM {
 		items, ok := item.([]interface{})
 		if !ok {
-			p.sub.Push(item)
-			return
+			return p.sub.PushContext(ctx, item)
 		}
 
-		p.PushNoTriggerBOS()
+		if err := p.sub.PushContext(ctx, BOS{p.strSrc}); err != nil {
+			return err
+		}
 		for _, i := range items {
-			p.sub.Push(i)
+			if err := p.sub.PushContext(ctx, i); err != nil {
+				return err
+			}
+		}
+		return p.sub.PushContext(ctx, EOS{p.strSrc})
+	}
+
+	return nil
+}
+
+// parseItem converts the text encodings of datetimes, durations and decimals pushed into ports of these types, so
+// that JSON and YAML input can be pushed directly
+func (p *Port) parseItem(item interface{}) interface{} {
+	var ok bool
+	var parsed interface{}
+	switch p.itemType {
+	case TYPE_DATETIME:
+		parsed, ok = toDateTime(item)
+	case TYPE_DURATION:
+		parsed, ok = toDuration(item)
+	case TYPE_DECIMAL:
+		parsed, ok = toDecimal(item)
+	}
+	if ok {
+		return parsed
+	}
+	return item
+}
+
+func (p *Port) pushBuffer(ctx context.Context, item interface{}) error {
+	p.closeMutex.RLock()
+	defer p.closeMutex.RUnlock()
+	if p.closed {
+		return errOperatorStopped
+	}
+
+	switch policy := p.Buffer().Policy; policy {
+	case BUFFER_POLICY_SPILL:
+		return p.spillItem(item)
+	case BUFFER_POLICY_DROP_OLDEST, BUFFER_POLICY_DROP_NEWEST:
+		return p.dropItem(ctx, policy, item)
+	}
+
+	return p.pushBlocking(ctx, item)
+}
+
+func (p *Port) pushBlocking(ctx context.Context, item interface{}) error {
+	if s := p.scheduler(); s != nil {
+		err := s.wait(func() bool {
+			select {
+			case p.buf <- item:
+				return true
+			default:
+				return false
+			}
+		}, ctx, p.context())
+		if err != nil && ctx.Err() == nil {
+			return errOperatorStopped
+		}
+		return err
+	}
+
+	done := p.context().Done()
+
+	select {
+	case p.buf <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return errOperatorStopped
+	}
+}
+
+// context returns the context of the operator this port belongs to
+func (p *Port) context() context.Context {
+	if p.operator == nil {
+		return context.Background()
+	}
+	return p.operator.Context()
+}
+
+// scheduler returns the scheduler driving the operator this port belongs to
+func (p *Port) scheduler() *Scheduler {
+	if p.operator == nil {
+		return nil
+	}
+	return p.operator.scheduler
+}
+
+// destinations returns the ports this port pushes to sorted by their names, so that scheduled operators push in the
+// same order on every run
+func (p *Port) destinations() []*Port {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.destList == nil {
+		p.destList = make([]*Port, 0, len(p.dests))
+		for dest := range p.dests {
+			p.destList = append(p.destList, dest)
 		}
-		p.PushEOS()
+		sort.Slice(p.destList, func(i, j int) bool { return p.destList[i].String() < p.destList[j].String() })
 	}
+	return p.destList
 }
 
 func (p *Port) PushNoTriggerBOS() {
@@ -504,7 +711,7 @@ func (p *Port) PushNoTriggerBOS() {
 
 func (p *Port) PushBOS() {
 	// For triggers, we need to push right here
-	for dest := range p.dests {
+	for _, dest := range p.destinations() {
 		if dest.Type() == TYPE_TRIGGER {
 			dest.Push(nil)
 		}
@@ -517,27 +724,68 @@ func (p *Port) PushEOS() {
 	p.sub.Push(EOS{p.strSrc})
 }
 
-// Pull an item from this port
+// Pull an item from this port. Pull is cancelled when the operator of this port is stopped. In that case, in ports
+// of elementary operators unwind the calling operator goroutine, all other ports return nil.
 func (p *Port) Pull() interface{} {
+	i, err := p.PullContext(p.context())
+	if err != nil {
+		if p.direction == DIRECTION_IN && p.operator != nil && p.operator.function != nil {
+			panic(errOperatorStopped)
+		}
+		return nil
+	}
+	return i
+}
+
+// PullContext pulls an item from this port and returns an error as soon as ctx is done.
+func (p *Port) PullContext(ctx context.Context) (interface{}, error) {
 	if p.itemType == TYPE_GENERIC {
 		panic("cannot pull from generic")
 	}
 
+	if s := p.scheduler(); s != nil && p.direction == DIRECTION_OUT && p.operator == s.root && !entered(ctx) {
+		t, ectx, err := s.enter(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer s.finish(t)
+		ctx = ectx
+	}
+
 	if p.buf != nil {
-		if CHANNEL_DYNAMIC {
-			for {
-				p.mutex.Lock()
+		var blockedSince time.Time
+		select {
+		case i := <-p.buf:
+			p.countPull(blockedSince)
+			p.unspill()
+			return i, nil
+		default:
+		}
+		blockedSince = time.Now()
+		if s := p.scheduler(); s != nil {
+			var i interface{}
+			err := s.wait(func() bool {
 				select {
-				case i := <-p.buf:
-					p.mutex.Unlock()
-					return i
+				case i = <-p.buf:
+					return true
 				default:
-					p.mutex.Unlock()
+					return false
 				}
-				time.Sleep(1 * time.Millisecond)
+			}, ctx, nil)
+			if err != nil {
+				return nil, err
 			}
-		} else {
-			return <-p.buf
+			p.countPull(blockedSince)
+			p.unspill()
+			return i, nil
+		}
+		select {
+		case i := <-p.buf:
+			p.countPull(blockedSince)
+			p.unspill()
+			return i, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 	}
 
@@ -549,8 +797,16 @@ func (p *Port) Pull() interface{} {
 		var mi interface{}
 		itemMap := make(map[string]interface{})
 
-		for k, sub := range p.subs {
-			i := sub.Pull()
+		for _, k := range p.subNames {
+			sub := p.subs[k]
+			i, err := sub.PullContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			if sub.missing(i) {
+				continue
+			}
 
 			if i == PHMultiple {
 				mi = PHMultiple
@@ -568,25 +824,34 @@ func (p *Port) Pull() interface{} {
 		}
 
 		if mi != nil {
-			return mi
+			return mi, nil
 		}
-		return itemMap
+		if p.missingMap(itemMap) {
+			return nil, nil
+		}
+		return itemMap, nil
 	}
 
 	if p.itemType == TYPE_STREAM {
-		i := p.sub.Pull()
+		i, err := p.sub.PullContext(ctx)
+		if err != nil {
+			return nil, err
+		}
 
 		if !p.OwnBOS(i) {
-			return i
+			return i, nil
 		}
 
 		items := []interface{}{}
 
 		for {
-			i := p.sub.Pull()
+			i, err := p.sub.PullContext(ctx)
+			if err != nil {
+				return nil, err
+			}
 
 			if p.OwnEOS(i) {
-				return items
+				return items, nil
 			}
 
 			items = append(items, i)
@@ -596,6 +861,25 @@ func (p *Port) Pull() interface{} {
 	panic("unknown type")
 }
 
+// missing returns true if i stands for a missing optional map entry
+func (p *Port) missing(i interface{}) bool {
+	return i == nil && p.optional && !p.nullable
+}
+
+// missingMap returns true if the map m pulled from this port stands for a missing optional map entry, i.e. null has
+// been pushed into all of its entries
+func (p *Port) missingMap(m map[string]interface{}) bool {
+	if !p.optional || p.nullable || len(p.subs) == 0 || len(m) != len(p.subs) {
+		return false
+	}
+	for _, i := range m {
+		if i != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // Similar to Port.Pull but will return (nil, false) when there is no item after timeout otherwise (value, true)
 func (p *Port) Poll() (interface{}, bool) {
 	timeout := time.After(5 * time.Millisecond)
@@ -605,28 +889,13 @@ func (p *Port) Poll() (interface{}, bool) {
 	}
 
 	if p.buf != nil {
-		if CHANNEL_DYNAMIC {
-			for {
-				p.mutex.Lock()
-				select {
-				case i := <-p.buf:
-					p.mutex.Unlock()
-					return i, true
-				case <-timeout:
-					p.mutex.Unlock()
-					return nil, false
-				default:
-					p.mutex.Unlock()
-				}
-				time.Sleep(1 * time.Millisecond)
-			}
-		} else {
-			select {
-			case i := <-p.buf:
-				return i, true
-			case <-timeout:
-				return nil, false
-			}
+		select {
+		case i := <-p.buf:
+			p.countPull(time.Time{})
+			p.unspill()
+			return i, true
+		case <-timeout:
+			return nil, false
 		}
 	}
 
@@ -638,19 +907,25 @@ func (p *Port) Poll() (interface{}, bool) {
 		var mi interface{}
 		itemMap := make(map[string]interface{})
 
-		for k, sub := range p.subs {
+		polled := false
+		for _, k := range p.subNames {
+			sub := p.subs[k]
 			var i any
 
-			if len(itemMap) == 0 {
+			if !polled {
 				// prevent blocking when there has not arrived any value yet.
 				var ok bool
 				if i, ok = sub.Poll(); !ok {
 					return nil, false
 				}
+				polled = true
 			} else {
 				i = sub.Pull()
 			}
- 
+
+			if sub.missing(i) {
+				continue
+			}
 
 			if i == PHMultiple {
 				mi = PHMultiple
@@ -670,6 +945,9 @@ func (p *Port) Poll() (interface{}, bool) {
 		if mi != nil {
 			return mi, true
 		}
+		if p.missingMap(itemMap) {
+			return nil, true
+		}
 		return itemMap, true
 	}
 
@@ -751,6 +1029,33 @@ func (p *Port) PullBinary() (Binary, interface{}) {
 	return nil, item
 }
 
+// Pull a datetime
+func (p *Port) PullTime() (time.Time, interface{}) {
+	item := p.Pull()
+	if t, ok := toDateTime(item); ok {
+		return t, nil
+	}
+	return time.Time{}, item
+}
+
+// Pull a duration
+func (p *Port) PullDuration() (time.Duration, interface{}) {
+	item := p.Pull()
+	if d, ok := toDuration(item); ok {
+		return d, nil
+	}
+	return 0, item
+}
+
+// Pull a decimal
+func (p *Port) PullDecimal() (Decimal, interface{}) {
+	item := p.Pull()
+	if d, ok := toDecimal(item); ok {
+		return d, nil
+	}
+	return Decimal{}, item
+}
+
 func (p *Port) PullBOS() bool {
 	i := p.sub.Pull()
 	if !p.OwnBOS(i) {
@@ -809,7 +1114,7 @@ func (p *Port) Bufferize() {
 	}
 
 	if p.PrimitiveType() {
-		p.buf = make(chan interface{}, CHANNEL_SIZE)
+		p.makeBuffer()
 	} else if p.itemType == TYPE_MAP {
 		for _, sub := range p.subs {
 			sub.Bufferize()
@@ -834,6 +1139,7 @@ func setParentStreams(p *Port, parent *Port) {
 func (p *Port) wire(q *Port, original bool) {
 	if original {
 		p.dests[q] = true
+		p.destList = nil
 		q.src = p
 	}
 	q.strSrc = p.strSrc
@@ -920,7 +1226,12 @@ func (p *Port) PrimitiveType() bool {
 		p.itemType == TYPE_NUMBER ||
 		p.itemType == TYPE_STRING ||
 		p.itemType == TYPE_BINARY ||
-		p.itemType == TYPE_BOOLEAN
+		p.itemType == TYPE_BOOLEAN ||
+		p.itemType == TYPE_DATETIME ||
+		p.itemType == TYPE_DURATION ||
+		p.itemType == TYPE_DECIMAL ||
+		p.itemType == TYPE_ENUM ||
+		p.itemType == TYPE_UNION
 }
 
 func (p *Port) TriggerType() bool {
@@ -951,6 +1262,22 @@ func (p *Port) Define() TypeDef {
 		def.Type = "boolean"
 	case TYPE_BINARY:
 		def.Type = "binary"
+	case TYPE_DATETIME:
+		def.Type = "datetime"
+	case TYPE_DURATION:
+		def.Type = "duration"
+	case TYPE_DECIMAL:
+		def.Type = "decimal"
+	case TYPE_ENUM:
+		def.Type = "enum"
+		def.Enum = append([]string{}, p.enum...)
+	case TYPE_UNION:
+		def.Type = "union"
+		def.Union = make(TypeDefMap)
+		for tag, v := range p.union {
+			vCpy := v.Copy()
+			def.Union[tag] = &vCpy
+		}
 	case TYPE_GENERIC:
 		def.Type = "generic"
 	case TYPE_STREAM:
@@ -966,6 +1293,9 @@ func (p *Port) Define() TypeDef {
 		}
 	}
 
+	def.Nullable = p.nullable
+	def.Optional = p.optional
+
 	return def
 }
 
@@ -1027,6 +1357,10 @@ func (p *Port) defineConnections(def *Blueprint) {
 		def.Connections[portStr] = make([]string, 0)
 		for dst := range p.dests {
 			def.Connections[portStr] = append(def.Connections[portStr], dst.String())
+			if dst.bufDef != nil {
+				bufDef := *dst.bufDef
+				def.Buffers[dst.String()] = &bufDef
+			}
 			dst.operator.defineConnections(def)
 		}
 	}
@@ -0,0 +1,248 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Record is a single line of a recording. Items are encoded as for snapshots, so that markers, binaries, datetimes,
+// durations and decimals survive.
+type Record struct {
+	Time time.Time   `json:"time"`
+	Port string      `json:"port"`
+	Item interface{} `json:"item"`
+}
+
+// Recorder writes all items entering and leaving an operator as newline delimited JSON records.
+type Recorder struct {
+	mutex sync.Mutex
+	enc   *json.Encoder
+	taps  []*Tap
+	err   error
+}
+
+// NewRecorder starts recording the items pushed into and emitted by the main service of o as well as the delegates
+// with the given names. Items are recorded per primitive port including BOS and EOS markers.
+func NewRecorder(o *Operator, w io.Writer, delegates []string) (*Recorder, error) {
+	ports, err := recordedPorts(o, delegates)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{enc: json.NewEncoder(w)}
+	for _, p := range ports {
+		r.taps = append(r.taps, p.Tap(r.record))
+	}
+	return r, nil
+}
+
+func (r *Recorder) record(p *Port, item interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(&Record{time.Now(), p.String(), encodeItem(item)})
+}
+
+// Close stops recording. It returns the first error which occurred while writing records.
+func (r *Recorder) Close() error {
+	for _, t := range r.taps {
+		t.Untap()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.err
+}
+
+// recordedPorts returns the in and out ports of the main service and the given delegates of o
+func recordedPorts(o *Operator, delegates []string) ([]*Port, error) {
+	ports := []*Port{o.Main().In(), o.Main().Out()}
+	for _, name := range delegates {
+		d := o.Delegate(name)
+		if d == nil {
+			return nil, fmt.Errorf("unknown delegate: %s", name)
+		}
+		ports = append(ports, d.In(), d.Out())
+	}
+	return ports, nil
+}
+
+// ReplayDiff describes an item emitted during a replay which differs from the recorded one.
+type ReplayDiff struct {
+	Port  string `json:"port"`
+	Index int    `json:"index"`
+	// Expected is the recorded item, Actual the item emitted during the replay. Either is missing if the replay
+	// emitted less or more items than recorded.
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+	Missing  bool        `json:"missing,omitempty"`
+	Extra    bool        `json:"extra,omitempty"`
+}
+
+// ReplayResult summarizes a replay.
+type ReplayResult struct {
+	Inputs  int          `json:"inputs"`
+	Outputs int          `json:"outputs"`
+	Diffs   []ReplayDiff `json:"diffs"`
+}
+
+// Replay pushes the recorded items of the in ports of the started operator o into these ports again and compares
+// the items o emits with the recorded ones. Before each item is pushed, Replay waits for the items recorded before it
+// to be emitted, so that the replay follows the recording. It gives up waiting after timeout. The recording must
+// have been made with an operator built from the same blueprint.
+func Replay(o *Operator, r io.Reader, timeout time.Duration) (*ReplayResult, error) {
+	var records []Record
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		var rec Record
+		if err := dec.Decode(&rec); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+
+	ports := o.portsByName()
+	c := &replayCollector{actual: make(map[string][]interface{}), changed: make(chan bool, 1)}
+	tapped := make(map[*Port]bool)
+	defer func() {
+		for _, t := range c.taps {
+			t.Untap()
+		}
+	}()
+
+	for _, rec := range records {
+		p, ok := ports[rec.Port]
+		if !ok || p.operator != o {
+			return nil, fmt.Errorf("unknown port in recording: %s", rec.Port)
+		}
+		if p.direction == DIRECTION_OUT && !tapped[p] {
+			tapped[p] = true
+			c.taps = append(c.taps, p.Tap(c.collect))
+		}
+	}
+
+	res := &ReplayResult{}
+	expected := make(map[string][]interface{})
+	waiting := true
+
+	for _, rec := range records {
+		p := ports[rec.Port]
+		if p.direction == DIRECTION_OUT {
+			expected[rec.Port] = append(expected[rec.Port], rec.Item)
+			res.Outputs++
+			continue
+		}
+
+		if waiting {
+			waiting = c.wait(expected, timeout)
+		}
+		replayItem(p, decodeItem(rec.Item, ports))
+		res.Inputs++
+	}
+	c.wait(expected, timeout)
+
+	res.Diffs = c.diff(expected)
+	return res, nil
+}
+
+// replayItem pushes item into the primitive port p. Begins of streams are pushed by the stream port, so that
+// triggers connected to it fire as during the recording.
+func replayItem(p *Port, item interface{}) {
+	if bos, ok := item.(BOS); ok && bos.src.sub == p {
+		bos.src.PushBOS()
+		return
+	}
+	p.Push(item)
+}
+
+// replayCollector collects the items emitted by an operator during a replay
+type replayCollector struct {
+	mutex   sync.Mutex
+	actual  map[string][]interface{}
+	changed chan bool
+	taps    []*Tap
+}
+
+func (c *replayCollector) collect(p *Port, item interface{}) {
+	// Round trip through JSON to compare with the recorded items
+	var plain interface{}
+	data, _ := json.Marshal(encodeItem(item))
+	json.Unmarshal(data, &plain)
+
+	c.mutex.Lock()
+	c.actual[p.String()] = append(c.actual[p.String()], plain)
+	c.mutex.Unlock()
+
+	select {
+	case c.changed <- true:
+	default:
+	}
+}
+
+// wait waits until at least as many items as expected have been emitted by each port. It returns false on timeout.
+func (c *replayCollector) wait(expected map[string][]interface{}, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		if c.complete(expected) {
+			return true
+		}
+		select {
+		case <-c.changed:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func (c *replayCollector) complete(expected map[string][]interface{}) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for port, items := range expected {
+		if len(c.actual[port]) < len(items) {
+			return false
+		}
+	}
+	return true
+}
+
+// diff compares the emitted items with the expected ones port by port
+func (c *replayCollector) diff(expected map[string][]interface{}) []ReplayDiff {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	portNames := make([]string, 0, len(c.actual))
+	for port := range c.actual {
+		portNames = append(portNames, port)
+	}
+	for port := range expected {
+		if _, ok := c.actual[port]; !ok {
+			portNames = append(portNames, port)
+		}
+	}
+	sort.Strings(portNames)
+
+	diffs := make([]ReplayDiff, 0)
+	for _, port := range portNames {
+		exp, act := expected[port], c.actual[port]
+		for i := 0; i < len(exp) || i < len(act); i++ {
+			switch {
+			case i >= len(act):
+				diffs = append(diffs, ReplayDiff{Port: port, Index: i, Expected: exp[i], Missing: true})
+			case i >= len(exp):
+				diffs = append(diffs, ReplayDiff{Port: port, Index: i, Actual: act[i], Extra: true})
+			case !reflect.DeepEqual(exp[i], act[i]):
+				diffs = append(diffs, ReplayDiff{Port: port, Index: i, Expected: exp[i], Actual: act[i]})
+			}
+		}
+	}
+	return diffs
+}
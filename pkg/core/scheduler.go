@@ -0,0 +1,303 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Scheduler runs an operator tree deterministically. Only one goroutine of the tree runs at a time. Whenever it
+// would block on a port, it yields and the scheduler picks the next goroutine using a pseudo-random generator
+// seeded with a fixed seed. Items are pushed into and pulled from the ports of the root operator only when all
+// goroutines of the tree are blocked. Given the same blueprint, input and seed, the tree produces the same output
+// in the same order, and the scheduler records the same trace.
+//
+// Elementary operators must run all of their goroutines with Operator.Go and must not wait for each other other than
+// through ports, Operator.Await or by polling with Operator.CheckStop, otherwise the tree deadlocks. Scheduling is slow
+// compared to free running goroutines, so it is meant for testing.
+type Scheduler struct {
+	mutex  sync.Mutex
+	rng    *rand.Rand
+	root   *Operator
+	tasks  []*schedulerTask
+	extern []*schedulerTask
+	holder *schedulerTask
+	counts map[*Operator]int
+	trace  []string
+
+	// epoch is incremented whenever an item has been pushed or pulled, tasks which could not go on during the
+	// current epoch are not scheduled again until it ends
+	epoch int
+
+	// halted is set when the root operator is stopped, the token is not passed on anymore afterwards
+	halted bool
+}
+
+// schedulerTask is a goroutine driven by the scheduler
+type schedulerTask struct {
+	key       string
+	op        *Operator
+	external  bool
+	wake      chan bool
+	blockedAt int
+	yieldedAt int
+}
+
+type schedulerContextKey struct{}
+
+// EXTERNAL_TASK is the name of goroutines outside of the operator tree in the trace of a scheduler.
+const EXTERNAL_TASK = "(external)"
+
+// NewScheduler returns a scheduler which schedules the goroutines in the order determined by seed.
+func NewScheduler(seed int64) *Scheduler {
+	return &Scheduler{
+		rng:    rand.New(rand.NewSource(seed)),
+		counts: make(map[*Operator]int),
+	}
+}
+
+// Trace returns the names of the goroutines in the order they have been scheduled. Goroutines are named after the
+// path of their operator and their number within the operator, e.g. "/sort/merge#0".
+func (s *Scheduler) Trace() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.trace...)
+}
+
+// SetScheduler makes s drive this operator and all of its children. It has to be called on the root operator
+// before starting it.
+func (o *Operator) SetScheduler(s *Scheduler) {
+	if s != nil {
+		s.root = o
+	}
+	o.setScheduler(s)
+}
+
+func (o *Operator) setScheduler(s *Scheduler) {
+	o.scheduler = s
+	for _, c := range o.children {
+		c.setScheduler(s)
+	}
+}
+
+// Scheduler returns the scheduler driving this operator or nil if its goroutines run freely.
+func (o *Operator) Scheduler() *Scheduler {
+	return o.scheduler
+}
+
+func schedulerPath(o *Operator) string {
+	if o.parent == nil {
+		return o.name
+	}
+	return schedulerPath(o.parent) + "/" + o.name
+}
+
+// register adds a new goroutine of operator o. It is not run before the current holder yields.
+func (s *Scheduler) register(o *Operator) *schedulerTask {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t := &schedulerTask{
+		key:       fmt.Sprintf("%s#%d", schedulerPath(o), s.counts[o]),
+		op:        o,
+		wake:      make(chan bool, 1),
+		blockedAt: -1,
+		yieldedAt: -1,
+	}
+	s.counts[o]++
+
+	i := sort.Search(len(s.tasks), func(i int) bool { return s.tasks[i].key >= t.key })
+	s.tasks = append(s.tasks, nil)
+	copy(s.tasks[i+1:], s.tasks[i:])
+	s.tasks[i] = t
+
+	if s.holder == nil {
+		s.dispatch()
+	}
+	return t
+}
+
+// enter registers a goroutine outside of the operator tree and waits until all goroutines of the tree are blocked.
+// The returned context marks the calls made on behalf of the external goroutine.
+func (s *Scheduler) enter(ctx, opCtx context.Context) (*schedulerTask, context.Context, error) {
+	s.mutex.Lock()
+	if s.halted {
+		s.mutex.Unlock()
+		return nil, ctx, errOperatorStopped
+	}
+	t := &schedulerTask{key: EXTERNAL_TASK, external: true, wake: make(chan bool, 1), blockedAt: -1}
+	s.extern = append(s.extern, t)
+	if s.holder == nil {
+		s.dispatch()
+	}
+	s.mutex.Unlock()
+
+	if err := s.acquire(t, ctx, opCtx); err != nil {
+		s.finish(t)
+		return nil, ctx, err
+	}
+	return t, context.WithValue(ctx, schedulerContextKey{}, t), nil
+}
+
+// entered returns true if ctx has been returned by enter
+func entered(ctx context.Context) bool {
+	return ctx.Value(schedulerContextKey{}) != nil
+}
+
+// acquire waits until t is given the token or one of the contexts is done
+func (s *Scheduler) acquire(t *schedulerTask, ctx, opCtx context.Context) error {
+	var opDone <-chan struct{}
+	if opCtx != nil {
+		opDone = opCtx.Done()
+	}
+
+	select {
+	case <-t.wake:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-opDone:
+		return opCtx.Err()
+	}
+}
+
+// finish removes t after its goroutine has returned and passes the token on if t holds it
+func (s *Scheduler) finish(t *schedulerTask) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if t.external {
+		s.extern = removeTask(s.extern, t)
+	} else {
+		s.tasks = removeTask(s.tasks, t)
+	}
+
+	if s.holder == t {
+		// Ending goroutines may have pushed items before
+		s.epoch++
+		s.holder = nil
+		s.dispatch()
+	}
+}
+
+func removeTask(tasks []*schedulerTask, t *schedulerTask) []*schedulerTask {
+	for i, task := range tasks {
+		if task == t {
+			return append(tasks[:i], tasks[i+1:]...)
+		}
+	}
+	return tasks
+}
+
+// dispatch passes the token to the next task. Goroutines of the operator tree are chosen randomly, external
+// goroutines in the order they arrived once all goroutines of the tree are blocked. The token stays free if all
+// goroutines are blocked.
+func (s *Scheduler) dispatch() {
+	if s.halted {
+		return
+	}
+
+	candidates := make([]*schedulerTask, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		if t.blockedAt != s.epoch {
+			candidates = append(candidates, t)
+		}
+	}
+
+	var next *schedulerTask
+	if len(candidates) > 0 {
+		next = candidates[s.rng.Intn(len(candidates))]
+	} else {
+		for _, t := range s.extern {
+			if t.blockedAt != s.epoch {
+				next = t
+				break
+			}
+		}
+	}
+
+	if next == nil {
+		return
+	}
+
+	s.holder = next
+	s.trace = append(s.trace, next.key)
+	next.wake <- true
+}
+
+// wait is called by the goroutine holding the token. It calls try until it succeeds, yielding to other goroutines
+// in between. It returns an error if one of the contexts is done before.
+func (s *Scheduler) wait(try func() bool, ctx, opCtx context.Context) error {
+	for {
+		if try() {
+			s.mutex.Lock()
+			s.epoch++
+			s.mutex.Unlock()
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if opCtx != nil {
+			if err := opCtx.Err(); err != nil {
+				return err
+			}
+		}
+
+		s.mutex.Lock()
+		t := s.holder
+		if t == nil {
+			// Called outside of the scheduled goroutines, which should not happen
+			s.mutex.Unlock()
+			panic("scheduler: blocking call without holding the token")
+		}
+		t.blockedAt = s.epoch
+		s.holder = nil
+		s.dispatch()
+		s.mutex.Unlock()
+
+		if err := s.acquire(t, ctx, opCtx); err != nil {
+			return err
+		}
+	}
+}
+
+// yield is called at points where the goroutine holding the token could go on. It lets the scheduler pick the next
+// goroutine, which may be the same one again. A goroutine yielding again without any item pushed or pulled in between
+// is treated as blocked, so that goroutines polling for a condition do not starve the others.
+func (s *Scheduler) yield(o *Operator, ctx context.Context) error {
+	s.mutex.Lock()
+	t := s.holder
+	if t == nil || t.op != o {
+		// Called outside of the goroutines of o
+		s.mutex.Unlock()
+		return nil
+	}
+	if t.yieldedAt == s.epoch {
+		t.blockedAt = s.epoch
+	}
+	t.yieldedAt = s.epoch
+	s.holder = nil
+	s.dispatch()
+	s.mutex.Unlock()
+
+	return s.acquire(t, ctx, nil)
+}
+
+// halt keeps the token from being passed on after the root operator has been stopped, as its goroutines unwind in
+// no particular order
+func (s *Scheduler) halt() {
+	s.mutex.Lock()
+	s.halted = true
+	s.mutex.Unlock()
+}
+
+// resume lets the token be passed on again when the root operator is started
+func (s *Scheduler) resume() {
+	s.mutex.Lock()
+	s.halted = false
+	s.mutex.Unlock()
+}
@@ -0,0 +1,321 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SnapshotFunc returns the state of an elementary operator. The state must be serializable to JSON.
+type SnapshotFunc func() (interface{}, error)
+
+// Snapshot holds the state of an operator tree which has been halted.
+type Snapshot struct {
+	// Operators maps operator paths (instance names separated by slashes) to their state. The path of the root
+	// operator is empty.
+	Operators map[string]*OperatorSnapshot `json:"operators"`
+}
+
+// OperatorSnapshot holds the state of a single operator and the items waiting in its in ports.
+type OperatorSnapshot struct {
+	State   json.RawMessage          `json:"state,omitempty"`
+	Buffers map[string][]interface{} `json:"buffers,omitempty"`
+}
+
+// OnSnapshot registers f which is called to capture the state of this operator when a snapshot is taken.
+// Elementary operators with state which should survive restarts call OnSnapshot when they start.
+func (o *Operator) OnSnapshot(f SnapshotFunc) {
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+	o.snapshotFunc = f
+}
+
+// RestoreState decodes the state this operator has been restored with into state and returns true. It returns false
+// if there is no state to restore. Markers contained in the state are only restored if state is of type
+// *interface{}, *[]interface{} or *map[string]interface{}.
+func (o *Operator) RestoreState(state interface{}) (bool, error) {
+	o.stateMutex.Lock()
+	raw := o.restoredState
+	o.restoredState = nil
+	o.stateMutex.Unlock()
+
+	if raw == nil {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, state); err != nil {
+		return false, err
+	}
+
+	ports := o.root().portsByName()
+	switch s := state.(type) {
+	case *interface{}:
+		*s = decodeItem(*s, ports)
+	case *[]interface{}:
+		*s = decodeItem(*s, ports).([]interface{})
+	case *map[string]interface{}:
+		*s = decodeItem(*s, ports).(map[string]interface{})
+	}
+
+	return true, nil
+}
+
+// Snapshot captures the state of all operators of this tree and the items waiting in their in ports. The operator
+// must have been halted before, see Halt. Taking a snapshot drains the buffers of the ports, unless capturing the state
+// of an operator fails. Items which were being processed while halting may be emitted again after restoring.
+func (o *Operator) Snapshot() (*Snapshot, error) {
+	if !o.Stopped() || o.runningGoroutines() != 0 {
+		return nil, errors.New("operator must be halted before taking a snapshot")
+	}
+
+	s := &Snapshot{Operators: make(map[string]*OperatorSnapshot)}
+	var err error
+
+	// Capture all states before draining any buffer so that a failing operator leaves the tree as it was
+	o.walkOperators(func(path string, op *Operator) {
+		if err != nil {
+			return
+		}
+
+		op.stateMutex.Lock()
+		f := op.snapshotFunc
+		op.stateMutex.Unlock()
+		if f == nil {
+			return
+		}
+
+		var state interface{}
+		if state, err = f(); err != nil {
+			err = fmt.Errorf("%s: %s", path, err)
+			return
+		}
+		ops := &OperatorSnapshot{}
+		if ops.State, err = json.Marshal(encodeItem(state)); err != nil {
+			err = fmt.Errorf("%s: %s", path, err)
+			return
+		}
+		s.Operators[path] = ops
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	o.walkOperators(func(path string, op *Operator) {
+		op.WalkPorts(func(p *Port) {
+			if p.direction != DIRECTION_IN {
+				return
+			}
+			p.WalkPrimitivePorts(func(pp *Port) {
+				items := pp.drain()
+				if len(items) == 0 {
+					return
+				}
+				ops, ok := s.Operators[path]
+				if !ok {
+					ops = &OperatorSnapshot{}
+					s.Operators[path] = ops
+				}
+				if ops.Buffers == nil {
+					ops.Buffers = make(map[string][]interface{})
+				}
+				for i := range items {
+					items[i] = encodeItem(items[i])
+				}
+				ops.Buffers[pp.String()] = items
+			})
+		})
+	})
+
+	return s, nil
+}
+
+// Restore restores the state of all operators of this tree from s. It has to be called before the operator is
+// started. Elementary operators receive their state by calling RestoreState.
+func (o *Operator) Restore(s *Snapshot) error {
+	ops := make(map[string]*Operator)
+	o.walkOperators(func(path string, op *Operator) {
+		ops[path] = op
+	})
+	ports := o.portsByName()
+
+	for path, opSnap := range s.Operators {
+		op, ok := ops[path]
+		if !ok {
+			return fmt.Errorf("unknown operator in snapshot: %s", path)
+		}
+
+		if opSnap.State != nil {
+			op.stateMutex.Lock()
+			op.restoredState = opSnap.State
+			op.stateMutex.Unlock()
+		}
+
+		for portName, items := range opSnap.Buffers {
+			p, ok := ports[portName]
+			if !ok || p.buf == nil {
+				return fmt.Errorf("cannot restore buffer of port %s", portName)
+			}
+			if len(items) > cap(p.buf)-len(p.buf) {
+				return fmt.Errorf("too many items for port %s", portName)
+			}
+			for _, item := range items {
+				p.buf <- decodeItem(item, ports)
+			}
+		}
+	}
+
+	return nil
+}
+
+// drain removes and returns all items from the buffer of this port without blocking.
+func (p *Port) drain() []interface{} {
+	if p.buf == nil {
+		return nil
+	}
+
+	var items []interface{}
+	for {
+		select {
+		case i, ok := <-p.buf:
+			if !ok {
+				return items
+			}
+			items = append(items, i)
+			p.unspill()
+		default:
+			return items
+		}
+	}
+}
+
+// walkOperators calls handle for this operator and all of its descendants with their paths relative to this operator.
+// The path of this operator is empty.
+func (o *Operator) walkOperators(handle func(path string, op *Operator)) {
+	handle("", o)
+
+	var walk func(prefix []string, op *Operator)
+	walk = func(prefix []string, op *Operator) {
+		for name, c := range op.children {
+			path := append(append([]string{}, prefix...), name)
+			handle(strings.Join(path, "/"), c)
+			walk(path, c)
+		}
+	}
+	walk(nil, o)
+}
+
+// portsByName returns all ports of this operator tree including sub ports indexed by their names.
+func (o *Operator) portsByName() map[string]*Port {
+	ports := make(map[string]*Port)
+
+	var add func(p *Port)
+	add = func(p *Port) {
+		ports[p.String()] = p
+		if p.sub != nil {
+			add(p.sub)
+		}
+		for _, sub := range p.subs {
+			add(sub)
+		}
+	}
+
+	o.walkOperators(func(_ string, op *Operator) {
+		op.WalkPorts(add)
+	})
+
+	return ports
+}
+
+// EncodeItem converts an item into a value which can be serialized to JSON without losing binaries, datetimes,
+// durations and decimals.
+func EncodeItem(item interface{}) interface{} {
+	return encodeItem(item)
+}
+
+// DecodeItem reverts EncodeItem. Markers cannot be decoded without their ports and are left encoded.
+func DecodeItem(item interface{}) interface{} {
+	return decodeItem(item, nil)
+}
+
+// encodeItem converts an item into a value which can be serialized to JSON without losing markers, binaries,
+// datetimes, durations and decimals.
+func encodeItem(item interface{}) interface{} {
+	switch i := item.(type) {
+	case BOS:
+		return map[string]interface{}{"$bos": i.src.String()}
+	case EOS:
+		return map[string]interface{}{"$eos": i.src.String()}
+	case Binary:
+		return map[string]interface{}{"$binary": base64.StdEncoding.EncodeToString(i)}
+	case time.Time:
+		return map[string]interface{}{"$datetime": FormatDateTime(i)}
+	case time.Duration:
+		return map[string]interface{}{"$duration": i.String()}
+	case Decimal:
+		return map[string]interface{}{"$decimal": i.String()}
+	case []interface{}:
+		items := make([]interface{}, len(i))
+		for k, el := range i {
+			items[k] = encodeItem(el)
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, el := range i {
+			m[k] = encodeItem(el)
+		}
+		return m
+	}
+	return item
+}
+
+// decodeItem reverts encodeItem. ports are used to look up the sources of markers.
+func decodeItem(item interface{}, ports map[string]*Port) interface{} {
+	switch i := item.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(i))
+		for k, el := range i {
+			items[k] = decodeItem(el, ports)
+		}
+		return items
+	case map[string]interface{}:
+		if len(i) == 1 {
+			if src, ok := i["$bos"].(string); ok && ports[src] != nil {
+				return BOS{ports[src]}
+			}
+			if src, ok := i["$eos"].(string); ok && ports[src] != nil {
+				return EOS{ports[src]}
+			}
+			if b64, ok := i["$binary"].(string); ok {
+				if b, err := base64.StdEncoding.DecodeString(b64); err == nil {
+					return Binary(b)
+				}
+			}
+			if str, ok := i["$datetime"].(string); ok {
+				if t, err := ParseDateTime(str); err == nil {
+					return t
+				}
+			}
+			if str, ok := i["$duration"].(string); ok {
+				if d, err := ParseDuration(str); err == nil {
+					return d
+				}
+			}
+			if str, ok := i["$decimal"].(string); ok {
+				if d, err := ParseDecimal(str); err == nil {
+					return d
+				}
+			}
+		}
+		m := make(map[string]interface{})
+		for k, el := range i {
+			m[k] = decodeItem(el, ports)
+		}
+		return m
+	}
+	return item
+}
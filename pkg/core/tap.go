@@ -0,0 +1,70 @@
+package core
+
+// TapFunc is called with the primitive port an item has been pushed to and the item itself.
+// Items include BOS and EOS markers.
+type TapFunc func(p *Port, item interface{})
+
+// Tap represents a subscription on a port and all of its sub ports.
+type Tap struct {
+	port  *Port
+	ports []*Port
+	f     TapFunc
+}
+
+// Tap subscribes f to all items pushed through this port. As maps and streams are pushed item by item
+// into their sub ports, f is registered on every primitive port below this port.
+func (p *Port) Tap(f TapFunc) *Tap {
+	t := &Tap{port: p, f: f}
+
+	p.WalkPrimitivePorts(func(sub *Port) {
+		sub.tapMutex.Lock()
+		if sub.taps == nil {
+			sub.taps = make(map[*Tap]bool)
+		}
+		sub.taps[t] = true
+		sub.tapMutex.Unlock()
+
+		t.ports = append(t.ports, sub)
+	})
+
+	return t
+}
+
+// Port returns the port this tap has been created on.
+func (t *Tap) Port() *Port {
+	return t.port
+}
+
+// Untap removes the subscription from all ports.
+func (t *Tap) Untap() {
+	for _, sub := range t.ports {
+		sub.tapMutex.Lock()
+		delete(sub.taps, t)
+		sub.tapMutex.Unlock()
+	}
+	t.ports = nil
+}
+
+// Tapped returns true if there is at least one tap on this port.
+func (p *Port) Tapped() bool {
+	p.tapMutex.RLock()
+	defer p.tapMutex.RUnlock()
+	return len(p.taps) > 0
+}
+
+func (p *Port) notifyTaps(item interface{}) {
+	p.tapMutex.RLock()
+	if len(p.taps) == 0 {
+		p.tapMutex.RUnlock()
+		return
+	}
+	fs := make([]TapFunc, 0, len(p.taps))
+	for t := range p.taps {
+		fs = append(fs, t.f)
+	}
+	p.tapMutex.RUnlock()
+
+	for _, f := range fs {
+		f(p, item)
+	}
+}
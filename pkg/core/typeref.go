@@ -0,0 +1,237 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ParseTypeRef splits a type reference of the form "<blueprint-id>#Name" into blueprint id and type name. The id is
+// uuid.Nil for references of the form "#Name".
+func ParseTypeRef(ref string) (uuid.UUID, string, error) {
+	i := strings.LastIndexByte(ref, '#')
+	if i < 0 {
+		return uuid.Nil, "", fmt.Errorf(`invalid type reference "%s": expected <blueprint-id>#Name`, ref)
+	}
+
+	name := ref[i+1:]
+	if name == "" {
+		return uuid.Nil, "", fmt.Errorf(`invalid type reference "%s": type name missing`, ref)
+	}
+
+	if i == 0 {
+		return uuid.Nil, name, nil
+	}
+
+	id, err := uuid.Parse(ref[:i])
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf(`invalid type reference "%s": %s`, ref, err)
+	}
+	return id, name, nil
+}
+
+// TypeRefs returns the ids of all blueprints other than the blueprint itself whose types are referenced by the
+// blueprint, including references within its own types.
+func (d Blueprint) TypeRefs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0)
+	seen := map[uuid.UUID]bool{d.Id: true, uuid.Nil: true}
+
+	d.walkTypeDefs(func(td *TypeDef) {
+		td.walk(func(td *TypeDef) {
+			if td.Type != "ref" {
+				return
+			}
+			if id, _, err := ParseTypeRef(td.Ref); err == nil && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		})
+	})
+
+	return ids
+}
+
+// ResolveTypes replaces all type references in the ports, properties and instance generics of the blueprint by
+// copies of the referenced types. Blueprints referenced by id are obtained from load. References may not be cyclic.
+func (d *Blueprint) ResolveTypes(load func(uuid.UUID) (*Blueprint, error)) error {
+	r := &typeResolver{
+		load:       load,
+		blueprints: map[uuid.UUID]*Blueprint{d.Id: d},
+		resolving:  make(map[string]bool),
+	}
+
+	srvs := make(map[string]*ServiceDef)
+	for srvName, srv := range d.ServiceDefs {
+		srvCpy := srv.Copy()
+		if err := r.resolve(&srvCpy.In, d.Id); err != nil {
+			return fmt.Errorf(`service "%s": %s`, srvName, err)
+		}
+		if err := r.resolve(&srvCpy.Out, d.Id); err != nil {
+			return fmt.Errorf(`service "%s": %s`, srvName, err)
+		}
+		srvs[srvName] = &srvCpy
+	}
+	d.ServiceDefs = srvs
+
+	dels := make(map[string]*DelegateDef)
+	for delName, del := range d.DelegateDefs {
+		delCpy := del.Copy()
+		if err := r.resolve(&delCpy.In, d.Id); err != nil {
+			return fmt.Errorf(`delegate "%s": %s`, delName, err)
+		}
+		if err := r.resolve(&delCpy.Out, d.Id); err != nil {
+			return fmt.Errorf(`delegate "%s": %s`, delName, err)
+		}
+		dels[delName] = &delCpy
+	}
+	d.DelegateDefs = dels
+
+	for propName, prop := range d.PropertyDefs {
+		propCpy := prop.Copy()
+		if err := r.resolve(&propCpy, d.Id); err != nil {
+			return fmt.Errorf(`property "%s": %s`, propName, err)
+		}
+		d.PropertyDefs[propName] = &propCpy
+	}
+
+	for _, insDef := range d.InstanceDefs {
+		for genName, gen := range insDef.Generics {
+			genCpy := gen.Copy()
+			if err := r.resolve(&genCpy, d.Id); err != nil {
+				return fmt.Errorf(`instance "%s": generic "%s": %s`, insDef.Name, genName, err)
+			}
+			insDef.Generics[genName] = &genCpy
+		}
+	}
+
+	return nil
+}
+
+// walkTypeDefs calls f for all type definitions of the blueprint
+func (d Blueprint) walkTypeDefs(f func(td *TypeDef)) {
+	for _, srv := range d.ServiceDefs {
+		f(&srv.In)
+		f(&srv.Out)
+	}
+	for _, del := range d.DelegateDefs {
+		f(&del.In)
+		f(&del.Out)
+	}
+	for _, prop := range d.PropertyDefs {
+		f(prop)
+	}
+	for _, td := range d.Types {
+		f(td)
+	}
+	for _, insDef := range d.InstanceDefs {
+		for _, gen := range insDef.Generics {
+			f(gen)
+		}
+	}
+}
+
+// walk calls f for the type definition and all type definitions nested within
+func (d *TypeDef) walk(f func(td *TypeDef)) {
+	f(d)
+	if d.Stream != nil {
+		d.Stream.walk(f)
+	}
+	for _, e := range d.Map {
+		e.walk(f)
+	}
+	for _, v := range d.Union {
+		v.walk(f)
+	}
+}
+
+type typeResolver struct {
+	load       func(uuid.UUID) (*Blueprint, error)
+	blueprints map[uuid.UUID]*Blueprint
+	resolving  map[string]bool
+}
+
+func (r *typeResolver) blueprint(id uuid.UUID) (*Blueprint, error) {
+	if bp, ok := r.blueprints[id]; ok {
+		return bp, nil
+	}
+	bp, err := r.load(id)
+	if err != nil {
+		return nil, err
+	}
+	r.blueprints[id] = bp
+	return bp, nil
+}
+
+// resolve replaces the references within td. Local references are looked up in the blueprint with id ctx.
+func (r *typeResolver) resolve(td *TypeDef, ctx uuid.UUID) error {
+	switch td.Type {
+	case "ref":
+		id, name, err := ParseTypeRef(td.Ref)
+		if err != nil {
+			return err
+		}
+		if id == uuid.Nil {
+			id = ctx
+		}
+
+		key := id.String() + "#" + name
+		if r.resolving[key] {
+			return fmt.Errorf("cyclic type reference %s", key)
+		}
+
+		bp, err := r.blueprint(id)
+		if err != nil {
+			return err
+		}
+		named, ok := bp.Types[name]
+		if !ok || named == nil {
+			return fmt.Errorf(`unknown type "%s" in blueprint %s`, name, id)
+		}
+
+		resolved := named.Copy()
+		r.resolving[key] = true
+		err = r.resolve(&resolved, id)
+		delete(r.resolving, key)
+		if err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+
+		resolved.Nullable = resolved.Nullable || td.Nullable
+		resolved.Optional = resolved.Optional || td.Optional
+		if td.Default != nil {
+			resolved.Default = td.Default
+		}
+		*td = resolved
+	case "stream":
+		if td.Stream == nil {
+			return nil
+		}
+		strCpy := td.Stream.Copy()
+		if err := r.resolve(&strCpy, ctx); err != nil {
+			return err
+		}
+		td.Stream = &strCpy
+	case "map":
+		mapCpy := make(TypeDefMap)
+		for k, e := range td.Map {
+			eCpy := e.Copy()
+			if err := r.resolve(&eCpy, ctx); err != nil {
+				return fmt.Errorf("%s: %s", k, err)
+			}
+			mapCpy[k] = &eCpy
+		}
+		td.Map = mapCpy
+	case "union":
+		unionCpy := make(TypeDefMap)
+		for tag, v := range td.Union {
+			vCpy := v.Copy()
+			if err := r.resolve(&vCpy, ctx); err != nil {
+				return fmt.Errorf("%s: %s", tag, err)
+			}
+			unionCpy[tag] = &vCpy
+		}
+		td.Union = unionCpy
+	}
+	return nil
+}
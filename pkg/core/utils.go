@@ -13,3 +13,20 @@ func IsBOS(item interface{}) bool {
 func IsMarker(item interface{}) bool {
 	return IsBOS(item) || IsEOS(item)
 }
+
+// UnionValue makes a value of a union type with the given tag.
+func UnionValue(tag string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{tag: value}
+}
+
+// UnionTag returns the tag and the value of a union value. It returns false if item is no union value.
+func UnionTag(item interface{}) (string, interface{}, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", nil, false
+	}
+	for tag, value := range m {
+		return tag, value, true
+	}
+	return "", nil, false
+}
@@ -0,0 +1,254 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version of a blueprint such as "1.4.2" or "2.0.0-beta".
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	Pre   string
+}
+
+// VersionRange is a set of versions an instance accepts for its blueprint. It is a disjunction of conjunctions of
+// comparisons, e.g. ">=1.2.0 <2.0.0 || ^3.1".
+type VersionRange [][]versionComparator
+
+type versionComparator struct {
+	op string
+	v  Version
+}
+
+// ParseVersion parses a semantic version. A leading "v" is allowed.
+func ParseVersion(s string) (Version, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if parts != 3 {
+		return Version{}, fmt.Errorf("incomplete version: %s", s)
+	}
+	return v, nil
+}
+
+// parsePartialVersion parses versions which may lack minor and patch number or have wildcards ("x", "*") instead.
+// It returns the number of parts given.
+func parsePartialVersion(s string) (Version, int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	var v Version
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.Pre = s[i+1:]
+		s = s[:i]
+		if v.Pre == "" {
+			return Version{}, 0, fmt.Errorf("empty pre-release: %s", s)
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, 0, fmt.Errorf("too many version parts: %s", s)
+	}
+
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			if v.Pre != "" {
+				return Version{}, 0, fmt.Errorf("wildcard with pre-release: %s", s)
+			}
+			return v, i, nil
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version: %s", s)
+		}
+		*nums[i] = n
+	}
+
+	if v.Pre != "" && len(parts) != 3 {
+		return Version{}, 0, fmt.Errorf("pre-release of incomplete version: %s", s)
+	}
+
+	return v, len(parts), nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to or greater than w. Pre-releases are lower than their
+// release.
+func (v Version) Compare(w Version) int {
+	for _, d := range []int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	case v.Pre < w.Pre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// ParseVersionRange parses a range of versions. Supported are exact versions ("1.2.3"), wildcards ("1.2", "1.x",
+// "*"), caret ("^1.2.3") and tilde ("~1.2") ranges and comparisons (">=1.0.0 <2.0.0"), combined with "||".
+func ParseVersionRange(s string) (VersionRange, error) {
+	var r VersionRange
+
+	for _, alt := range strings.Split(s, "||") {
+		var cmps []versionComparator
+
+		for _, term := range strings.Fields(alt) {
+			tcmps, err := parseVersionTerm(term)
+			if err != nil {
+				return nil, err
+			}
+			cmps = append(cmps, tcmps...)
+		}
+
+		if len(cmps) == 0 && strings.Contains(s, "||") {
+			return nil, fmt.Errorf("empty alternative in version range: %s", s)
+		}
+		r = append(r, cmps)
+	}
+
+	return r, nil
+}
+
+func parseVersionTerm(term string) ([]versionComparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op = prefix
+			term = term[len(prefix):]
+			break
+		}
+	}
+
+	v, parts, err := parsePartialVersion(term)
+	if err != nil {
+		return nil, err
+	}
+
+	// upper is the lowest version above all versions matching the given parts
+	upper := v
+	upper.Pre = ""
+	switch parts {
+	case 0:
+		if op == "" || op == "=" || op == ">=" || op == "<=" || op == "^" || op == "~" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid version range: %s%s", op, term)
+	case 1:
+		upper = Version{v.Major + 1, 0, 0, ""}
+	case 2:
+		upper = Version{v.Major, v.Minor + 1, 0, ""}
+	}
+
+	switch op {
+	case "", "=":
+		if parts == 3 {
+			return []versionComparator{{"=", v}}, nil
+		}
+		return []versionComparator{{">=", v}, {"<", upper}}, nil
+	case "^":
+		switch {
+		case v.Major > 0 || parts == 1:
+			upper = Version{v.Major + 1, 0, 0, ""}
+		case v.Minor > 0 || parts == 2:
+			upper = Version{0, v.Minor + 1, 0, ""}
+		default:
+			upper = Version{0, 0, v.Patch + 1, ""}
+		}
+		return []versionComparator{{">=", v}, {"<", upper}}, nil
+	case "~":
+		if parts == 1 {
+			upper = Version{v.Major + 1, 0, 0, ""}
+		} else {
+			upper = Version{v.Major, v.Minor + 1, 0, ""}
+		}
+		return []versionComparator{{">=", v}, {"<", upper}}, nil
+	case ">":
+		if parts == 3 {
+			return []versionComparator{{">", v}}, nil
+		}
+		return []versionComparator{{">=", upper}}, nil
+	case "<=":
+		if parts == 3 {
+			return []versionComparator{{"<=", v}}, nil
+		}
+		return []versionComparator{{"<", upper}}, nil
+	default:
+		return []versionComparator{{op, v}}, nil
+	}
+}
+
+// Contains returns true if v lies within the range.
+func (r VersionRange) Contains(v Version) bool {
+	for _, cmps := range r {
+		if versionSatisfies(v, cmps) {
+			return true
+		}
+	}
+	return false
+}
+
+func versionSatisfies(v Version, cmps []versionComparator) bool {
+	for _, c := range cmps {
+		d := v.Compare(c.v)
+		var ok bool
+		switch c.op {
+		case "=":
+			ok = d == 0
+		case ">":
+			ok = d > 0
+		case ">=":
+			ok = d >= 0
+		case "<":
+			ok = d < 0
+		case "<=":
+			ok = d <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsString returns true if version is a valid version within the range. Unversioned blueprints only lie
+// within ranges accepting any version.
+func (r VersionRange) ContainsString(version string) bool {
+	if version == "" {
+		for _, cmps := range r {
+			if len(cmps) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	v, err := ParseVersion(version)
+	return err == nil && r.Contains(v)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"time"
 )
 
 type MapStr map[string]interface{}
@@ -64,6 +65,12 @@ func CleanValue(v interface{}) interface{} {
 		return v
 	case Binary:
 		return v
+	case time.Time:
+		return v
+	case time.Duration:
+		return v
+	case Decimal:
+		return v
 	case int:
 		return float64(v)
 	case float64:
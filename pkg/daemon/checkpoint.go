@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// checkpoint holds everything required to resume a running operator after it has been halted
+type checkpoint struct {
+	// JSON
+	Handle     string          `json:"handle"`
+	Blueprint  uuid.UUID       `json:"blueprint"`
+	Generics   core.Generics   `json:"generics,omitempty"`
+	Properties core.Properties `json:"properties,omitempty"`
+	Snapshot   *core.Snapshot  `json:"snapshot"`
+}
+
+var checkpointHandle = regexp.MustCompile(`^\w+$`)
+
+func checkpointPath(dir string, handle string) string {
+	return filepath.Join(dir, handle+".json")
+}
+
+// Checkpoint stops the running operator, takes a snapshot of its state and writes it to dir. The operator is only
+// halted for good once the checkpoint has been written, otherwise it goes on running.
+func (rom *runningOperatorManager) Checkpoint(ro *runningOperator, dir string) (*checkpoint, error) {
+	if dir == "" {
+		return nil, errors.New("no checkpoint directory configured")
+	}
+
+	// The snapshot can only be taken while no goroutine of the operator is running
+	if err := ro.op.HaltTimeout(haltTimeout); err != nil {
+		rom.Halt(ro)
+		return nil, err
+	}
+
+	snap, err := ro.op.Snapshot()
+	if err != nil {
+		// Items waiting in the ports are kept, but elementary operators start over without their state
+		ro.op.Start()
+		return nil, err
+	}
+
+	cp := &checkpoint{ro.Handle, ro.Blueprint, ro.gens, ro.props, snap}
+	if err := writeCheckpoint(dir, cp); err != nil {
+		// Put back the items taken from the ports by the snapshot
+		if rerr := ro.op.Restore(snap); rerr != nil {
+			log.Printf("operator %s (id: %s) could not be restored: %s", ro.op.Name(), ro.Handle, rerr)
+		}
+		ro.op.Start()
+		return nil, err
+	}
+
+	if err := rom.Halt(ro); err != nil {
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+// writeCheckpoint writes cp to dir, replacing an older checkpoint with the same handle only once cp has been written
+// completely
+func writeCheckpoint(dir string, cp *checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	path := checkpointPath(dir, cp.Handle)
+	if err := ioutil.WriteFile(path+".tmp", data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+	return nil
+}
+
+// Resume starts the operator stored in the checkpoint with the given handle and restores its state.
+func (rom *runningOperatorManager) Resume(dir string, handle string, st storage.Storage, hub *Hub) (*runningOperator, error) {
+	if !checkpointHandle.MatchString(handle) {
+		return nil, fmt.Errorf("invalid checkpoint handle: %s", handle)
+	}
+
+	data, err := ioutil.ReadFile(checkpointPath(dir, handle))
+	if err != nil {
+		return nil, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+
+	return rom.Exec(cp.Blueprint, cp.Generics, cp.Properties, st, hub, cp.Snapshot)
+}
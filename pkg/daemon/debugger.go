@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Bitspark/slang/pkg/core"
+)
+
+// debugCommand is sent by clients over the websocket to drive the debugger of a running operator
+type debugCommand struct {
+	// JSON
+	Handle string `json:"handle"`
+	// Command is one of "break", "unbreak", "continue", "step", "set" and "state"
+	Command    string      `json:"command"`
+	Port       string      `json:"port,omitempty"`
+	Condition  string      `json:"condition,omitempty"`
+	Breakpoint int         `json:"breakpoint,omitempty"`
+	Item       interface{} `json:"item,omitempty"`
+}
+
+// debuggerState is broadcast whenever an item is paused and in reply to debug commands
+type debuggerState struct {
+	// JSON
+	Handle      string            `json:"handle"`
+	Breakpoints []core.Breakpoint `json:"breakpoints"`
+	Paused      *core.Pause       `json:"paused"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// incomingMessage is a message received from a client over the websocket
+type incomingMessage struct {
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (rop *runningOperator) attachDebugger(hub *Hub) {
+	rop.debugger = core.NewDebugger()
+	rop.op.SetDebugger(rop.debugger)
+
+	if hub != nil {
+		rop.debugger.OnPause(func(core.Pause) {
+			hub.broadCastTo(Root, Debugger, rop.DebuggerState())
+		})
+	}
+}
+
+// DebuggerState returns the breakpoints of the running operator and the item currently paused.
+func (rop *runningOperator) DebuggerState() *debuggerState {
+	state := &debuggerState{Handle: rop.Handle, Breakpoints: rop.debugger.Breakpoints()}
+	if pause, ok := rop.debugger.Paused(); ok {
+		pause.Item = core.PlainItem(pause.Item)
+		state.Paused = &pause
+	}
+	return state
+}
+
+// Debug executes cmd on the debugger of the running operator.
+func (rop *runningOperator) Debug(cmd debugCommand) error {
+	d := rop.debugger
+
+	switch cmd.Command {
+	case "break":
+		_, err := d.SetBreakpoint(cmd.Port, cmd.Condition)
+		return err
+	case "unbreak":
+		return d.RemoveBreakpoint(cmd.Breakpoint)
+	case "continue":
+		return d.Continue()
+	case "step":
+		d.Step()
+		return nil
+	case "set":
+		return d.SetItem(cmd.Item)
+	case "state":
+		return nil
+	}
+	return fmt.Errorf("unknown debug command: %s", cmd.Command)
+}
+
+// handleIncoming executes the commands clients send over the websocket and broadcasts the results
+func (h *Hub) handleIncoming(data []byte) {
+	var msg incomingMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	if msg.Topic != Debugger.String() {
+		return
+	}
+
+	var cmd debugCommand
+	if err := json.Unmarshal(msg.Payload, &cmd); err != nil {
+		h.broadCastTo(Root, Debugger, &debuggerState{Error: err.Error()})
+		return
+	}
+
+	rop, err := romanager.GetByHandle(cmd.Handle)
+	if err != nil {
+		h.broadCastTo(Root, Debugger, &debuggerState{Handle: cmd.Handle, Error: err.Error()})
+		return
+	}
+
+	err = rop.Debug(cmd)
+	state := rop.DebuggerState()
+	if err != nil {
+		state.Error = err.Error()
+	}
+	h.broadCastTo(Root, Debugger, state)
+}
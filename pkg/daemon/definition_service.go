@@ -6,8 +6,12 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/Bitspark/slang/pkg/api"
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/thoas/go-funk"
 )
 
 var DefinitionService = &Service{map[string]*Endpoint{
@@ -28,7 +32,18 @@ var DefinitionService = &Service{map[string]*Endpoint{
 		var err error
 		blueprints := make([]blueprintJSON, 0)
 
-		opIds, err := st.List()
+		// Blueprints can be filtered by name or tag, backends keeping an index look them up without loading all
+		var opIds []uuid.UUID
+		matches := func(blueprint *core.Blueprint) bool { return true }
+		if name := r.URL.Query().Get("name"); name != "" {
+			opIds, err = st.FindByName(name)
+			matches = func(blueprint *core.Blueprint) bool { return blueprint.Meta.Name == name }
+		} else if tag := r.URL.Query().Get("tag"); tag != "" {
+			opIds, err = st.FindByTag(tag)
+			matches = func(blueprint *core.Blueprint) bool { return funk.ContainsString(blueprint.Meta.Tags, tag) }
+		} else {
+			opIds, err = st.List()
+		}
 
 		if err == nil {
 			builtinOpIds := elem.GetBuiltinIds()
@@ -41,6 +56,10 @@ var DefinitionService = &Service{map[string]*Endpoint{
 					break
 				}
 
+				if !matches(blueprint) {
+					continue
+				}
+
 				blueprints = append(blueprints, blueprintJSON{
 					Type: "elementary",
 					Def:  *blueprint,
@@ -101,18 +120,224 @@ var DefinitionService = &Service{map[string]*Endpoint{
 				return
 			}
 
+			saved := make([]uuid.UUID, 0)
+			toSave := make([]core.Blueprint, 0)
 			for _, bp := range bpList.Blueprints {
 				if !st.IsSavedInWritableBackend(bp.Id) && st.IsSaved(bp.Id) {
 					continue
 				}
+				toSave = append(toSave, bp)
+				saved = append(saved, bp.Id)
+			}
 
-				if _, err = st.Save(bp); err != nil {
-					fail(&Error{Msg: err.Error(), Code: "E000X"})
-					return
-				}
+			// Backends keeping the history of blueprints commit them with the given message and author
+			message := r.URL.Query().Get("message")
+			author := r.URL.Query().Get("author")
+			if err = st.SaveAllWithMessage(toSave, message, author); err != nil {
+				fail(&Error{Msg: err.Error(), Code: "E000X"})
+				return
+			}
+
+			// Running operators keep their definition unless they are reloaded explicitly
+			reload := r.URL.Query().Get("reload") == "true"
+			affected := romanager.BlueprintsChanged(saved, st, GetHub(r), reload)
+
+			sendSuccess(w, &responseOK{Data: affected})
+		}
+	}},
+	`/def/{blueprint:[0-9a-f]{8}-[0-9a-f-]+}/lint/`: {func(w http.ResponseWriter, r *http.Request) {
+		st := GetStorage(r)
+		bpid, err := uuid.Parse(mux.Vars(r)["blueprint"])
+		if err != nil {
+			responseError(w, http.StatusBadRequest, err, "E01")
+			return
+		}
+
+		blueprint, err := st.Load(bpid)
+		if err != nil {
+			responseError(w, http.StatusNotFound, err, "E02")
+			return
+		}
+
+		if r.Method == "GET" {
+			/*
+				Check blueprint and its dependencies without running it, properties are passed as query parameters
+			*/
+			r.ParseForm()
+			props, err := parseProperties(r.Form, blueprint.PropertyDefs)
+			if err != nil {
+				responseError(w, http.StatusBadRequest, err, "E03")
+				return
+			}
+
+			problems, err := api.Lint(bpid, nil, props, st)
+			if err != nil {
+				responseError(w, http.StatusBadRequest, err, "E04")
+				return
+			}
+
+			objects := make([]interface{}, len(problems))
+			for i, p := range problems {
+				objects[i] = p
+			}
+			response(w, http.StatusOK, &ResponseJSON{Objects: objects, Status: "ok"})
+		}
+	}},
+	`/def/{blueprint:[0-9a-f]{8}-[0-9a-f-]+}/history/`: {func(w http.ResponseWriter, r *http.Request) {
+		st := GetStorage(r)
+		bpid, err := uuid.Parse(mux.Vars(r)["blueprint"])
+		if err != nil {
+			responseError(w, http.StatusBadRequest, err, "E01")
+			return
+		}
+
+		if r.Method == "GET" {
+			/*
+				List the revisions of the blueprint, latest first
+			*/
+			revisions, err := st.History(bpid)
+			if err != nil {
+				responseError(w, http.StatusNotFound, err, "E02")
+				return
+			}
+
+			objects := make([]interface{}, len(revisions))
+			for i, rev := range revisions {
+				objects[i] = rev
+			}
+			response(w, http.StatusOK, &ResponseJSON{Objects: objects, Status: "ok"})
+		}
+	}},
+	`/def/{blueprint:[0-9a-f]{8}-[0-9a-f-]+}/history/{revision}/`: {func(w http.ResponseWriter, r *http.Request) {
+		st := GetStorage(r)
+		bpid, err := uuid.Parse(mux.Vars(r)["blueprint"])
+		if err != nil {
+			responseError(w, http.StatusBadRequest, err, "E01")
+			return
+		}
+		revision := mux.Vars(r)["revision"]
+
+		if r.Method == "GET" {
+			/*
+				Load the blueprint as it was at the revision
+			*/
+			blueprint, err := st.LoadRevision(bpid, revision)
+			if err != nil {
+				responseError(w, http.StatusNotFound, err, "E02")
+				return
+			}
+			response(w, http.StatusOK, &ResponseJSON{Object: blueprint, Status: "ok"})
+		}
+	}},
+	`/def/{blueprint:[0-9a-f]{8}-[0-9a-f-]+}/revert/`: {func(w http.ResponseWriter, r *http.Request) {
+		st := GetStorage(r)
+		bpid, err := uuid.Parse(mux.Vars(r)["blueprint"])
+		if err != nil {
+			responseError(w, http.StatusBadRequest, err, "E01")
+			return
+		}
+
+		if r.Method == "POST" {
+			/*
+				Save the blueprint as it was at the revision and announce the affected running operators
+			*/
+			type revertJSON struct {
+				Revision string `json:"revision"`
+				Author   string `json:"author"`
+				Reload   bool   `json:"reload"`
+			}
+
+			var req revertJSON
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				responseError(w, http.StatusBadRequest, err, "E03")
+				return
+			}
+
+			if err := st.Revert(bpid, req.Revision, req.Author); err != nil {
+				responseError(w, http.StatusBadRequest, err, "E04")
+				return
+			}
+
+			affected := romanager.BlueprintsChanged([]uuid.UUID{bpid}, st, GetHub(r), req.Reload)
+			sendSuccess(w, &responseOK{Data: affected})
+		}
+	}},
+	`/def/{blueprint:[0-9a-f]{8}-[0-9a-f-]+}/dependencies/`: {func(w http.ResponseWriter, r *http.Request) {
+		st := GetStorage(r)
+		bpid, err := uuid.Parse(mux.Vars(r)["blueprint"])
+		if err != nil {
+			responseError(w, http.StatusBadRequest, err, "E01")
+			return
+		}
+
+		if r.Method == "GET" {
+			/*
+				List the blueprints the blueprint uses, including their dependencies if transitive is set
+			*/
+			deps, err := st.Dependencies(bpid, r.URL.Query().Get("transitive") == "true")
+			if err != nil {
+				responseError(w, http.StatusNotFound, err, "E02")
+				return
 			}
+			response(w, http.StatusOK, &ResponseJSON{Objects: idObjects(deps), Status: "ok"})
+		}
+	}},
+	`/def/{blueprint:[0-9a-f]{8}-[0-9a-f-]+}/dependents/`: {func(w http.ResponseWriter, r *http.Request) {
+		st := GetStorage(r)
+		bpid, err := uuid.Parse(mux.Vars(r)["blueprint"])
+		if err != nil {
+			responseError(w, http.StatusBadRequest, err, "E01")
+			return
+		}
 
-			sendSuccess(w, nil)
+		if r.Method == "GET" {
+			/*
+				List the blueprints using the blueprint, including their dependents if transitive is set
+			*/
+			deps, err := st.Dependents(bpid, r.URL.Query().Get("transitive") == "true")
+			if err != nil {
+				responseError(w, http.StatusNotFound, err, "E02")
+				return
+			}
+			response(w, http.StatusOK, &ResponseJSON{Objects: idObjects(deps), Status: "ok"})
+		}
+	}},
+	"/def/cycles/": {func(w http.ResponseWriter, r *http.Request) {
+		st := GetStorage(r)
+
+		if r.Method == "GET" {
+			/*
+				List the groups of blueprints depending on each other
+			*/
+			cycles := st.Cycles()
+			objects := make([]interface{}, len(cycles))
+			for i, cycle := range cycles {
+				objects[i] = cycle
+			}
+			response(w, http.StatusOK, &ResponseJSON{Objects: objects, Status: "ok"})
+		}
+	}},
+	"/def/missing/": {func(w http.ResponseWriter, r *http.Request) {
+		st := GetStorage(r)
+
+		if r.Method == "GET" {
+			/*
+				List the references of blueprints to unknown blueprints
+			*/
+			missing := st.MissingReferences()
+			objects := make([]interface{}, len(missing))
+			for i, m := range missing {
+				objects[i] = m
+			}
+			response(w, http.StatusOK, &ResponseJSON{Objects: objects, Status: "ok"})
 		}
 	}},
 }}
+
+func idObjects(ids []uuid.UUID) []interface{} {
+	objects := make([]interface{}, len(ids))
+	for i, id := range ids {
+		objects[i] = id
+	}
+	return objects
+}
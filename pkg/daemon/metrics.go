@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Bitspark/slang/pkg/core"
+)
+
+// metricFamily collects the samples of one metric so they can be written in the Prometheus text format.
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+func (mf *metricFamily) add(labels string, value interface{}) {
+	mf.samples = append(mf.samples, fmt.Sprintf("%s{%s} %v", mf.name, labels, value))
+}
+
+func (mf *metricFamily) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", mf.name, mf.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", mf.name, mf.kind)
+	sort.Strings(mf.samples)
+	for _, s := range mf.samples {
+		fmt.Fprintln(w, s)
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func metricLabels(kv ...string) string {
+	pairs := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, kv[i], labelValueEscaper.Replace(kv[i+1])))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// writeMetrics writes port and operator metrics of all running operators in the Prometheus text format.
+// Samples are labelled with the handle of the running operator, the blueprint id of the (flattened) instance,
+// its instance path and the port name.
+func writeMetrics(w io.Writer, rops []*runningOperator) {
+	pushed := &metricFamily{name: "slang_port_items_pushed_total", help: "Number of items pushed to a port.", kind: "counter"}
+	pulled := &metricFamily{name: "slang_port_items_pulled_total", help: "Number of items pulled from a port.", kind: "counter"}
+	buffered := &metricFamily{name: "slang_port_buffer_items", help: "Number of items waiting in the buffer of a port.", kind: "gauge"}
+	capacity := &metricFamily{name: "slang_port_buffer_capacity", help: "Capacity of the buffer of a port.", kind: "gauge"}
+	blocked := &metricFamily{name: "slang_port_pull_blocked_seconds_total", help: "Time spent blocked while pulling from a port.", kind: "counter"}
+	goroutines := &metricFamily{name: "slang_operator_goroutines", help: "Number of running goroutines of an operator.", kind: "gauge"}
+	goroutinesTotal := &metricFamily{name: "slang_operator_goroutines_started_total", help: "Number of goroutines started by an operator.", kind: "counter"}
+	goroutineTime := &metricFamily{name: "slang_operator_goroutine_seconds_total", help: "Accumulated lifetime of the goroutines of an operator.", kind: "counter"}
+
+	for _, rop := range rops {
+		ops := []*core.Operator{rop.op}
+		for _, c := range rop.op.Children() {
+			ops = append(ops, c)
+		}
+
+		for _, op := range ops {
+			opLabels := metricLabels("handle", rop.Handle, "blueprint", op.Id().String(), "instance", op.Name())
+
+			if op.Builtin() {
+				om := op.Metrics()
+				goroutines.add(opLabels, om.Goroutines)
+				goroutinesTotal.add(opLabels, om.GoroutinesTotal)
+				goroutineTime.add(opLabels, om.GoroutineTime.Seconds())
+			}
+
+			op.WalkPorts(func(p *core.Port) {
+				p.WalkPrimitivePorts(func(p *core.Port) {
+					pm := p.Metrics()
+					portLabels := opLabels + "," + metricLabels("port", p.String())
+					pushed.add(portLabels, pm.Pushed)
+					pulled.add(portLabels, pm.Pulled)
+					if pm.BufferCapacity > 0 {
+						buffered.add(portLabels, pm.Buffered)
+						capacity.add(portLabels, pm.BufferCapacity)
+						blocked.add(portLabels, pm.PullBlocked.Seconds())
+					}
+				})
+			})
+		}
+	}
+
+	for _, mf := range []*metricFamily{pushed, pulled, buffered, capacity, blocked, goroutines, goroutinesTotal, goroutineTime} {
+		mf.write(w)
+	}
+}
+
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	rops := romanager.running()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	writeMetrics(w, rops)
+}
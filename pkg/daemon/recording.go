@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+)
+
+// replayTimeout is the default time a replay waits for each recorded output
+const replayTimeout = 5 * time.Second
+
+// recording holds the file the items of a running operator are recorded to
+type recording struct {
+	dir       string
+	delegates []string
+	file      *os.File
+	recorder  *core.Recorder
+}
+
+func recordingPath(dir string, handle string) string {
+	return filepath.Join(dir, handle+".ndjson")
+}
+
+// Record starts recording all items entering and leaving the main service and the given delegates of the running
+// operator to dir. Records are appended to an existing recording of the same handle, so that reloads continue it.
+func (rom *runningOperatorManager) Record(ro *runningOperator, dir string, delegates []string) error {
+	if dir == "" {
+		return errors.New("no recording directory configured")
+	}
+	if ro.recording != nil {
+		return fmt.Errorf("operator %s is already recorded", ro.Handle)
+	}
+
+	f, err := os.OpenFile(recordingPath(dir, ro.Handle), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	rec, err := core.NewRecorder(ro.op, f, delegates)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	ro.recording = &recording{dir, delegates, f, rec}
+	return nil
+}
+
+// StopRecording stops recording the running operator.
+func (rom *runningOperatorManager) StopRecording(ro *runningOperator) error {
+	if ro.recording == nil {
+		return fmt.Errorf("operator %s is not recorded", ro.Handle)
+	}
+
+	err := ro.recording.recorder.Close()
+	if cerr := ro.recording.file.Close(); err == nil {
+		err = cerr
+	}
+	ro.recording = nil
+	return err
+}
+
+// Replay feeds the recording with the given handle found in dir into the running operator and compares its outputs
+// with the recorded ones. The running operator should have been started freshly from the recorded blueprint.
+func (rom *runningOperatorManager) Replay(ro *runningOperator, dir string, handle string, timeout time.Duration) (*core.ReplayResult, error) {
+	if !checkpointHandle.MatchString(handle) {
+		return nil, fmt.Errorf("invalid recording handle: %s", handle)
+	}
+
+	f, err := os.Open(recordingPath(dir, handle))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return core.Replay(ro.op, f, timeout)
+}
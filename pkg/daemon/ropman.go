@@ -4,9 +4,12 @@ import (
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
+	"log"
 	"math/rand"
 	"sort"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/api"
 	"github.com/Bitspark/slang/pkg/core"
@@ -24,10 +27,19 @@ type runningOperator struct {
 	URL       string       `json:"url"`
 
 	op       *core.Operator
+	gens     core.Generics
+	props    core.Properties
+	deps     []uuid.UUID
 	incoming chan interface{}
 	outgoing chan interface{}
 	inStop   chan bool
 	outStop  chan bool
+
+	taps     map[string]*core.Tap
+	tapMutex sync.Mutex
+
+	debugger  *core.Debugger
+	recording *recording
 }
 
 func (rop *runningOperator) Push(data interface{}) {
@@ -45,6 +57,67 @@ func (rop *runningOperator) Pull() interface{} {
 	}
 }
 
+// Tap subscribes to all items flowing through the port referenced by portRef and relays them to hub.
+// The reference is resolved against the compiled operator, so nested instances are addressed by their
+// flattened names, e.g. "outer#parser)rows".
+func (rop *runningOperator) Tap(portRef string, hub *Hub) error {
+	p, err := core.ParsePortReference(portRef, rop.op)
+	if err != nil {
+		return err
+	}
+
+	rop.tapMutex.Lock()
+	defer rop.tapMutex.Unlock()
+
+	if _, ok := rop.taps[portRef]; ok {
+		return nil
+	}
+
+	rop.taps[portRef] = p.Tap(func(p *core.Port, item interface{}) {
+		po := portOutput{rop.Handle, p.String(), core.PlainItem(item), core.IsEOS(item), core.IsBOS(item), p}
+		if po.IsBOS || po.IsEOS {
+			po.Data = nil
+		}
+		hub.broadCastTo(Root, Tap, &po)
+	})
+
+	return nil
+}
+
+// Untap stops relaying items of the port referenced by portRef.
+func (rop *runningOperator) Untap(portRef string) error {
+	rop.tapMutex.Lock()
+	defer rop.tapMutex.Unlock()
+
+	t, ok := rop.taps[portRef]
+	if !ok {
+		return fmt.Errorf("port not tapped: %s", portRef)
+	}
+
+	t.Untap()
+	delete(rop.taps, portRef)
+	return nil
+}
+
+func (rop *runningOperator) UntapAll() {
+	rop.tapMutex.Lock()
+	defer rop.tapMutex.Unlock()
+
+	for portRef, t := range rop.taps {
+		t.Untap()
+		delete(rop.taps, portRef)
+	}
+}
+
+func (rop *runningOperator) TappedPorts() []string {
+	rop.tapMutex.Lock()
+	defer rop.tapMutex.Unlock()
+
+	portRefs := funk.Keys(rop.taps).([]string)
+	sort.Strings(portRefs)
+	return portRefs
+}
+
 type portOutput struct {
 	// JSON
 	Handle string      `json:"handle"`
@@ -83,19 +156,42 @@ func hashProperties(p core.Properties) PropertiesHash {
 	return md5.Sum(serializedProps)
 }
 
+type operatorError struct {
+	// JSON
+	Handle   string `json:"handle"`
+	Operator string `json:"operator"`
+	Error    string `json:"error"`
+}
+
+type blueprintChange struct {
+	// JSON
+	Blueprint uuid.UUID `json:"blueprint"`
+	Handles   []string  `json:"handles"`
+	Reloaded  bool      `json:"reloaded"`
+}
+
 type runningOperatorManager struct {
 	ropByHandle   map[string]*runningOperator
 	handleByProps map[PropertiesHash]string
+	// mutex guards both maps, which are accessed by concurrent requests
+	mutex sync.RWMutex
 }
 
+// haltTimeout is the time a running operator is given to tear down all of its goroutines
+const haltTimeout = 5 * time.Second
+
 var rnd = rand.New(rand.NewSource(99))
 var romanager = &runningOperatorManager{
 	make(map[string]*runningOperator),
 	make(map[PropertiesHash]string),
+	sync.RWMutex{},
 }
 
-func (rom *runningOperatorManager) start(op *core.Operator) *runningOperator {
-	handle := strconv.FormatInt(rnd.Int63(), 16)
+func newHandle() string {
+	return strconv.FormatInt(rnd.Int63(), 16)
+}
+
+func (rom *runningOperatorManager) start(handle string, op *core.Operator, gens core.Generics, props core.Properties, deps []uuid.UUID, hub *Hub) *runningOperator {
 	url := "/run/" + handle + "/"
 	ro := &runningOperator{
 		op.Id(),
@@ -104,10 +200,24 @@ func (rom *runningOperatorManager) start(op *core.Operator) *runningOperator {
 		handle,
 		url,
 		op,
+		gens,
+		props,
+		deps,
 		make(chan interface{}),
 		make(chan interface{}),
 		make(chan bool),
 		make(chan bool),
+		make(map[string]*core.Tap),
+		sync.Mutex{},
+		nil,
+		nil,
+	}
+	ro.attachDebugger(hub)
+
+	if hub != nil {
+		op.SetErrorHandler(func(err *core.SlangError) {
+			hub.broadCastTo(Root, OperatorError, &operatorError{handle, err.Path(), err.Cause.Error()})
+		})
 	}
 
 	op.Main().Out().Bufferize()
@@ -120,10 +230,25 @@ func (rom *runningOperatorManager) addRopAccess(rop *runningOperator, props core
 	propsHash := hashProperties(props)
 	handle := rop.Handle
 
+	rom.mutex.Lock()
+	defer rom.mutex.Unlock()
 	rom.handleByProps[propsHash] = handle
 	rom.ropByHandle[handle] = rop
 }
 
+// running returns all running operators ordered by their handles.
+func (rom *runningOperatorManager) running() []*runningOperator {
+	rom.mutex.RLock()
+	rops := make([]*runningOperator, 0, len(rom.ropByHandle))
+	for _, ro := range rom.ropByHandle {
+		rops = append(rops, ro)
+	}
+	rom.mutex.RUnlock()
+
+	sort.Slice(rops, func(i, j int) bool { return rops[i].Handle < rops[j].Handle })
+	return rops
+}
+
 func (rom *runningOperatorManager) handleInputOutput(ro *runningOperator) {
 	op := ro.op
 
@@ -164,7 +289,8 @@ func (rom *runningOperatorManager) handleInputOutput(ro *runningOperator) {
 		******/
 
 		<-ro.outStop
-		ro.outStop <- true
+		// unblock everyone still waiting for output
+		close(ro.outStop)
 	}()
 
 	/*
@@ -184,33 +310,161 @@ func (rom *runningOperatorManager) handleInputOutput(ro *runningOperator) {
 	*/
 }
 
-func (rom *runningOperatorManager) Exec(bpid uuid.UUID, gens core.Generics, props core.Properties, st storage.Storage) (*runningOperator, error) {
+// Exec builds, compiles and starts the operator. If snap is given, the state of the operator is restored from it
+// before starting. Errors raised by the running operator are relayed to hub, if given.
+func (rom *runningOperatorManager) Exec(bpid uuid.UUID, gens core.Generics, props core.Properties, st storage.Storage, hub *Hub, snap *core.Snapshot) (*runningOperator, error) {
 	op, err := api.BuildAndCompile(bpid, gens, props, st)
 
 	if err != nil {
 		return nil, err
 	}
 
-	ro := rom.start(op)
+	if snap != nil {
+		if err := op.Restore(snap); err != nil {
+			return nil, err
+		}
+	}
+
+	deps, err := api.Dependencies(bpid, st)
+	if err != nil {
+		return nil, err
+	}
+
+	ro := rom.start(newHandle(), op, gens, props, deps, hub)
 	rom.addRopAccess(ro, props)
 	rom.handleInputOutput(ro)
 
 	return ro, nil
 }
 
+// Reload rebuilds the running operator from the blueprints currently found in st and restarts it in place. Handle,
+// generics, properties, tapped ports, breakpoints and recordings are kept. The running operator is left untouched if
+// building fails.
+func (rom *runningOperatorManager) Reload(ro *runningOperator, st storage.Storage, hub *Hub) (*runningOperator, error) {
+	op, err := api.BuildAndCompile(ro.Blueprint, ro.gens, ro.props, st)
+	if err != nil {
+		return nil, err
+	}
+
+	deps, err := api.Dependencies(ro.Blueprint, st)
+	if err != nil {
+		return nil, err
+	}
+
+	tapped := ro.TappedPorts()
+	breakpoints := ro.debugger.Breakpoints()
+	rec := ro.recording
+	if err := rom.Halt(ro); err != nil {
+		log.Printf("operator %s (id: %s) did not stop in time: %s", ro.op.Name(), ro.Handle, err)
+	}
+
+	nro := rom.start(ro.Handle, op, ro.gens, ro.props, deps, hub)
+	rom.addRopAccess(nro, ro.props)
+	rom.handleInputOutput(nro)
+
+	if rec != nil {
+		if err := rom.Record(nro, rec.dir, rec.delegates); err != nil {
+			log.Printf("operator %s (id: %s) cannot be recorded anymore: %s", op.Name(), nro.Handle, err)
+		}
+	}
+
+	for _, bp := range breakpoints {
+		if _, err := nro.debugger.SetBreakpoint(bp.Port, bp.Condition); err != nil {
+			log.Printf("operator %s (id: %s) cannot break on %s anymore: %s", op.Name(), nro.Handle, bp.Port, err)
+		}
+	}
+
+	if isQuasiTrigger(op.Main().In()) {
+		op.Main().In().Push(nil)
+	}
+
+	if hub != nil {
+		for _, portRef := range tapped {
+			if err := nro.Tap(portRef, hub); err != nil {
+				log.Printf("operator %s (id: %s) cannot tap %s anymore: %s", op.Name(), nro.Handle, portRef, err)
+			}
+		}
+	}
+
+	return nro, nil
+}
+
+// Dependents returns all running operators which are built from the blueprint with id bpid, either directly or
+// because one of their instances uses it.
+func (rom *runningOperatorManager) Dependents(bpid uuid.UUID) []*runningOperator {
+	rops := make([]*runningOperator, 0)
+	for _, ro := range rom.running() {
+		if funk.Contains(ro.deps, bpid) {
+			rops = append(rops, ro)
+		}
+	}
+	return rops
+}
+
+// Halt stops the running operator and waits at most haltTimeout for all of its goroutines to return.
 func (rom *runningOperatorManager) Halt(ro *runningOperator) error {
-	fmt.Println("OP STOP")
-	go ro.op.Stop()
-	fmt.Println("IN STOP")
+	ro.UntapAll()
+	err := ro.op.HaltTimeout(haltTimeout)
+	if ro.recording != nil {
+		if rerr := rom.StopRecording(ro); rerr != nil {
+			log.Printf("operator %s (id: %s) could not be recorded: %s", ro.op.Name(), ro.Handle, rerr)
+		}
+	}
 	ro.inStop <- true
-	fmt.Println("OUT STOP")
 	ro.outStop <- true
-	fmt.Println("DELETE")
-	delete(rom.ropByHandle, ro.Handle)
-	return nil
+
+	rom.mutex.Lock()
+	// ro may have been replaced by a reloaded operator with the same handle in the meantime
+	if rom.ropByHandle[ro.Handle] == ro {
+		delete(rom.ropByHandle, ro.Handle)
+	}
+	propsHash := hashProperties(ro.props)
+	if rom.handleByProps[propsHash] == ro.Handle && rom.ropByHandle[ro.Handle] == nil {
+		delete(rom.handleByProps, propsHash)
+	}
+	rom.mutex.Unlock()
+	return err
 }
 
-func (rom runningOperatorManager) GetByHandle(handle string) (*runningOperator, error) {
+// BlueprintsChanged announces the running operators affected by changes of the blueprints with ids bpids to hub
+// and restarts them if reload is set. It returns the handles of all affected running operators.
+func (rom *runningOperatorManager) BlueprintsChanged(bpids []uuid.UUID, st storage.Storage, hub *Hub, reload bool) []string {
+	affected := make(map[string]*runningOperator)
+
+	for _, bpid := range bpids {
+		handles := make([]string, 0)
+		for _, ro := range rom.Dependents(bpid) {
+			handles = append(handles, ro.Handle)
+			affected[ro.Handle] = ro
+		}
+		if len(handles) != 0 && hub != nil {
+			hub.broadCastTo(Root, BlueprintChanged, &blueprintChange{bpid, handles, reload})
+		}
+	}
+
+	handles := funk.Keys(affected).([]string)
+	sort.Strings(handles)
+
+	if reload {
+		for _, handle := range handles {
+			ro := affected[handle]
+			if _, err := rom.Reload(ro, st, hub); err != nil {
+				log.Printf("operator %s (id: %s) could not be reloaded: %s", ro.op.Name(), handle, err)
+				if hub != nil {
+					hub.broadCastTo(Root, OperatorError, &operatorError{handle, "", err.Error()})
+				}
+				continue
+			}
+			log.Printf("operator %s (id: %s) reloaded", ro.op.Name(), handle)
+		}
+	}
+
+	return handles
+}
+
+func (rom *runningOperatorManager) GetByHandle(handle string) (*runningOperator, error) {
+	rom.mutex.RLock()
+	defer rom.mutex.RUnlock()
 	if ro, ok := rom.ropByHandle[handle]; ok {
 		return ro, nil
 	}
@@ -219,6 +473,9 @@ func (rom runningOperatorManager) GetByHandle(handle string) (*runningOperator,
 
 func (rom *runningOperatorManager) GetByProperties(props core.Properties) *runningOperator {
 	propsHash := hashProperties(props)
+
+	rom.mutex.RLock()
+	defer rom.mutex.RUnlock()
 	handle, ok := rom.handleByProps[propsHash]
 
 	if !ok {
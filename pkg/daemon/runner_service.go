@@ -4,22 +4,40 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"github.com/thoas/go-funk"
 )
 
 type RequestRunOp struct {
 	Blueprint uuid.UUID       `json:"blueprint"`
 	Props     core.Properties `json:"props"`
 	Gens      core.Generics   `json:"gens"`
+	// Checkpoint is the handle of a checkpointed operator which should be resumed instead
+	Checkpoint string `json:"checkpoint,omitempty"`
+	// Record starts recording the operator right away
+	Record *RequestRecord `json:"record,omitempty"`
 }
+type RequestTap struct {
+	Port string `json:"port"`
+}
+type RequestRecord struct {
+	Delegates []string `json:"delegates"`
+}
+type RequestReplay struct {
+	// Recording is the handle of the recorded operator
+	Recording string `json:"recording"`
+	// Timeout is the number of milliseconds to wait for each recorded output
+	Timeout int `json:"timeout"`
+}
+
 type ResponseRunOp struct {
 	Object *runningOperator `json:"object"`
 	Status string           `json:"status"`
@@ -89,7 +107,7 @@ var RunnerService = &Service{map[string]*Endpoint{
 
 		if r.Method == "GET" {
 			/*
-				Get all running operators
+				Get all running operators, optionally only those built from a given blueprint
 			*/
 			type responseListJSON struct {
 				Objects []*runningOperator `json:"objects"`
@@ -97,10 +115,20 @@ var RunnerService = &Service{map[string]*Endpoint{
 				Error   *Error             `json:"error,omitempty"`
 			}
 
+			rops := romanager.running()
+			if bp := r.URL.Query().Get("blueprint"); bp != "" {
+				bpid, err := uuid.Parse(bp)
+				if err != nil {
+					responseError(w, http.StatusBadRequest, err, "E01")
+					return
+				}
+				rops = romanager.Dependents(bpid)
+			}
+
 			response(w,
 				http.StatusOK,
 				&responseListJSON{
-					Objects: funk.Values(romanager.ropByHandle).([]*runningOperator),
+					Objects: rops,
 					Status:  "ok",
 					Error:   nil,
 				},
@@ -123,12 +151,25 @@ var RunnerService = &Service{map[string]*Endpoint{
 				return
 			}
 
-			rop, err := romanager.Exec(requ.Blueprint, requ.Gens, requ.Props, st)
+			var rop *runningOperator
+			if requ.Checkpoint != "" {
+				rop, err = romanager.Resume(GetCheckpointDir(r), requ.Checkpoint, st, GetHub(r))
+			} else {
+				rop, err = romanager.Exec(requ.Blueprint, requ.Gens, requ.Props, st, GetHub(r), nil)
+			}
 			if err != nil {
 				responseError(w, http.StatusBadRequest, err, "E02")
 				return
 			}
 
+			if requ.Record != nil {
+				if err := romanager.Record(rop, GetRecordingDir(r), requ.Record.Delegates); err != nil {
+					romanager.Halt(rop)
+					responseError(w, http.StatusBadRequest, err, "E03")
+					return
+				}
+			}
+
 			op := rop.op
 
 			if isQuasiTrigger(op.Main().In()) {
@@ -196,7 +237,7 @@ var RunnerService = &Service{map[string]*Endpoint{
 			rop := romanager.GetByProperties(props)
 			if rop == nil {
 				st := GetStorage(r)
-				rop, err = romanager.Exec(blueprint.Id, nil, props, st)
+				rop, err = romanager.Exec(blueprint.Id, nil, props, st, GetHub(r), nil)
 				if err != nil {
 					responseError(w, http.StatusBadRequest, err, "E04")
 					return
@@ -204,7 +245,7 @@ var RunnerService = &Service{map[string]*Endpoint{
 			}
 
 			rop.Push(nil)
-			out := rop.Pull()
+			out := core.PlainItem(rop.Pull())
 
 			if out != nil {
 				fmt.Println("\t<--", out)
@@ -218,6 +259,168 @@ var RunnerService = &Service{map[string]*Endpoint{
 
 	}},
 
+	`/{handle:\w+}/tap/`: {func(w http.ResponseWriter, r *http.Request) {
+		handle := mux.Vars(r)["handle"]
+
+		rop, err := romanager.GetByHandle(handle)
+		if err != nil {
+			response(w, http.StatusNotFound, nil)
+			return
+		}
+
+		if r.Method == "GET" {
+			/*
+				List tapped ports of running operator
+			*/
+			ports := []interface{}{}
+			for _, portRef := range rop.TappedPorts() {
+				ports = append(ports, portRef)
+			}
+			response(w, http.StatusOK, &ResponseJSON{Objects: ports, Status: "ok"})
+			return
+		}
+
+		var requ RequestTap
+		if err := json.NewDecoder(r.Body).Decode(&requ); err != nil {
+			responseError(w, http.StatusBadRequest, err, "E01")
+			return
+		}
+
+		if r.Method == "POST" {
+			/*
+				Tap port of running operator and relay its items via websocket
+			*/
+			if err := rop.Tap(requ.Port, GetHub(r)); err != nil {
+				responseError(w, http.StatusBadRequest, err, "E02")
+				return
+			}
+			response(w, http.StatusOK, &ResponseJSON{Object: requ.Port, Status: "ok"})
+		} else if r.Method == "DELETE" {
+			/*
+				Stop relaying items of tapped port
+			*/
+			if err := rop.Untap(requ.Port); err != nil {
+				responseError(w, http.StatusBadRequest, err, "E02")
+				return
+			}
+			response(w, http.StatusNoContent, nil)
+		}
+	}},
+
+	`/{handle:\w+}/record/`: {func(w http.ResponseWriter, r *http.Request) {
+		handle := mux.Vars(r)["handle"]
+
+		rop, err := romanager.GetByHandle(handle)
+		if err != nil {
+			response(w, http.StatusNotFound, nil)
+			return
+		}
+
+		if r.Method == "POST" {
+			/*
+				Record items entering and leaving running operator to ndjson file named after its handle
+			*/
+			var requ RequestRecord
+			if err := json.NewDecoder(r.Body).Decode(&requ); err != nil && err != io.EOF {
+				responseError(w, http.StatusBadRequest, err, "E01")
+				return
+			}
+			if err := romanager.Record(rop, GetRecordingDir(r), requ.Delegates); err != nil {
+				responseError(w, http.StatusBadRequest, err, "E02")
+				return
+			}
+			response(w, http.StatusOK, &ResponseJSON{Object: rop, Status: "ok"})
+		} else if r.Method == "DELETE" {
+			/*
+				Stop recording
+			*/
+			if err := romanager.StopRecording(rop); err != nil {
+				responseError(w, http.StatusBadRequest, err, "E02")
+				return
+			}
+			response(w, http.StatusNoContent, nil)
+		}
+	}},
+
+	`/{handle:\w+}/replay/`: {func(w http.ResponseWriter, r *http.Request) {
+		handle := mux.Vars(r)["handle"]
+
+		rop, err := romanager.GetByHandle(handle)
+		if err != nil {
+			response(w, http.StatusNotFound, nil)
+			return
+		}
+
+		if r.Method == "POST" {
+			/*
+				Feed recording into running operator and compare its outputs with the recorded ones
+			*/
+			var requ RequestReplay
+			if err := json.NewDecoder(r.Body).Decode(&requ); err != nil {
+				responseError(w, http.StatusBadRequest, err, "E01")
+				return
+			}
+
+			timeout := time.Duration(requ.Timeout) * time.Millisecond
+			if timeout <= 0 {
+				timeout = replayTimeout
+			}
+
+			res, err := romanager.Replay(rop, GetRecordingDir(r), requ.Recording, timeout)
+			if err != nil {
+				responseError(w, http.StatusBadRequest, err, "E02")
+				return
+			}
+			response(w, http.StatusOK, &ResponseJSON{Object: res, Status: "ok"})
+		}
+	}},
+
+	`/{handle:\w+}/checkpoint/`: {func(w http.ResponseWriter, r *http.Request) {
+		handle := mux.Vars(r)["handle"]
+
+		rop, err := romanager.GetByHandle(handle)
+		if err != nil {
+			response(w, http.StatusNotFound, nil)
+			return
+		}
+
+		if r.Method == "POST" {
+			/*
+				Halt running operator and write its state to disk so that it can be resumed later
+			*/
+			cp, err := romanager.Checkpoint(rop, GetCheckpointDir(r))
+			if err != nil {
+				responseError(w, http.StatusInternalServerError, err, "E01")
+				return
+			}
+			log.Printf("operator %s (id: %s) checkpointed", rop.op.Name(), rop.Handle)
+			response(w, http.StatusOK, &ResponseJSON{Object: cp, Status: "ok"})
+		}
+	}},
+
+	`/{handle:\w+}/reload/`: {func(w http.ResponseWriter, r *http.Request) {
+		handle := mux.Vars(r)["handle"]
+
+		rop, err := romanager.GetByHandle(handle)
+		if err != nil {
+			response(w, http.StatusNotFound, nil)
+			return
+		}
+
+		if r.Method == "POST" {
+			/*
+				Restart running operator with the current blueprints keeping handle, properties and generics
+			*/
+			rop, err = romanager.Reload(rop, GetStorage(r), GetHub(r))
+			if err != nil {
+				responseError(w, http.StatusBadRequest, err, "E01")
+				return
+			}
+			log.Printf("operator %s (id: %s) reloaded", rop.op.Name(), rop.Handle)
+			response(w, http.StatusOK, &ResponseJSON{Object: rop, Status: "ok"})
+		}
+	}},
+
 	`/{handle:\w+}/`: {func(w http.ResponseWriter, r *http.Request) {
 		handle := mux.Vars(r)["handle"]
 
@@ -270,7 +473,11 @@ var RunnerService = &Service{map[string]*Endpoint{
 			/*
 				Stop running operator
 			*/
-			romanager.Halt(rop)
+			if err := romanager.Halt(rop); err != nil {
+				log.Printf("operator %s (id: %s) did not stop in time: %s", rop.op.Name(), rop.Handle, err)
+				responseError(w, http.StatusInternalServerError, err, "E03")
+				return
+			}
 			log.Printf("operator %s (id: %s) stopped", rop.op.Name(), rop.Handle)
 			response(w, http.StatusNoContent, nil)
 		} else if r.Method == "OPTIONS" {
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Bitspark/slang/pkg/env"
+	"github.com/Bitspark/slang/pkg/storage"
 
 	"github.com/rs/cors"
 
@@ -30,7 +31,7 @@ const (
 	pingPeriod = (pongWait * 9) / 10
 
 	// Maximum message size allowed from peer.
-	maxMessageSize = 512
+	maxMessageSize = 64 * 1024
 )
 
 var (
@@ -137,14 +138,19 @@ type UserID struct {
 type Topic int
 
 const (
-	Port     Topic = iota
-	Operator       // currently unused but displays the intended usage
+	Port             Topic = iota
+	Operator               // currently unused but displays the intended usage
+	Tap                    // items flowing through tapped ports of a running operator
+	OperatorError          // errors raised by running operators
+	BlueprintChanged       // running operators affected by a changed blueprint
+	Debugger               // state of the debugger of a running operator
+	StorageChanged         // blueprints added, modified or removed in the storage
 )
 
 // Since we can't send proper type information over the wire, we send a string
 // representation instead.
 func (t Topic) String() string {
-	return [...]string{"Port", "Operator"}[t]
+	return [...]string{"Port", "Operator", "Tap", "OperatorError", "BlueprintChanged", "Debugger", "StorageChanged"}[t]
 }
 
 // This encodes a `Topic` to Json using it's string representation
@@ -281,7 +287,8 @@ func (c *ConnectedClient) waitOnIncoming() {
 			break
 		}
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
-		log.Printf("Got Message: %v", message)
+		// Messages of a client are handled in the order they have been sent
+		c.hub.handleIncoming(message)
 	}
 }
 
@@ -342,8 +349,7 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 	// waits on messages from the `hub` that it can forward outwards to the connected client
 	go client.waitOnOutgoing()
 
-	// we keep this around for now as it serves the websocket ping<>pong
-	// if we want to allow the UI to control the daemon via websockets, this is the place.
+	// Serves the websocket ping<>pong and the commands the UI sends to control the daemon, e.g. the debugger
 	go client.waitOnIncoming()
 
 	// so basically only returns if the ping pong fails or there is another error.
@@ -351,7 +357,9 @@ func serveWs(w http.ResponseWriter, r *http.Request) {
 
 func NewServer(ctx *context.Context, env *env.Environment, auth *BasicAuth) *Server {
 	r := mux.NewRouter().StrictSlash(true)
-	srv := &Server{env.HTTP.Address, env.HTTP.Port, r, ctx, auth}
+	newCtx := SetCheckpointDir(*ctx, env.SLANG_CHECKPOINTS)
+	newCtx = SetRecordingDir(newCtx, env.SLANG_RECORDINGS)
+	srv := &Server{env.HTTP.Address, env.HTTP.Port, r, &newCtx, auth}
 	srv.mountWebServices()
 	return srv
 }
@@ -404,6 +412,13 @@ func (s *Server) AddWebsocket(path string) {
 	newCtx := SetHub(*s.ctx, hub)
 	s.ctx = &newCtx
 	go hub.run()
+
+	// Changes of blueprints are forwarded to the UI
+	if st, ok := newCtx.Value(storageKey).(*storage.Storage); ok {
+		st.Subscribe(func(c storage.Change) {
+			hub.broadCastTo(Root, StorageChanged, c)
+		})
+	}
 }
 
 func (s *Server) mountWebServices() {
@@ -411,6 +426,7 @@ func (s *Server) mountWebServices() {
 	s.AddService("/run", RunnerService)
 	s.AddService("/share", SharingService)
 	s.AddWebsocket("/ws")
+	s.AddMetrics("/metrics")
 }
 
 func (s *Server) AddService(pathPrefix string, services *Service) {
@@ -422,6 +438,11 @@ func (s *Server) AddService(pathPrefix string, services *Service) {
 	}
 }
 
+// AddMetrics exposes runtime metrics of all running operators in the Prometheus text format.
+func (s *Server) AddMetrics(path string) {
+	s.router.Path(path).HandlerFunc(s.basicAuth(serveMetrics))
+}
+
 func (s *Server) AddStaticServer(pathPrefix string, directory http.Dir) {
 	r := s.router.PathPrefix(pathPrefix)
 	r.Handler(http.StripPrefix(pathPrefix, http.FileServer(directory)))
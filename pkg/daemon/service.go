@@ -22,6 +22,8 @@ type contextKey string
 
 const storageKey contextKey = "storage"
 const hubKey contextKey = "hub"
+const checkpointsKey contextKey = "checkpoints"
+const recordingsKey contextKey = "recordings"
 
 func GetStorage(r *http.Request) storage.Storage {
 	return *contextGet(r, storageKey).(*storage.Storage)
@@ -35,6 +37,26 @@ func GetHub(r *http.Request) *Hub {
 	return contextGet(r, hubKey).(*Hub)
 }
 
+// SetCheckpointDir sets the directory checkpoints of running operators are written to.
+func SetCheckpointDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, checkpointsKey, dir)
+}
+
+func GetCheckpointDir(r *http.Request) string {
+	dir, _ := contextGet(r, checkpointsKey).(string)
+	return dir
+}
+
+// SetRecordingDir sets the directory recordings of running operators are written to.
+func SetRecordingDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, recordingsKey, dir)
+}
+
+func GetRecordingDir(r *http.Request) string {
+	dir, _ := contextGet(r, recordingsKey).(string)
+	return dir
+}
+
 func SetStorage(ctx context.Context, st *storage.Storage) context.Context {
 	return context.WithValue(ctx, storageKey, st)
 }
@@ -0,0 +1,99 @@
+package elem
+
+import (
+	"fmt"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/google/uuid"
+)
+
+var controlTagSwitchId = uuid.MustParse("674dbc80-4fa8-4d74-aba0-e2a2177a8894")
+var controlTagSwitchCfg = &builtinConfig{
+	safe: true,
+	blueprint: core.Blueprint{
+		Id: controlTagSwitchId,
+		Meta: core.BlueprintMetaDef{
+			Name:             "tag switch",
+			ShortDescription: "routes union values by their tag",
+			Icon:             "code-branch",
+			Tags:             []string{"control"},
+			DocURL:           "https://bitspark.de/slang/docs/operator/tag-switch",
+		},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In: core.TypeDef{
+					Type:    "generic",
+					Generic: "unionType",
+				},
+				Out: core.TypeDef{
+					Type:    "generic",
+					Generic: "outType",
+				},
+			},
+		},
+		DelegateDefs: map[string]*core.DelegateDef{
+			"{tags}": {
+				In: core.TypeDef{
+					Type:    "generic",
+					Generic: "outType",
+				},
+				Out: core.TypeDef{
+					Type: "primitive",
+				},
+			},
+			"default": {
+				In: core.TypeDef{
+					Type:    "generic",
+					Generic: "outType",
+				},
+				Out: core.TypeDef{
+					Type:    "generic",
+					Generic: "unionType",
+				},
+			},
+		},
+		PropertyDefs: core.PropertyMap{
+			"tags": {
+				Type: "stream",
+				Stream: &core.TypeDef{
+					Type: "string",
+				},
+			},
+		},
+	},
+	opFunc: func(op *core.Operator) {
+		in := op.Main().In()
+		out := op.Main().Out()
+		cases := make(map[string]*core.Delegate)
+		dflt := op.Delegate("default")
+		for _, t := range op.Property("tags").([]interface{}) {
+			tag := fmt.Sprintf("%v", t)
+			cases[tag] = op.Delegate(tag)
+		}
+		for !op.CheckStop() {
+			i := in.Pull()
+			if core.IsMarker(i) {
+				out.Push(i)
+				continue
+			}
+
+			tag, value, ok := core.UnionTag(i)
+			if !ok {
+				if !op.Fail(fmt.Errorf("no union value: %v", i)) {
+					return
+				}
+				out.Push(nil)
+				continue
+			}
+
+			if cs, ok := cases[tag]; ok {
+				cs.Out().Push(value)
+				out.Push(cs.In().Pull())
+			} else {
+				// Pass the whole union value so that the tag is not lost
+				dflt.Out().Push(i)
+				out.Push(dflt.In().Pull())
+			}
+		}
+	},
+}
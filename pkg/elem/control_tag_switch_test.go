@@ -0,0 +1,82 @@
+package elem
+
+import (
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
+)
+
+func tagSwitchTestInstance(tags ...interface{}) core.InstanceDef {
+	return core.InstanceDef{
+		Operator: controlTagSwitchId,
+		Generics: map[string]*core.TypeDef{
+			"unionType": {Type: "union", Union: map[string]*core.TypeDef{
+				"number": {Type: "number"},
+				"error":  {Type: "string"},
+				"empty":  {Type: "trigger"},
+			}},
+			"outType": {Type: "string"},
+		},
+		Properties: map[string]interface{}{
+			"tags": tags,
+		},
+	}
+}
+
+func Test_CtrlTagSwitch__IsRegistered(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	ocSwitch := getBuiltinCfg(controlTagSwitchId)
+	a.NotNil(ocSwitch)
+}
+
+func Test_CtrlTagSwitch__Ports(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+	r := require.New(t)
+
+	o, err := buildOperator(tagSwitchTestInstance("number", "error"))
+	r.NoError(err)
+	r.NotNil(o)
+
+	a.Equal(core.TYPE_UNION, o.Main().In().Type())
+	a.Equal(core.TYPE_STRING, o.Main().Out().Type())
+	a.NotNil(o.Delegate("default"))
+	a.NotNil(o.Delegate("number"))
+	a.NotNil(o.Delegate("error"))
+	a.Equal(core.TYPE_UNION, o.Delegate("default").Out().Type())
+}
+
+func Test_CtrlTagSwitch__Route(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+	r := require.New(t)
+
+	o, err := buildOperator(tagSwitchTestInstance("number", "error"))
+	r.NoError(err)
+
+	o.Main().Out().Bufferize()
+	o.Delegate("number").Out().Bufferize()
+	o.Delegate("error").Out().Bufferize()
+	o.Delegate("default").Out().Bufferize()
+
+	o.Start()
+
+	o.Main().In().Push(core.UnionValue("error", "timeout"))
+	o.Main().In().Push(core.UnionValue("number", 5.0))
+	o.Main().In().Push(core.UnionValue("empty", nil))
+
+	a.Equal("timeout", o.Delegate("error").Out().Pull())
+	o.Delegate("error").In().Push("failed")
+	a.Equal(5.0, o.Delegate("number").Out().Pull())
+	o.Delegate("number").In().Push("five")
+	a.Equal(core.UnionValue("empty", nil), o.Delegate("default").Out().Pull())
+	o.Delegate("default").In().Push("other")
+
+	a.Equal("failed", o.Main().Out().Pull())
+	a.Equal("five", o.Main().Out().Pull())
+	a.Equal("other", o.Main().Out().Pull())
+}
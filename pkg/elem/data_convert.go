@@ -3,6 +3,7 @@ package elem
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/google/uuid"
@@ -42,6 +43,47 @@ func stringToBool(value string) bool {
 	return result
 }
 
+// Datetimes are converted from and to UNIX timestamps in milliseconds, durations from and to milliseconds
+
+func dateTimeToNumber(value time.Time) float64 {
+	return float64(value.UnixNano()) / float64(time.Millisecond)
+}
+
+func numberToDateTime(value float64) time.Time {
+	return time.Unix(0, int64(value*float64(time.Millisecond))).UTC()
+}
+
+func durationToNumber(value time.Duration) float64 {
+	return float64(value) / float64(time.Millisecond)
+}
+
+func numberToDuration(value float64) time.Duration {
+	return time.Duration(value * float64(time.Millisecond))
+}
+
+func decimalToNumber(value core.Decimal) float64 {
+	result, _ := value.Float64()
+	return result
+}
+
+// stringToTyped parses datetimes, durations and decimals from strings
+func stringToTyped(value string, itemType int) (interface{}, error) {
+	switch itemType {
+	case core.TYPE_DATETIME:
+		return parseDate(value)
+	case core.TYPE_DURATION:
+		return core.ParseDuration(value)
+	case core.TYPE_DECIMAL:
+		return core.ParseDecimal(value)
+	}
+	return nil, fmt.Errorf("cannot convert string to type %d", itemType)
+}
+
+// typedToString formats datetimes, durations and decimals
+func typedToString(value interface{}) string {
+	return core.PlainItem(value).(string)
+}
+
 var dataConvertId = uuid.MustParse("d1191456-3583-4eaf-8ec1-e486c3818c60")
 var dataConvertCfg = &builtinConfig{
 	safe: true,
@@ -93,6 +135,12 @@ var dataConvertCfg = &builtinConfig{
 					out.Push(numberToBinary(value))
 				case core.TYPE_BOOLEAN: // number -> bool
 					out.Push(value != 0.0)
+				case core.TYPE_DATETIME: // number -> datetime
+					out.Push(numberToDateTime(value))
+				case core.TYPE_DURATION: // number -> duration
+					out.Push(numberToDuration(value))
+				case core.TYPE_DECIMAL: // number -> decimal
+					out.Push(core.NewDecimal(value))
 				default:
 					panic("not supported yet")
 				}
@@ -117,6 +165,16 @@ var dataConvertCfg = &builtinConfig{
 					out.Push(stringToNumber(value))
 				case core.TYPE_BOOLEAN: // string -> bool
 					out.Push(stringToBool(value))
+				case core.TYPE_DATETIME, core.TYPE_DURATION, core.TYPE_DECIMAL: // string -> datetime, duration, decimal
+					typed, err := stringToTyped(value, out.Type())
+					if err != nil {
+						if !op.Fail(err) {
+							return
+						}
+						out.Push(nil)
+						continue
+					}
+					out.Push(typed)
 				default:
 					panic("not supported yet")
 				}
@@ -132,6 +190,36 @@ var dataConvertCfg = &builtinConfig{
 				default:
 					panic("not supported yet")
 				}
+			case core.TYPE_DATETIME:
+				value := i.(time.Time)
+				switch out.Type() {
+				case core.TYPE_STRING: // datetime -> string
+					out.Push(typedToString(value))
+				case core.TYPE_NUMBER: // datetime -> number
+					out.Push(dateTimeToNumber(value))
+				default:
+					panic("not supported yet")
+				}
+			case core.TYPE_DURATION:
+				value := i.(time.Duration)
+				switch out.Type() {
+				case core.TYPE_STRING: // duration -> string
+					out.Push(typedToString(value))
+				case core.TYPE_NUMBER: // duration -> number
+					out.Push(durationToNumber(value))
+				default:
+					panic("not supported yet")
+				}
+			case core.TYPE_DECIMAL:
+				value := i.(core.Decimal)
+				switch out.Type() {
+				case core.TYPE_STRING: // decimal -> string
+					out.Push(typedToString(value))
+				case core.TYPE_NUMBER: // decimal -> number
+					out.Push(decimalToNumber(value))
+				default:
+					panic("not supported yet")
+				}
 			case core.TYPE_STREAM:
 				value := i.([]interface{})
 				switch out.Type() {
@@ -154,6 +242,8 @@ var dataConvertCfg = &builtinConfig{
 						out.Push(strconv.FormatBool(value))
 					case core.Binary: // binary -> string
 						out.Push(string(value))
+					case time.Time, time.Duration, core.Decimal: // datetime, duration, decimal -> string
+						out.Push(typedToString(value))
 					default:
 						panic("not supported yet")
 					}
@@ -167,6 +257,12 @@ var dataConvertCfg = &builtinConfig{
 						out.Push(boolToNumber(value))
 					case core.Binary: // binary -> number
 						out.Push(binaryToNumber(value))
+					case time.Time: // datetime -> number
+						out.Push(dateTimeToNumber(value))
+					case time.Duration: // duration -> number
+						out.Push(durationToNumber(value))
+					case core.Decimal: // decimal -> number
+						out.Push(decimalToNumber(value))
 					default:
 						panic("not supported yet")
 					}
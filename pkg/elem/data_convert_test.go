@@ -2,9 +2,11 @@ package elem
 
 import (
 	"testing"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
 )
 
 func convertOperator(t *testing.T, from string, to string, in interface{}, out interface{}) {
@@ -126,3 +128,48 @@ func Test_Convert__To_Binary(t *testing.T) {
 
 	convertOperator(t, "primitive", "binary", nil, nil)
 }
+
+func Test_Convert__DateTime(t *testing.T) {
+	Init()
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	convertOperator(t, "string", "datetime", "2021-03-04T05:06:07Z", ts)
+	convertOperator(t, "number", "datetime", 1614834367000.0, ts)
+	convertOperator(t, "datetime", "number", ts, 1614834367000.0)
+	convertOperator(t, "datetime", "string", ts, "2021-03-04T05:06:07Z")
+	convertOperator(t, "primitive", "string", ts, "2021-03-04T05:06:07Z")
+}
+
+func Test_Convert__Duration(t *testing.T) {
+	Init()
+	convertOperator(t, "string", "duration", "1m30s", 90*time.Second)
+	convertOperator(t, "number", "duration", 1500.0, 1500*time.Millisecond)
+	convertOperator(t, "duration", "number", 90*time.Second, 90000.0)
+	convertOperator(t, "duration", "string", 90*time.Second, "1m30s")
+}
+
+func Test_Convert__Decimal(t *testing.T) {
+	Init()
+	convertOperator(t, "decimal", "string", "12.30", "12.3")
+	convertOperator(t, "decimal", "number", "0.1", 0.1)
+	convertOperator(t, "primitive", "number", core.NewDecimal(2.5), 2.5)
+	convertOperator(t, "number", "string", 12.3, "12.3")
+}
+
+func Test_Convert__InvalidDateTime(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+	fo, err := buildOperator(core.InstanceDef{
+		Operator: dataConvertId,
+		Generics: map[string]*core.TypeDef{
+			"fromType": {Type: "string"},
+			"toType":   {Type: "datetime"},
+		},
+	})
+	require.NoError(t, err)
+	fo.SetErrorPolicy(core.ERROR_POLICY_SKIP)
+	fo.Main().Out().Bufferize()
+	go fo.Start()
+	fo.Main().In().Push("yesterday")
+	a.Nil(fo.Main().Out().Pull())
+}
@@ -60,6 +60,10 @@ var dataVariableGetCfg = &builtinConfig{
 					break
 				}
 
+				if op.CheckStop() {
+					return
+				}
+
 				time.Sleep(10 * time.Millisecond)
 			}
 		}
@@ -1,6 +1,9 @@
 package elem
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/google/uuid"
 )
@@ -57,6 +60,18 @@ var streamWindow2Cfg = &builtinConfig{
 		ignore := 0
 		started := fill
 
+		state := map[string]interface{}{}
+		ok, err := op.RestoreState(&state)
+		if err == nil && ok {
+			err = restoreWindowState(state, &items, &ignore, &started)
+		}
+		if err != nil && !op.Fail(fmt.Errorf("cannot restore state: %s", err)) {
+			return
+		}
+		op.OnSnapshot(func() (interface{}, error) {
+			return map[string]interface{}{"items": items, "ignore": ignore, "started": started}, nil
+		})
+
 		for !op.CheckStop() {
 			i := in.Pull()
 			if core.IsMarker(i) {
@@ -99,3 +114,22 @@ var streamWindow2Cfg = &builtinConfig{
 		}
 	},
 }
+
+// restoreWindowState sets the window items, the number of items to ignore and whether the first window has been
+// emitted from state. Nothing is set if state is incomplete.
+func restoreWindowState(state map[string]interface{}, items *[]interface{}, ignore *int, started *bool) error {
+	restoredItems, ok := state["items"].([]interface{})
+	if !ok {
+		return errors.New("items missing")
+	}
+	restoredIgnore, ok := state["ignore"].(float64)
+	if !ok {
+		return errors.New("ignore missing")
+	}
+	restoredStarted, ok := state["started"].(bool)
+	if !ok {
+		return errors.New("started missing")
+	}
+	*items, *ignore, *started = restoredItems, int(restoredIgnore), restoredStarted
+	return nil
+}
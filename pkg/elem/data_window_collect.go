@@ -65,6 +65,18 @@ var streamWindowCollectCfg = &builtinConfig{
 		store := op.Property("store").(string)
 		ws := getWindowStore(store)
 
+		var items []interface{}
+		if ok, _ := op.RestoreState(&items); ok {
+			ws.mutex.Lock()
+			ws.items = append(items, ws.items...)
+			ws.mutex.Unlock()
+		}
+		op.OnSnapshot(func() (interface{}, error) {
+			ws.mutex.Lock()
+			defer ws.mutex.Unlock()
+			return ws.items, nil
+		})
+
 		for !op.CheckStop() {
 			item := in.Pull()
 			if core.IsMarker(item) {
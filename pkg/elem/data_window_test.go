@@ -1,7 +1,9 @@
 package elem
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/tests/assertions"
@@ -157,4 +159,83 @@ func Test_StreamWindow2__Jumping1(t *testing.T) {
 	}, o.Main().Out())
 }
 
-*/
\ No newline at end of file
+*/
+func Test_StreamWindow2__Snapshot(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	insDef := core.InstanceDef{
+		Operator: streamWindow2Id,
+		Generics: map[string]*core.TypeDef{
+			"itemType": {
+				Type: "number",
+			},
+		},
+		Properties: map[string]interface{}{
+			"size":   3,
+			"stride": 1,
+			"fill":   true,
+		},
+	}
+
+	o, err := buildOperator(insDef)
+	require.NoError(t, err)
+
+	o.Main().Out().Bufferize()
+	o.Start()
+
+	o.Main().In().Push(1.0)
+	o.Main().In().Push(2.0)
+	require.NoError(t, o.HaltTimeout(100*time.Millisecond))
+
+	snap, err := o.Snapshot()
+	require.NoError(t, err)
+
+	o, err = buildOperator(insDef)
+	require.NoError(t, err)
+	require.NoError(t, o.Restore(snap))
+
+	o.Main().Out().Bufferize()
+	o.Start()
+
+	o.Main().In().Push(3.0)
+	a.PortPushes(
+		[]interface{}{1.0, 2.0, 3.0},
+		o.Main().Out())
+}
+
+func Test_StreamWindow2__RestoreIncompleteState(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	o, err := buildOperator(core.InstanceDef{
+		Operator: streamWindow2Id,
+		Generics: map[string]*core.TypeDef{
+			"itemType": {
+				Type: "number",
+			},
+		},
+		Properties: map[string]interface{}{
+			"size":   3,
+			"stride": 1,
+			"fill":   true,
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Restore(&core.Snapshot{Operators: map[string]*core.OperatorSnapshot{
+		"": {State: json.RawMessage(`{"items":[1]}`)},
+	}}))
+
+	errs := make(chan *core.SlangError, 1)
+	o.SetErrorHandler(func(err *core.SlangError) { errs <- err })
+	o.Main().Out().Bufferize()
+	o.Start()
+	defer o.HaltTimeout(100 * time.Millisecond)
+
+	select {
+	case err := <-errs:
+		a.Equal("cannot restore state: ignore missing", err.Cause.Error())
+	case <-time.After(time.Second):
+		t.Fatal("no error reported")
+	}
+}
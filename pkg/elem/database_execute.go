@@ -108,7 +108,7 @@ var databaseExecuteCfg = &builtinConfig{
 			for _, param := range params {
 				args = append(args, im[param])
 			}
-			result, err := stmt.Exec(args...)
+			result, err := stmt.ExecContext(op.Context(), args...)
 
 			if err != nil {
 				out.Push(nil)
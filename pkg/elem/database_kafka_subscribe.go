@@ -99,6 +99,10 @@ var databaseKafkaSubscribeCfg = &builtinConfig{
 					outValueStream.Push(core.Binary(msg.Value))
 				case <-signals:
 					break ConsumerLoop
+				case <-op.Context().Done():
+					partitionConsumer.Close()
+					consumer.Close()
+					return
 				}
 			}
 			out.PushEOS()
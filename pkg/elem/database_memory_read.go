@@ -28,6 +28,32 @@ func getMemoryStore(store string) *memoryStore {
 	return ms
 }
 
+// snapshot returns a copy of all items in the store
+func (ms *memoryStore) snapshot() (interface{}, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	items := make(map[string]interface{})
+	for k, v := range ms.items {
+		items[k] = v
+	}
+	return items, nil
+}
+
+// restore adds the items restored from a snapshot of op to the store without overwriting existing items
+func (ms *memoryStore) restore(op *core.Operator) {
+	items := make(map[string]interface{})
+	if ok, _ := op.RestoreState(&items); !ok {
+		return
+	}
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	for k, v := range items {
+		if _, ok := ms.items[k]; !ok {
+			ms.items[k] = v
+		}
+	}
+}
+
 var databaseMemoryReadId = uuid.MustParse("2fcd32f5-c83c-4fff-9ac2-ccd6d02139fa")
 var databaseMemoryReadCfg = &builtinConfig{
 	safe: true,
@@ -88,6 +114,8 @@ var databaseMemoryReadCfg = &builtinConfig{
 		// Get store
 		store := op.Property("store").(string)
 		ms := getMemoryStore(store)
+		ms.restore(op)
+		op.OnSnapshot(ms.snapshot)
 
 		for {
 			i := in.Pull()
@@ -50,6 +50,8 @@ var databaseMemoryWriteCfg = &builtinConfig{
 		// Get store
 		store := op.Property("store").(string)
 		ms := getMemoryStore(store)
+		ms.restore(op)
+		op.OnSnapshot(ms.snapshot)
 
 		for {
 			i := in.Pull()
@@ -91,22 +91,11 @@ var databaseQueryCfg = &builtinConfig{
 			rowColumns = append(rowColumns, col.(string))
 		}
 
-		db, err := sql.Open(driver, url)
-		if err != nil {
-			panic(err.Error())
+		db, stmt, err := prepareQuery(driver, url, query)
+		if err == nil {
+			defer db.Close()
+			defer stmt.Close()
 		}
-		defer db.Close()
-
-		err = db.Ping()
-		if err != nil {
-			panic(err.Error())
-		}
-
-		stmt, err := db.Prepare(query)
-		if err != nil {
-			panic(err.Error())
-		}
-		defer stmt.Close()
 
 		in := op.Main().In()
 		out := op.Main().Out()
@@ -117,15 +106,26 @@ var databaseQueryCfg = &builtinConfig{
 				continue
 			}
 
+			if err != nil {
+				if !op.Fail(err) {
+					return
+				}
+				out.Push(nil)
+				continue
+			}
+
 			im := i.(map[string]interface{})
 
 			args := []interface{}{}
 			for _, param := range params {
 				args = append(args, im[param])
 			}
-			rows, err := stmt.Query(args...)
+			rows, err := stmt.QueryContext(op.Context(), args...)
 
 			if err != nil {
+				if !op.Fail(err) {
+					return
+				}
 				out.Push(nil)
 				continue
 			}
@@ -150,13 +150,37 @@ var databaseQueryCfg = &builtinConfig{
 					}
 					dests = append(dests, colPtr)
 				}
-				rows.Scan(dests...)
+				if err := rows.Scan(dests...); err != nil {
+					op.Report(err)
+				}
 				for i, col := range rowColumns {
 					row[col] = reflect.ValueOf(dests[i]).Elem().Interface()
 				}
 				out.Stream().Push(row)
 			}
+			rows.Close()
 			out.PushEOS()
 		}
 	},
 }
+
+// prepareQuery opens the database and prepares the query
+func prepareQuery(driver, url, query string) (*sql.DB, *sql.Stmt, error) {
+	db, err := sql.Open(driver, url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return db, stmt, nil
+}
@@ -62,7 +62,7 @@ var databaseRedisSubscribeCfg = &builtinConfig{
 		ch := pubsub.Channel()
 		defer pubsub.Close()
 
-		// this loop can never break or end
+		// this loop only ends when the operator is stopped
 		// as we are constantly wait for messages
 		// on the subscribed channel(s)
 		for {
@@ -76,6 +76,8 @@ var databaseRedisSubscribeCfg = &builtinConfig{
 				select {
 				case msg := <-ch:
 					out.Stream().Push(msg.Payload)
+				case <-op.Context().Done():
+					return
 				}
 			}
 			// it also makes no sense to push an EOS as
@@ -41,7 +41,7 @@ var encodingJSONWriteCfg = &builtinConfig{
 				out.Push(i)
 				continue
 			}
-			b, err := json.Marshal(&i)
+			b, err := json.Marshal(core.PlainItem(i))
 			if err != nil {
 				panic(err)
 			}
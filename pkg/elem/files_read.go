@@ -59,6 +59,7 @@ var filesReadCfg = &builtinConfig{
 			}
 			content, err := ioutil.ReadFile(path)
 			if err != nil {
+				op.Report(err)
 				out.Map("content").Push(nil)
 				out.Map("error").Push(err.Error())
 				continue
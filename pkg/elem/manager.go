@@ -95,6 +95,7 @@ func Init() {
 	Register(controlSplitCfg)
 	Register(controlMergeCfg)
 	Register(controlSwitchCfg)
+	Register(controlTagSwitchCfg)
 	Register(controlLoopCfg)
 	Register(controlIterateCfg)
 	Register(streamReduceCfg)
@@ -140,9 +141,13 @@ func Init() {
 	Register(encodingURLWriteCfg)
 
 	Register(timeDelayCfg)
+	Register(timeDelayDurationCfg)
 	Register(timeCrontabCfg)
 	Register(timeParseDateCfg)
+	Register(timeDateComponentsCfg)
 	Register(timeDateNowCfg)
+	Register(timeParseDateTimeCfg)
+	Register(timeDateTimeNowCfg)
 	Register(timeUNIXMillisCfg)
 
 	Register(stringTemplateCfg)
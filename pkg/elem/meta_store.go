@@ -20,11 +20,11 @@ func (s store) attachPort(p *core.Port) {
 			index: 0,
 			items: []interface{}{},
 		}
-		go func() {
-			for !p.Operator().Stopped() {
+		p.Operator().Go(func() {
+			for !p.Operator().CheckStop() {
 				s[p].items = append(s[p].items, p.Pull())
 			}
-		}()
+		})
 	} else if p.Type() == core.TYPE_MAP {
 		for _, sub := range p.MapEntryNames() {
 			s.attachPort(p.Map(sub))
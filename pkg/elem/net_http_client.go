@@ -6,7 +6,6 @@ import (
 	"net/http"
 
 	"github.com/Bitspark/slang/pkg/core"
-	"github.com/Bitspark/slang/pkg/log"
 	"github.com/google/uuid"
 )
 
@@ -53,7 +52,9 @@ var netHTTPClientCfg = &builtinConfig{
 
 			r, err := http.NewRequest(method, url, bytes.NewReader(body))
 			if err != nil {
-				log.Error(err)
+				if !op.Fail(err) {
+					return
+				}
 				out.Push(nil)
 				continue
 			}
@@ -64,17 +65,21 @@ var netHTTPClientCfg = &builtinConfig{
 				}
 			}
 
-			resp, err := http.DefaultClient.Do(r)
+			resp, err := http.DefaultClient.Do(r.WithContext(op.Context()))
 			
 			if err != nil {
-				log.Error(err)
+				if !op.Fail(err) {
+					return
+				}
 				out.Push(nil)
 				continue
 			}
 
 			respBody, err := ioutil.ReadAll(resp.Body)
 			if err != nil {
-				log.Error(err)
+				if !op.Fail(err) {
+					return
+				}
 				out.Push(nil)
 				continue
 			}
@@ -117,7 +117,7 @@ var netHTTPServerCfg = &builtinConfig{
 		sync.Init(
 			slangHandler.In(),
 			slangHandler.Out())
-		go sync.Worker()
+		op.Go(sync.Worker)
 
 		for !op.CheckStop() {
 			port, marker := in.PullInt()
@@ -134,10 +134,10 @@ var netHTTPServerCfg = &builtinConfig{
 				MaxHeaderBytes: 1 << 20,
 			}
 
-			go func() {
+			op.Go(func() {
 				op.WaitForStop()
 				s.Close()
-			}()
+			})
 
 			err := s.ListenAndServe()
 			out.Push(err.Error())
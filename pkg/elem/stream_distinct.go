@@ -76,17 +76,35 @@ var streamDistinctCfg = &builtinConfig{
 		hasher := op.Delegate("hasher")
 		checker := op.Delegate("checker")
 
+		// pending holds the items of the stream currently processed, which are replayed after restoring
+		var pending, replay []interface{}
+		op.RestoreState(&replay)
+		op.OnSnapshot(func() (interface{}, error) {
+			return pending, nil
+		})
+		pull := func() interface{} {
+			var i interface{}
+			if len(replay) > 0 {
+				i, replay = replay[0], replay[1:]
+			} else {
+				i = inStream.Pull()
+			}
+			pending = append(pending, i)
+			return i
+		}
+
 		for !op.CheckStop() {
-			i := inStream.Pull()
+			i := pull()
 			if !in.OwnBOS(i) {
 				out.Push(i)
+				pending = nil
 				continue
 			}
 
 			m := make(map[string]interface{})
 
 			for {
-				i = inStream.Pull()
+				i = pull()
 				if in.OwnEOS(i) {
 					break
 				}
@@ -119,6 +137,7 @@ var streamDistinctCfg = &builtinConfig{
 				outStream.Push(mi)
 			}
 			out.PushEOS()
+			pending = nil
 		}
 	},
 }
@@ -67,22 +67,41 @@ var streamReduceCfg = &builtinConfig{
 		sIn := op.Delegate("reducer").In()
 		sOut := op.Delegate("reducer").Out()
 		nullValue := op.Property("emptyValue")
+
+		// pending holds the items of the stream currently reduced, which are replayed after restoring
+		var pending, replay []interface{}
+		op.RestoreState(&replay)
+		op.OnSnapshot(func() (interface{}, error) {
+			return pending, nil
+		})
+		pull := func() interface{} {
+			var i interface{}
+			if len(replay) > 0 {
+				i, replay = replay[0], replay[1:]
+			} else {
+				i = in.Stream().Pull()
+			}
+			pending = append(pending, i)
+			return i
+		}
+
 		for !op.CheckStop() {
-			i := in.Stream().Pull()
+			i := pull()
 
 			if !in.OwnBOS(i) {
 				out.Push(i)
+				pending = nil
 				continue
 			}
 
 			mutex := &sync.Mutex{}
 			pool := []interface{}{}
 			done := false
-			doneChan := make(chan bool)
+			doneChan := make(chan bool, 1)
 
 			// Reducer
-			go func() {
-				for {
+			op.Go(func() {
+				for !op.CheckStop() {
 					mutex.Lock()
 					if done && len(pool) < 2 {
 						doneChan <- true
@@ -111,12 +130,12 @@ var streamReduceCfg = &builtinConfig{
 					pool = append([]interface{}{i}, pool...)
 					mutex.Unlock()
 				}
-			}()
+			})
 
 			for {
 				// Stream items
 
-				i = in.Stream().Pull()
+				i = pull()
 				if in.OwnEOS(i) {
 					done = true
 					break
@@ -127,13 +146,16 @@ var streamReduceCfg = &builtinConfig{
 				mutex.Unlock()
 			}
 
-			<-doneChan
+			if !op.Await(doneChan) {
+				return
+			}
 
 			if len(pool) == 1 {
 				out.Push(pool[0])
 			} else {
 				out.Push(nullValue)
 			}
+			pending = nil
 		}
 	},
 }
@@ -0,0 +1,70 @@
+package elem
+
+import (
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/google/uuid"
+)
+
+var timeDateComponentsId = uuid.MustParse("5d2c0f37-2a1b-4e65-9d0a-bc1f8a7e3c42")
+var timeDateComponentsCfg = &builtinConfig{
+	safe: true,
+	blueprint: core.Blueprint{
+		Id: timeDateComponentsId,
+		Meta: core.BlueprintMetaDef{
+			Name:             "date components",
+			ShortDescription: "takes a datetime and emits its weekday, date and time",
+			Icon:             "calendar-week",
+			Tags:             []string{"time"},
+			DocURL:           "https://bitspark.de/slang/docs/operator/date-components",
+		},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In: core.TypeDef{
+					Type: "datetime",
+				},
+				Out: core.TypeDef{
+					Type: "map",
+					Map: map[string]*core.TypeDef{
+						"weekday": {Type: "string"},
+						"date": {
+							Type: "map",
+							Map: core.TypeDefMap{
+								"year":  {Type: "number"},
+								"month": {Type: "number"},
+								"day":   {Type: "number"},
+							},
+						},
+						"time": {
+							Type: "map",
+							Map: core.TypeDefMap{
+								"hour":   {Type: "number"},
+								"minute": {Type: "number"},
+								"second": {Type: "number"},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	opFunc: func(op *core.Operator) {
+		in := op.Main().In()
+		out := op.Main().Out()
+		odate := out.Map("date")
+		otime := out.Map("time")
+		for !op.CheckStop() {
+			t, i := in.PullTime()
+			if i != nil {
+				out.Push(i)
+				continue
+			}
+			odate.Map("year").Push(t.Year())
+			odate.Map("month").Push(int(t.Month()))
+			odate.Map("day").Push(t.Day())
+			otime.Map("hour").Push(t.Hour())
+			otime.Map("minute").Push(t.Minute())
+			otime.Map("second").Push(t.Second())
+			out.Map("weekday").Push(t.Weekday().String())
+		}
+	},
+}
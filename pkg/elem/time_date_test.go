@@ -0,0 +1,81 @@
+package elem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TimeParseDate(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	o, err := buildOperator(core.InstanceDef{Operator: uuid.MustParse("2a9da2d5-2684-4d2f-8a37-9560d0f2de29")})
+	require.NoError(t, err)
+	a.Equal(core.TYPE_MAP, o.Main().Out().Type())
+
+	o.Main().Out().Bufferize()
+	o.Start()
+	o.Main().In().Push("Thu, 04 Mar 2021 05:06:07 +0000")
+	a.PortPushes(map[string]interface{}{
+		"weekday": "Thursday",
+		"date":    map[string]interface{}{"year": 2021, "month": 3, "day": 4},
+		"time":    map[string]interface{}{"hour": 5, "minute": 6, "second": 7},
+	}, o.Main().Out())
+}
+
+func Test_TimeParseDateTime(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	o, err := buildOperator(core.InstanceDef{Operator: timeParseDateTimeId})
+	require.NoError(t, err)
+	a.Equal(core.TYPE_STRING, o.Main().In().Type())
+	a.Equal(core.TYPE_DATETIME, o.Main().Out().Type())
+
+	o.Main().Out().Bufferize()
+	o.Start()
+	o.Main().In().Push("Thu, 04 Mar 2021 05:06:07 +0000")
+	ts, i := o.Main().Out().PullTime()
+	a.Nil(i)
+	a.True(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC).Equal(ts))
+}
+
+func Test_TimeDateComponents(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	o, err := buildOperator(core.InstanceDef{Operator: timeDateComponentsId})
+	require.NoError(t, err)
+	a.Equal(core.TYPE_DATETIME, o.Main().In().Type())
+
+	o.Main().Out().Bufferize()
+	o.Start()
+	o.Main().In().Push("2021-03-04T05:06:07Z")
+	a.PortPushes(map[string]interface{}{
+		"weekday": "Thursday",
+		"date":    map[string]interface{}{"year": 2021, "month": 3, "day": 4},
+		"time":    map[string]interface{}{"hour": 5, "minute": 6, "second": 7},
+	}, o.Main().Out())
+}
+
+func Test_TimeDateTimeNow(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	o, err := buildOperator(core.InstanceDef{Operator: timeDateTimeNowId})
+	require.NoError(t, err)
+
+	o.Main().Out().Bufferize()
+	o.Start()
+	before := time.Now()
+	o.Main().In().Push(nil)
+	ts, i := o.Main().Out().PullTime()
+	a.Nil(i)
+	a.False(ts.Before(before))
+	a.False(ts.After(time.Now()))
+}
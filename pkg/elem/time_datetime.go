@@ -0,0 +1,50 @@
+package elem
+
+import (
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/google/uuid"
+)
+
+var timeParseDateTimeId = uuid.MustParse("c4b3e1a6-7d52-4f0e-8a9b-2e6f1d3c5a78")
+var timeParseDateTimeCfg = &builtinConfig{
+	safe: true,
+	blueprint: core.Blueprint{
+		Id: timeParseDateTimeId,
+		Meta: core.BlueprintMetaDef{
+			Name:             "to datetime",
+			ShortDescription: "takes a string containing date and time and emits it as datetime",
+			Icon:             "calendar-week",
+			Tags:             []string{"time"},
+			DocURL:           "https://bitspark.de/slang/docs/operator/to-datetime",
+		},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In: core.TypeDef{
+					Type: "string",
+				},
+				Out: core.TypeDef{
+					Type: "datetime",
+				},
+			},
+		},
+	},
+	opFunc: func(op *core.Operator) {
+		in := op.Main().In()
+		out := op.Main().Out()
+		for !op.CheckStop() {
+			if i := in.Pull(); !core.IsMarker(i) {
+				t, err := parseDate(i.(string))
+				if err != nil {
+					if !op.Fail(err) {
+						return
+					}
+					out.Push(nil)
+					continue
+				}
+				out.Push(t)
+			} else {
+				out.Push(i)
+			}
+		}
+	},
+}
@@ -0,0 +1,44 @@
+package elem
+
+import (
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/google/uuid"
+)
+
+var timeDateTimeNowId = uuid.MustParse("1f7e9d2b-4c36-4a85-b0e1-9d8c7a6f5e43")
+var timeDateTimeNowCfg = &builtinConfig{
+	safe: true,
+	blueprint: core.Blueprint{
+		Id: timeDateTimeNowId,
+		Meta: core.BlueprintMetaDef{
+			Name:             "datetime now",
+			ShortDescription: "emits the current date and time as datetime",
+			Icon:             "clock",
+			Tags:             []string{"time"},
+			DocURL:           "https://bitspark.de/slang/docs/operator/datetime-now",
+		},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In: core.TypeDef{
+					Type: "trigger",
+				},
+				Out: core.TypeDef{
+					Type: "datetime",
+				},
+			},
+		},
+	},
+	opFunc: func(op *core.Operator) {
+		in := op.Main().In()
+		out := op.Main().Out()
+		for !op.CheckStop() {
+			if i := in.Pull(); !core.IsMarker(i) {
+				out.Push(time.Now())
+			} else {
+				out.Push(i)
+			}
+		}
+	},
+}
@@ -1,6 +1,7 @@
 package elem
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/Bitspark/slang/pkg/core"
@@ -41,24 +42,44 @@ var timeDelayCfg = &builtinConfig{
 		},
 	},
 	opFunc: func(op *core.Operator) {
-		in := op.Main().In()
-		out := op.Main().Out()
-		for !op.CheckStop() {
-			i := in.Pull()
-			if core.IsMarker(i) {
-				out.Push(i)
-				continue
-			}
-
-			im := i.(map[string]interface{})
-			delay := im["delay"].(float64)
-			item := im["item"]
-
-			<-time.After(time.Millisecond * time.Duration(delay))
-			out.Push(item)
-		}
+		delayItems(op, func(delay interface{}) (time.Duration, bool) {
+			ms, ok := delay.(float64)
+			return time.Millisecond * time.Duration(ms), ok
+		})
 	},
 	opConnFunc: func(op *core.Operator, dst, src *core.Port) error {
 		return nil
 	},
 }
+
+// delayItems emits each item pulled from the in port of op after the delay which durationOf returns for it. Items
+// with an invalid delay fail.
+func delayItems(op *core.Operator, durationOf func(delay interface{}) (time.Duration, bool)) {
+	in := op.Main().In()
+	out := op.Main().Out()
+	for !op.CheckStop() {
+		i := in.Pull()
+		if core.IsMarker(i) {
+			out.Push(i)
+			continue
+		}
+
+		im := i.(map[string]interface{})
+		delay, ok := durationOf(im["delay"])
+		item := im["item"]
+		if !ok {
+			if !op.Fail(fmt.Errorf("invalid delay: %v", im["delay"])) {
+				return
+			}
+			out.Push(nil)
+			continue
+		}
+
+		select {
+		case <-time.After(delay):
+			out.Push(item)
+		case <-op.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package elem
+
+import (
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/google/uuid"
+)
+
+var timeDelayDurationId = uuid.MustParse("c2a5e8d1-3f64-4b7a-9e05-7d18b4f6a3c9")
+var timeDelayDurationCfg = &builtinConfig{
+	safe: true,
+	blueprint: core.Blueprint{
+		Id: timeDelayDurationId,
+		Meta: core.BlueprintMetaDef{
+			Name:             "delay duration",
+			ShortDescription: "takes an item and emits it again after a given duration has passed",
+			Icon:             "clock",
+			Tags:             []string{"time"},
+			DocURL:           "https://bitspark.de/slang/docs/operator/delay-duration",
+		},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In: core.TypeDef{
+					Type: "map",
+					Map: map[string]*core.TypeDef{
+						"item": {
+							Type:    "generic",
+							Generic: "itemType",
+						},
+						"delay": {
+							Type: "duration",
+						},
+					},
+				},
+				Out: core.TypeDef{
+					Type:    "generic",
+					Generic: "itemType",
+				},
+			},
+		},
+	},
+	opFunc: func(op *core.Operator) {
+		delayItems(op, func(delay interface{}) (time.Duration, bool) {
+			switch d := delay.(type) {
+			case time.Duration:
+				return d, true
+			case float64:
+				// Numbers pushed from JSON input are taken as milliseconds
+				return time.Millisecond * time.Duration(d), true
+			}
+			return 0, false
+		})
+	},
+}
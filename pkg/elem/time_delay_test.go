@@ -2,8 +2,11 @@ package elem
 
 import (
 	"testing"
+	"time"
 
+	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_TimeDelay__IsRegistered(t *testing.T) {
@@ -12,3 +15,57 @@ func Test_TimeDelay__IsRegistered(t *testing.T) {
 	ocDelay := getBuiltinCfg(timeDelayId)
 	a.NotNil(ocDelay)
 }
+
+func Test_TimeDelay__Milliseconds(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	o, err := buildOperator(core.InstanceDef{
+		Operator: timeDelayId,
+		Generics: map[string]*core.TypeDef{"itemType": {Type: "number"}},
+	})
+	require.NoError(t, err)
+	a.Equal(core.TYPE_NUMBER, o.Main().In().Map("delay").Type())
+
+	o.Main().Out().Bufferize()
+	o.Start()
+	start := time.Now()
+	o.Main().In().Push(map[string]interface{}{"item": 1.0, "delay": 20.0})
+	a.Equal(1.0, o.Main().Out().Pull())
+	a.True(time.Since(start) >= 20*time.Millisecond)
+}
+
+func Test_TimeDelayDuration__IsRegistered(t *testing.T) {
+	a := assertions.New(t)
+
+	ocDelay := getBuiltinCfg(timeDelayDurationId)
+	a.NotNil(ocDelay)
+}
+
+func Test_TimeDelayDuration__Duration(t *testing.T) {
+	Init()
+	a := assertions.New(t)
+
+	o, err := buildOperator(core.InstanceDef{
+		Operator: timeDelayDurationId,
+		Generics: map[string]*core.TypeDef{"itemType": {Type: "number"}},
+	})
+	require.NoError(t, err)
+	a.Equal(core.TYPE_DURATION, o.Main().In().Map("delay").Type())
+
+	o.Main().Out().Bufferize()
+	o.Start()
+	start := time.Now()
+	o.Main().In().Push(map[string]interface{}{"item": 1.0, "delay": "20ms"})
+	a.Equal(1.0, o.Main().Out().Pull())
+	a.True(time.Since(start) >= 20*time.Millisecond)
+
+	start = time.Now()
+	o.Main().In().Push(map[string]interface{}{"item": 2.0, "delay": 20.0})
+	a.Equal(2.0, o.Main().Out().Pull())
+	a.True(time.Since(start) >= 20*time.Millisecond)
+
+	o.SetErrorPolicy(core.ERROR_POLICY_SKIP)
+	o.Main().In().Push(map[string]interface{}{"item": 3.0, "delay": "soon"})
+	a.Nil(o.Main().Out().Pull())
+}
@@ -22,6 +22,8 @@ type Environment struct {
 	SLANG_LIB_REPO_PATH string
 	SLANG_LIB           string
 	SLANG_UI            string
+	SLANG_CHECKPOINTS   string
+	SLANG_RECORDINGS    string
 
 	HTTP httpCfg
 }
@@ -48,6 +50,8 @@ func New(addr string, port int) *Environment {
 		ensureEnvironVar("SLANG_LIB_REPO_PATH", filepath.Join(slangPath, "shared")),
 		ensureEnvironVar("SLANG_LIB", filepath.Join(slangPath, "shared", "slang")),
 		ensureEnvironVar("SLANG_UI", filepath.Join(slangPath, "ui")),
+		ensureEnvironVar("SLANG_CHECKPOINTS", filepath.Join(slangPath, "checkpoints")),
+		ensureEnvironVar("SLANG_RECORDINGS", filepath.Join(slangPath, "recordings")),
 		httpCfg{Address: addr, Port: port},
 	}
 
@@ -62,6 +66,12 @@ func New(addr string, port int) *Environment {
 	if _, err = utils.EnsureDirExists(e.SLANG_UI); err != nil {
 		log.Fatal(err)
 	}
+	if _, err = utils.EnsureDirExists(e.SLANG_CHECKPOINTS); err != nil {
+		log.Fatal(err)
+	}
+	if _, err = utils.EnsureDirExists(e.SLANG_RECORDINGS); err != nil {
+		log.Fatal(err)
+	}
 
 	return e
 }
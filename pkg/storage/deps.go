@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/google/uuid"
+)
+
+// MissingReference is a reference of a stored blueprint to a blueprint which neither is stored nor elementary.
+type MissingReference struct {
+	Blueprint uuid.UUID `json:"blueprint"`
+	// Instance is the name of the instance referencing the missing blueprint, it is empty for type references
+	Instance string    `json:"instance,omitempty"`
+	Missing  uuid.UUID `json:"missing"`
+}
+
+// depGraph holds the graph of the dependencies between the latest versions of all stored blueprints. It is built when
+// first needed and dropped whenever blueprints are saved or watched backends change.
+type depGraph struct {
+	mutex   sync.Mutex
+	current *depSnapshot
+	// unindexed holds the dependents among the blueprints of backends without index
+	unindexed map[uuid.UUID][]uuid.UUID
+}
+
+// depSnapshot is a built dependency graph, which is never modified
+type depSnapshot struct {
+	dependencies map[uuid.UUID][]uuid.UUID
+	dependents   map[uuid.UUID][]uuid.UUID
+	missing      []MissingReference
+}
+
+func (g *depGraph) invalidate() {
+	g.mutex.Lock()
+	g.current = nil
+	g.unindexed = nil
+	g.mutex.Unlock()
+}
+
+// graph returns the dependency graph, building it if necessary
+func (s *Storage) graph() *depSnapshot {
+	s.deps.mutex.Lock()
+	defer s.deps.mutex.Unlock()
+
+	if s.deps.current != nil {
+		return s.deps.current
+	}
+
+	g := &depSnapshot{
+		dependencies: make(map[uuid.UUID][]uuid.UUID),
+		dependents:   make(map[uuid.UUID][]uuid.UUID),
+		missing:      make([]MissingReference, 0),
+	}
+
+	blueprints := make(map[uuid.UUID]*core.Blueprint)
+	ids, _ := s.List()
+	for _, id := range ids {
+		if _, ok := blueprints[id]; ok {
+			continue
+		}
+		blueprint, err := s.Load(id)
+		if err != nil {
+			continue
+		}
+		blueprints[id] = blueprint
+		g.dependencies[id] = blueprintDependencies(blueprint)
+	}
+
+	for id, deps := range g.dependencies {
+		for _, dep := range deps {
+			g.dependents[dep] = append(g.dependents[dep], id)
+		}
+	}
+	for _, dependents := range g.dependents {
+		sortIds(dependents)
+	}
+
+	for id, blueprint := range blueprints {
+		for _, ref := range blueprint.TypeRefs() {
+			if !g.known(ref) {
+				g.missing = append(g.missing, MissingReference{id, "", ref})
+			}
+		}
+		for _, insDef := range blueprint.InstanceDefs {
+			if !g.known(insDef.Operator) {
+				g.missing = append(g.missing, MissingReference{id, insDef.Name, insDef.Operator})
+			}
+		}
+	}
+	sort.Slice(g.missing, func(i, j int) bool {
+		a, b := g.missing[i], g.missing[j]
+		if a.Blueprint != b.Blueprint {
+			return a.Blueprint.String() < b.Blueprint.String()
+		}
+		if a.Instance != b.Instance {
+			return a.Instance < b.Instance
+		}
+		return a.Missing.String() < b.Missing.String()
+	})
+
+	s.deps.current = g
+	return g
+}
+
+func (g *depSnapshot) known(opId uuid.UUID) bool {
+	_, ok := g.dependencies[opId]
+	return ok || elem.IsRegistered(opId)
+}
+
+// blueprintDependencies returns the ids of the blueprints blueprint instantiates or refers to in types
+func blueprintDependencies(blueprint *core.Blueprint) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool)
+	deps := make([]uuid.UUID, 0)
+	add := func(id uuid.UUID) {
+		if !seen[id] {
+			seen[id] = true
+			deps = append(deps, id)
+		}
+	}
+	for _, ref := range blueprint.TypeRefs() {
+		add(ref)
+	}
+	for _, insDef := range blueprint.InstanceDefs {
+		add(insDef.Operator)
+	}
+	sortIds(deps)
+	return deps
+}
+
+// Dependencies returns the ids of the blueprints the blueprint with the given id instantiates or refers to in types.
+// If transitive is set, their dependencies are included as well.
+func (s *Storage) Dependencies(opId uuid.UUID, transitive bool) ([]uuid.UUID, error) {
+	g := s.graph()
+	if _, ok := g.dependencies[opId]; !ok {
+		return nil, fmt.Errorf("unknown operator for id: %s", opId)
+	}
+	return walkGraph(g.dependencies, opId, transitive), nil
+}
+
+// Dependents returns the ids of the stored blueprints instantiating or referring to the blueprint with the given id.
+// If transitive is set, their dependents are included as well. Ids of elementary and missing blueprints are allowed.
+// Indexed backends look up the dependents of their blueprints, only the blueprints of other backends are loaded.
+func (s *Storage) Dependents(opId uuid.UUID, transitive bool) ([]uuid.UUID, error) {
+	unindexed := s.unindexedDependents()
+
+	var err error
+	dependents := walk(func(id uuid.UUID) []uuid.UUID {
+		next := append([]uuid.UUID{}, unindexed[id]...)
+		for _, backend := range s.backends {
+			if ib, ok := backend.(IndexedBackend); ok {
+				ids, lookupErr := ib.Dependents(id)
+				if lookupErr != nil {
+					err = lookupErr
+					continue
+				}
+				next = append(next, ids...)
+			}
+		}
+		return next
+	}, opId, transitive)
+
+	if err != nil {
+		return nil, err
+	}
+	return dependents, nil
+}
+
+// unindexedDependents returns the dependents among the blueprints of backends without index, building them if
+// necessary
+func (s *Storage) unindexedDependents() map[uuid.UUID][]uuid.UUID {
+	s.deps.mutex.Lock()
+	defer s.deps.mutex.Unlock()
+
+	if s.deps.unindexed != nil {
+		return s.deps.unindexed
+	}
+
+	dependents := make(map[uuid.UUID][]uuid.UUID)
+	for _, backend := range s.backends {
+		if _, ok := backend.(IndexedBackend); ok {
+			continue
+		}
+		ids, err := backend.List()
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			blueprint, err := backend.Load(id)
+			if err != nil {
+				continue
+			}
+			for _, dep := range blueprintDependencies(blueprint) {
+				dependents[dep] = append(dependents[dep], id)
+			}
+		}
+	}
+
+	s.deps.unindexed = dependents
+	return dependents
+}
+
+// Cycles returns the groups of stored blueprints which depend on each other.
+func (s *Storage) Cycles() [][]uuid.UUID {
+	return findCycles(s.graph().dependencies)
+}
+
+// MissingReferences returns all references of stored blueprints to blueprints which are unknown.
+func (s *Storage) MissingReferences() []MissingReference {
+	return append([]MissingReference{}, s.graph().missing...)
+}
+
+// walkGraph returns the ids adjacent to opId in edges, or all ids reachable from opId if transitive is set
+func walkGraph(edges map[uuid.UUID][]uuid.UUID, opId uuid.UUID, transitive bool) []uuid.UUID {
+	return walk(func(id uuid.UUID) []uuid.UUID {
+		return edges[id]
+	}, opId, transitive)
+}
+
+// walk returns the ids adjacent to opId as returned by next, or all ids reachable from opId if transitive is set
+func walk(next func(uuid.UUID) []uuid.UUID, opId uuid.UUID, transitive bool) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool)
+	if transitive {
+		seen[opId] = true
+	}
+	reached := make([]uuid.UUID, 0)
+	queue := []uuid.UUID{opId}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, adj := range next(id) {
+			if seen[adj] {
+				continue
+			}
+			seen[adj] = true
+			reached = append(reached, adj)
+			if transitive {
+				queue = append(queue, adj)
+			}
+		}
+	}
+	sortIds(reached)
+	return reached
+}
+
+// findCycles returns the strongly connected components of the graph which form cycles, using Tarjan's algorithm
+func findCycles(edges map[uuid.UUID][]uuid.UUID) [][]uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(edges))
+	for id := range edges {
+		ids = append(ids, id)
+	}
+	sortIds(ids)
+
+	index := make(map[uuid.UUID]int)
+	lowlink := make(map[uuid.UUID]int)
+	onStack := make(map[uuid.UUID]bool)
+	stack := make([]uuid.UUID, 0)
+	cycles := make([][]uuid.UUID, 0)
+
+	var connect func(id uuid.UUID)
+	connect = func(id uuid.UUID) {
+		index[id] = len(index)
+		lowlink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		selfLoop := false
+		for _, next := range edges[id] {
+			if next == id {
+				selfLoop = true
+			}
+			if _, ok := index[next]; !ok {
+				connect(next)
+				if lowlink[next] < lowlink[id] {
+					lowlink[id] = lowlink[next]
+				}
+			} else if onStack[next] && index[next] < lowlink[id] {
+				lowlink[id] = index[next]
+			}
+		}
+
+		if lowlink[id] != index[id] {
+			return
+		}
+		component := make([]uuid.UUID, 0)
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			sortIds(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, id := range ids {
+		if _, ok := index[id]; !ok {
+			connect(id)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0].String() < cycles[j][0].String()
+	})
+	return cycles
+}
+
+func sortIds(ids []uuid.UUID) {
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/elem"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func depsTestBlueprint(deps ...uuid.UUID) core.Blueprint {
+	bp := core.Blueprint{Id: uuid.New()}
+	for i, dep := range deps {
+		bp.InstanceDefs = append(bp.InstanceDefs, &core.InstanceDef{Name: string(rune('a' + i)), Operator: dep})
+	}
+	return bp
+}
+
+func Test_Storage__Dependencies(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+	s := NewStorage().AddBackend(NewWritableFileSystem(t.TempDir()))
+
+	evalId := uuid.MustParse("37ccdc28-67b0-4bb1-8591-4e0e813e3ec1")
+	leaf := depsTestBlueprint(evalId)
+	mid := depsTestBlueprint(leaf.Id)
+	top := depsTestBlueprint(mid.Id, leaf.Id)
+	require.NoError(t, s.SaveAll([]core.Blueprint{leaf, mid, top}))
+
+	deps, err := s.Dependencies(top.Id, false)
+	a.NoError(err)
+	a.ElementsMatch([]uuid.UUID{mid.Id, leaf.Id}, deps)
+
+	deps, err = s.Dependencies(top.Id, true)
+	a.NoError(err)
+	a.ElementsMatch([]uuid.UUID{mid.Id, leaf.Id, evalId}, deps)
+
+	_, err = s.Dependencies(uuid.New(), false)
+	a.Error(err)
+
+	dependents, err := s.Dependents(leaf.Id, false)
+	a.NoError(err)
+	a.ElementsMatch([]uuid.UUID{mid.Id, top.Id}, dependents)
+
+	dependents, err = s.Dependents(evalId, true)
+	a.NoError(err)
+	a.ElementsMatch([]uuid.UUID{leaf.Id, mid.Id, top.Id}, dependents)
+
+	// Saving blueprints updates the graph
+	top.InstanceDefs = top.InstanceDefs[:1]
+	_, err = s.Save(top)
+	require.NoError(t, err)
+	dependents, err = s.Dependents(leaf.Id, false)
+	a.NoError(err)
+	a.Equal([]uuid.UUID{mid.Id}, dependents)
+}
+
+func Test_Storage__CyclesAndMissing(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+	s := NewStorage().AddBackend(NewWritableFileSystem(t.TempDir()))
+
+	missing := uuid.New()
+	first := depsTestBlueprint()
+	second := depsTestBlueprint(first.Id, missing)
+	first.InstanceDefs = append(first.InstanceDefs, &core.InstanceDef{Name: "second", Operator: second.Id})
+	self := depsTestBlueprint()
+	self.InstanceDefs = append(self.InstanceDefs, &core.InstanceDef{Name: "self", Operator: self.Id})
+	acyclic := depsTestBlueprint(first.Id)
+	require.NoError(t, s.SaveAll([]core.Blueprint{first, second, self, acyclic}))
+
+	cycles := s.Cycles()
+	require.Len(t, cycles, 2)
+	a.Contains(cycles, []uuid.UUID{self.Id})
+	pair := []uuid.UUID{first.Id, second.Id}
+	sortIds(pair)
+	a.Contains(cycles, pair)
+
+	a.Equal([]MissingReference{{second.Id, "b", missing}}, s.MissingReferences())
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -21,7 +22,10 @@ var FILE_ENDINGS = []string{".yaml", ".yml", ".json"} // Order of endings matter
 type FileSystem struct {
 	root      string
 	cache     map[uuid.UUID]*core.Blueprint
+	paths     map[uuid.UUID]string
+	versions  map[uuid.UUID]map[string]*core.Blueprint
 	cacheLock sync.Mutex
+	watch     *fsWatch
 }
 
 type WritableFileSystem struct {
@@ -44,7 +48,10 @@ func NewWritableFileSystem(root string) *WritableFileSystem {
 		FileSystem{
 			p,
 			make(map[uuid.UUID]*core.Blueprint),
+			make(map[uuid.UUID]string),
+			make(map[uuid.UUID]map[string]*core.Blueprint),
 			sync.Mutex{},
+			nil,
 		},
 	}
 }
@@ -54,7 +61,10 @@ func NewReadOnlyFileSystem(root string) *FileSystem {
 	return &FileSystem{
 		p,
 		make(map[uuid.UUID]*core.Blueprint),
+		make(map[uuid.UUID]string),
+		make(map[uuid.UUID]map[string]*core.Blueprint),
 		sync.Mutex{},
+		nil,
 	}
 }
 
@@ -76,7 +86,10 @@ func (fs *FileSystem) List() ([]uuid.UUID, error) {
 }
 
 func (fs *FileSystem) Load(opId uuid.UUID) (*core.Blueprint, error) {
-	if def, ok := fs.cache[opId]; ok {
+	fs.cacheLock.Lock()
+	def, ok := fs.cache[opId]
+	fs.cacheLock.Unlock()
+	if ok {
 		return def, nil
 	}
 
@@ -91,23 +104,78 @@ func (fs *FileSystem) Load(opId uuid.UUID) (*core.Blueprint, error) {
 		return nil, err
 	}
 
-	fs.cacheThis(blueprint)
+	fs.cacheThis(blueprint, blueprintFile)
+
+	return blueprint, nil
+}
+
+// Versions returns the versions of the blueprint with the given id found in any file.
+func (fs *FileSystem) Versions(opId uuid.UUID) ([]string, error) {
+	if len(fs.cache) == 0 {
+		fs.loadBlueprintFiles()
+	}
+
+	fs.cacheLock.Lock()
+	defer fs.cacheLock.Unlock()
+
+	versions, ok := fs.versions[opId]
+	if !ok {
+		return nil, fmt.Errorf("unknown operator for id: %s", opId)
+	}
+	return funk.Keys(versions).([]string), nil
+}
+
+// LoadVersion loads the given version of the blueprint with the given id.
+func (fs *FileSystem) LoadVersion(opId uuid.UUID, version string) (*core.Blueprint, error) {
+	if len(fs.cache) == 0 {
+		fs.loadBlueprintFiles()
+	}
+
+	fs.cacheLock.Lock()
+	defer fs.cacheLock.Unlock()
 
+	blueprint, ok := fs.versions[opId][version]
+	if !ok {
+		return nil, fmt.Errorf("unknown version %s of operator %s", version, opId)
+	}
 	return blueprint, nil
 }
 
+// Location returns the path of the file the blueprint has been read from or written to.
+func (fs *FileSystem) Location(opId uuid.UUID) string {
+	fs.cacheLock.Lock()
+	defer fs.cacheLock.Unlock()
+	return fs.paths[opId]
+}
+
+// Save writes the blueprint to the folder it has been read from. New blueprints are written to the folder of their
+// namespace.
 func (fs *WritableFileSystem) Save(blueprint core.Blueprint) (uuid.UUID, error) {
 	opId := blueprint.Id
 	cwd := fs.root
+	if curPath, err := fs.getFilePath(opId); err == nil {
+		cwd = filepath.Dir(curPath)
+	} else if blueprint.Meta.Namespace != "" {
+		nsPath, err := namespacePath(blueprint.Meta.Namespace)
+		if err != nil {
+			return opId, err
+		}
+		cwd = filepath.Join(fs.root, nsPath)
+	}
 	relPath := strings.Replace(opId.String(), ".", string(filepath.Separator), -1)
 	absPath := filepath.Join(cwd, relPath+".yaml")
+	blueprint.Meta.Namespace = fs.getNamespace(absPath)
 	_, err := utils.EnsureDirExists(filepath.Dir(absPath))
 
 	if err != nil {
 		return opId, err
 	}
 
-	fs.cacheThis(&blueprint)
+	if err := fs.archiveVersion(blueprint); err != nil {
+		return opId, err
+	}
+
+	fs.cacheThis(&blueprint, absPath)
 
 	blueprintYaml, err := yaml.Marshal(&blueprint)
 
@@ -123,6 +191,39 @@ func (fs *WritableFileSystem) Save(blueprint core.Blueprint) (uuid.UUID, error)
 	return opId, nil
 }
 
+// archiveVersion keeps the file of the current version of the blueprint as "<id>@<version>" if blueprint has another
+// version, so that instances pinned to the old version can still use it. The file of the blueprint always holds its
+// latest version, so blueprint must not have a lower version than the current one.
+func (fs *WritableFileSystem) archiveVersion(blueprint core.Blueprint) error {
+	curPath, err := fs.getFilePath(blueprint.Id)
+	if err != nil {
+		return nil
+	}
+
+	cur, err := fs.readBlueprintFile(curPath)
+	if cur == nil || cur.Meta.Version == "" || cur.Meta.Version == blueprint.Meta.Version {
+		return nil
+	}
+	if compareVersions(blueprint.Meta.Version, cur.Meta.Version) < 0 {
+		return fmt.Errorf(`cannot save version "%s" of operator %s, it is lower than the current version %s`, blueprint.Meta.Version, blueprint.Id, cur.Meta.Version)
+	}
+
+	archivePath := filepath.Join(filepath.Dir(curPath), blueprint.Id.String()+"@"+cur.Meta.Version+filepath.Ext(curPath))
+	return os.Rename(curPath, archivePath)
+}
+
+func (fs *WritableFileSystem) Versions(opId uuid.UUID) ([]string, error) {
+	// force to reload writable/local blueprints
+	fs.clearCache(nil)
+	return fs.FileSystem.Versions(opId)
+}
+
+func (fs *WritableFileSystem) LoadVersion(opId uuid.UUID, version string) (*core.Blueprint, error) {
+	// force to reload writable/local blueprints
+	fs.clearCache(nil)
+	return fs.FileSystem.LoadVersion(opId, version)
+}
+
 func (fs *WritableFileSystem) List() ([]uuid.UUID, error) {
 	// force to reload writable/local blueprints
 	fs.clearCache(nil)
@@ -135,20 +236,36 @@ func (fs *WritableFileSystem) Load(opId uuid.UUID) (*core.Blueprint, error) {
 	return fs.FileSystem.Load(opId)
 }
 
-func (fs *FileSystem) cacheThis(blueprint *core.Blueprint) {
+func (fs *FileSystem) cacheThis(blueprint *core.Blueprint, path string) {
 	fs.cacheLock.Lock()
-	fs.cache[blueprint.Id] = blueprint
+	if fs.versions[blueprint.Id] == nil {
+		fs.versions[blueprint.Id] = make(map[string]*core.Blueprint)
+	}
+	fs.versions[blueprint.Id][blueprint.Meta.Version] = blueprint
+
+	// Other versions of the blueprint are only accessible via LoadVersion
+	if cur, ok := fs.cache[blueprint.Id]; !ok || cur.Meta.Version == blueprint.Meta.Version || compareVersions(blueprint.Meta.Version, cur.Meta.Version) > 0 {
+		fs.cache[blueprint.Id] = blueprint
+		fs.paths[blueprint.Id] = path
+	}
 	fs.cacheLock.Unlock()
 }
 
+// clearCache forces blueprints to be read again unless the file system is watched, which keeps the cache coherent
 func (fs *WritableFileSystem) clearCache(blueprintId *uuid.UUID) {
 	fs.cacheLock.Lock()
+	if fs.watch != nil {
+		fs.cacheLock.Unlock()
+		return
+	}
 	if blueprintId != nil {
 		delete(fs.cache, *blueprintId)
+		delete(fs.versions, *blueprintId)
 		fs.cacheLock.Unlock()
 		return
 	}
 	fs.cache = make(map[uuid.UUID]*core.Blueprint)
+	fs.versions = make(map[uuid.UUID]map[string]*core.Blueprint)
 	fs.cacheLock.Unlock()
 }
 
@@ -160,8 +277,53 @@ func (fs *FileSystem) getInstanceName(blueprintFilePath string) string {
 	return strings.TrimSuffix(filepath.Base(blueprintFilePath), filepath.Ext(blueprintFilePath))
 }
 
+// getFilePath returns the path of the file of the latest version of the blueprint with the given id, which may be
+// located in any folder below the root
 func (fs *FileSystem) getFilePath(opId uuid.UUID) (string, error) {
-	return utils.FileWithFileEnding(filepath.Join(fs.root, opId.String()), FILE_ENDINGS)
+	fs.cacheLock.Lock()
+	p, ok := fs.paths[opId]
+	fs.cacheLock.Unlock()
+	if ok {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+
+	p, err := utils.FileWithFileEnding(filepath.Join(fs.root, opId.String()), FILE_ENDINGS)
+	if err == nil {
+		return p, nil
+	}
+
+	fs.loadBlueprintFiles()
+
+	fs.cacheLock.Lock()
+	p, ok = fs.paths[opId]
+	fs.cacheLock.Unlock()
+	if !ok {
+		return "", err
+	}
+	return p, nil
+}
+
+// getNamespace returns the namespace of the blueprint stored in the given file. Folders below the root are separated
+// by dots, blueprints stored in the root have no namespace.
+func (fs *FileSystem) getNamespace(blueprintFilePath string) string {
+	rel, err := filepath.Rel(fs.root, filepath.Dir(blueprintFilePath))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return strings.Join(strings.Split(filepath.ToSlash(rel), "/"), ".")
+}
+
+// namespacePath returns the folder of the namespace relative to the root
+func namespacePath(namespace string) (string, error) {
+	segs := strings.Split(namespace, ".")
+	for _, seg := range segs {
+		if seg == "" || strings.ContainsAny(seg, `/\`) {
+			return "", fmt.Errorf("invalid namespace: %s", namespace)
+		}
+	}
+	return filepath.Join(segs...), nil
 }
 
 func (fs *FileSystem) loadBlueprintFiles() {
@@ -171,8 +333,8 @@ func (fs *FileSystem) loadBlueprintFiles() {
 			return nil
 		}
 
-		// Prevent recursive walk. Just read files within fs.root
-		if info.IsDir() && path != fs.root {
+		// Hidden folders such as .git are not scanned
+		if info.IsDir() && strings.HasPrefix(info.Name(), ".") && cleanPath(path) != fs.root {
 			return filepath.SkipDir
 		}
 
@@ -189,7 +351,7 @@ func (fs *FileSystem) loadBlueprintFiles() {
 			return nil
 		}
 
-		fs.cacheThis(blueprint)
+		fs.cacheThis(blueprint, path)
 
 		return nil
 	})
@@ -213,6 +375,7 @@ func (fs *FileSystem) readBlueprintFile(blueprintFile string) (*core.Blueprint,
 	if err != nil {
 		return nil, err
 	}
+	def.Meta.Namespace = fs.getNamespace(blueprintFile)
 
 	// Validate the file
 	if !def.Valid() {
@@ -5,7 +5,10 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_ReadOnlyFilesystem(t *testing.T) {
@@ -35,3 +38,122 @@ func Test_cleanPath__ExpandRelativePath(t *testing.T) {
 	// filepath.join stips trailing slash
 	a.Equal(filepath.Join(cwd, "folder")+string(filepath.Separator), path)
 }
+
+func Test_WritableFilesystem__Location(t *testing.T) {
+	a := assertions.New(t)
+	dir := t.TempDir()
+	fs := NewWritableFileSystem(dir)
+	s := NewStorage().AddBackend(fs)
+
+	bp := core.Blueprint{Id: uuid.New()}
+	_, err := s.Save(bp)
+	a.NoError(err)
+	a.Equal(filepath.Join(dir, bp.Id.String()+".yaml"), s.Location(bp.Id))
+	a.Empty(s.Location(uuid.New()))
+}
+
+func Test_WritableFilesystem__Versions(t *testing.T) {
+	a := assertions.New(t)
+	s := NewStorage().AddBackend(NewWritableFileSystem(t.TempDir()))
+
+	bp := core.Blueprint{Id: uuid.New()}
+	for _, version := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		bp.Meta.Version = version
+		_, err := s.Save(bp)
+		a.NoError(err)
+	}
+
+	a.Equal([]string{"1.0.0", "1.1.0", "2.0.0"}, s.Versions(bp.Id))
+
+	latest, err := s.Load(bp.Id)
+	a.NoError(err)
+	a.Equal("2.0.0", latest.Meta.Version)
+
+	pinned, err := s.LoadVersion(bp.Id, "^1")
+	a.NoError(err)
+	a.Equal("1.1.0", pinned.Meta.Version)
+
+	pinned, err = s.LoadVersion(bp.Id, "~1.0")
+	a.NoError(err)
+	a.Equal("1.0.0", pinned.Meta.Version)
+
+	_, err = s.LoadVersion(bp.Id, "^3")
+	a.Error(err)
+}
+
+func Test_WritableFilesystem__LowerVersion(t *testing.T) {
+	a := assertions.New(t)
+	fs := NewWritableFileSystem(t.TempDir())
+	s := NewStorage().AddBackend(fs)
+
+	bp := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Version: "2.0.0"}}
+	_, err := s.Save(bp)
+	require.NoError(t, err)
+
+	bp.Meta.Version = "1.0.0"
+	_, err = s.Save(bp)
+	a.Error(err)
+
+	latest, err := s.Load(bp.Id)
+	require.NoError(t, err)
+	a.Equal("2.0.0", latest.Meta.Version)
+	a.Equal([]string{"2.0.0"}, s.Versions(bp.Id))
+	a.Equal(filepath.Join(fs.root, bp.Id.String()+".yaml"), fs.Location(bp.Id))
+
+	// Later saves still go to the file of the latest version
+	bp.Meta.Version = "2.1.0"
+	_, err = s.Save(bp)
+	require.NoError(t, err)
+	a.Equal([]string{"2.0.0", "2.1.0"}, s.Versions(bp.Id))
+	a.Equal(filepath.Join(fs.root, bp.Id.String()+".yaml"), fs.Location(bp.Id))
+}
+
+func Test_FileSystem__NestedFolders(t *testing.T) {
+	a := assertions.New(t)
+	dir := t.TempDir()
+
+	nested := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Name: "invoice"}}
+	hidden := core.Blueprint{Id: uuid.New()}
+	_, err := NewWritableFileSystem(filepath.Join(dir, "finance", "billing")).Save(nested)
+	a.NoError(err)
+	_, err = NewWritableFileSystem(filepath.Join(dir, ".git")).Save(hidden)
+	a.NoError(err)
+
+	fs := NewReadOnlyFileSystem(dir)
+	a.True(fs.Has(nested.Id))
+	a.False(fs.Has(hidden.Id))
+
+	loaded, err := fs.Load(nested.Id)
+	a.NoError(err)
+	a.Equal("invoice", loaded.Meta.Name)
+	a.Equal("finance.billing", loaded.Meta.Namespace)
+	a.Equal(filepath.Join(dir, "finance", "billing", nested.Id.String()+".yaml"), fs.Location(nested.Id))
+}
+
+func Test_WritableFilesystem__SaveKeepsFolder(t *testing.T) {
+	a := assertions.New(t)
+	dir := t.TempDir()
+	s := NewStorage().AddBackend(NewWritableFileSystem(dir))
+
+	bp := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Namespace: "finance.billing"}}
+	_, err := s.Save(bp)
+	a.NoError(err)
+	path := filepath.Join(dir, "finance", "billing", bp.Id.String()+".yaml")
+	a.FileExists(path)
+
+	// Blueprints stay in their folder even if their namespace is not given when saving them again
+	bp.Meta.Namespace = ""
+	bp.Meta.Version = "1.0.0"
+	_, err = NewStorage().AddBackend(NewWritableFileSystem(dir)).Save(bp)
+	a.NoError(err)
+	a.NoFileExists(filepath.Join(dir, bp.Id.String()+".yaml"))
+
+	loaded, err := s.Load(bp.Id)
+	a.NoError(err)
+	a.Equal("1.0.0", loaded.Meta.Version)
+	a.Equal("finance.billing", loaded.Meta.Namespace)
+	a.Equal(path, s.Location(bp.Id))
+
+	_, err = s.Save(core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Namespace: "finance..billing"}})
+	a.Error(err)
+}
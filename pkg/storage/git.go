@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// DefaultGitAuthor is the author of commits for which no author is given.
+const DefaultGitAuthor = "slang <slang@localhost>"
+
+// Revision is a saved state of a blueprint in a backend keeping the history of blueprints.
+type Revision struct {
+	Id      string    `json:"id"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// HistoricBackend is a writable backend which keeps the history of the blueprints saved to it.
+type HistoricBackend interface {
+	WriteableBackend
+	// SaveWithMessage saves blueprints as a single revision described by message and authored by author. Defaults
+	// are used for empty messages and authors.
+	SaveWithMessage(blueprints []core.Blueprint, message string, author string) error
+	// History returns the revisions of the blueprint with the given id, latest first.
+	History(opId uuid.UUID) ([]Revision, error)
+	LoadRevision(opId uuid.UUID, revision string) (*core.Blueprint, error)
+	// Revert saves the blueprint with the given id as it was at revision.
+	Revert(opId uuid.UUID, revision string, author string) error
+}
+
+// Git stores blueprints as files in a directory within a git repository and commits each save. The git executable
+// must be installed.
+type Git struct {
+	WritableFileSystem
+	author string
+	// mutex serializes saves, which would otherwise compete for the index of the repository
+	mutex sync.Mutex
+}
+
+// NewGit returns a backend storing blueprints in root. If root is not the top level directory of a git repository
+// yet, a repository is initialized in root, also if root is inside of another repository. Commits for which no author
+// is given are authored by author, which has the form "Name <email>".
+func NewGit(root string, author string) (*Git, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("git executable not found")
+	}
+	if author == "" {
+		author = DefaultGitAuthor
+	}
+	if _, _, err := parseGitAuthor(author); err != nil {
+		return nil, err
+	}
+
+	g := &Git{WritableFileSystem: *NewWritableFileSystem(root), author: author}
+	if _, err := utils.EnsureDirExists(g.root); err != nil {
+		return nil, err
+	}
+	if top, err := g.git("rev-parse", "--show-toplevel"); err != nil || !sameDir(strings.TrimSpace(top), g.root) {
+		if _, err := g.git("init"); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *Git) Save(blueprint core.Blueprint) (uuid.UUID, error) {
+	return blueprint.Id, g.SaveWithMessage([]core.Blueprint{blueprint}, "", "")
+}
+
+// SaveWithMessage writes the files of all blueprints and commits them. Other changes in the repository are not
+// committed. Nothing is committed if the blueprints have not changed.
+func (g *Git) SaveWithMessage(blueprints []core.Blueprint, message string, author string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	paths := make([]string, 0, len(blueprints))
+	for _, blueprint := range blueprints {
+		if _, err := g.WritableFileSystem.Save(blueprint); err != nil {
+			return err
+		}
+		// Include the files of former versions and formats of the blueprint
+		path, err := g.relPath(blueprint.Id)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.Join(filepath.Dir(path), blueprint.Id.String()+"*"))
+	}
+
+	if message == "" {
+		names := make([]string, len(blueprints))
+		for i, blueprint := range blueprints {
+			names[i] = blueprint.Meta.Name
+			if names[i] == "" {
+				names[i] = blueprint.Id.String()
+			}
+		}
+		message = "Save " + strings.Join(names, ", ")
+	}
+	return g.commit(paths, message, author)
+}
+
+// History returns the commits which changed the file of the blueprint with the given id, latest first.
+func (g *Git) History(opId uuid.UUID) ([]Revision, error) {
+	path, err := g.relPath(opId)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := g.git("log", "--format=%H%x1f%an <%ae>%x1f%aI%x1f%s", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]Revision, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339, fields[2])
+		revisions = append(revisions, Revision{fields[0], fields[1], t, fields[3]})
+	}
+	return revisions, nil
+}
+
+// LoadRevision loads the blueprint with the given id as it was committed in revision.
+func (g *Git) LoadRevision(opId uuid.UUID, revision string) (*core.Blueprint, error) {
+	path, err := g.relPath(opId)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(revision, "-") {
+		return nil, fmt.Errorf("invalid revision: %s", revision)
+	}
+
+	out, err := g.git("show", revision+":./"+path)
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s of operator %s", revision, opId)
+	}
+
+	var blueprint core.Blueprint
+	if utils.IsJSON(path) {
+		blueprint, err = core.ParseJSONOperatorDef(out)
+	} else {
+		blueprint, err = core.ParseYAMLOperatorDef(out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &blueprint, nil
+}
+
+// Revert commits the blueprint with the given id as it was in revision.
+func (g *Git) Revert(opId uuid.UUID, revision string, author string) error {
+	blueprint, err := g.LoadRevision(opId, revision)
+	if err != nil {
+		return err
+	}
+
+	short := revision
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	name := blueprint.Meta.Name
+	if name == "" {
+		name = opId.String()
+	}
+	return g.SaveWithMessage([]core.Blueprint{*blueprint}, fmt.Sprintf("Revert %s to %s", name, short), author)
+}
+
+// commit commits the changes of the files matching paths
+func (g *Git) commit(paths []string, message string, author string) error {
+	if author == "" {
+		author = g.author
+	}
+	name, email, err := parseGitAuthor(author)
+	if err != nil {
+		return err
+	}
+
+	if _, err := g.git(append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return err
+	}
+	if _, err := g.git(append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err == nil {
+		return nil
+	}
+
+	// Blueprints are committed in the name of their author
+	cmd := g.command(append([]string{"commit", "--quiet", "--message", message, "--author", author, "--"}, paths...)...)
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_NAME="+name, "GIT_COMMITTER_EMAIL="+email)
+	return runGit(cmd)
+}
+
+// relPath returns the path of the file of the blueprint with the given id relative to the root
+func (g *Git) relPath(opId uuid.UUID) (string, error) {
+	path, err := g.getFilePath(opId)
+	if err != nil {
+		return "", fmt.Errorf("unknown operator for id: %s", opId)
+	}
+	return filepath.Rel(g.root, path)
+}
+
+func (g *Git) command(args ...string) *exec.Cmd {
+	return exec.Command("git", append([]string{"-C", g.root}, args...)...)
+}
+
+func (g *Git) git(args ...string) (string, error) {
+	cmd := g.command(args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := runGit(cmd); err != nil {
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+func runGit(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git %s: %s", cmd.Args[3], msg)
+		}
+		return fmt.Errorf("git %s: %s", cmd.Args[3], err)
+	}
+	return nil
+}
+
+// sameDir returns true if both paths denote the same directory
+func sameDir(a, b string) bool {
+	if p, err := filepath.EvalSymlinks(a); err == nil {
+		a = p
+	}
+	if p, err := filepath.EvalSymlinks(b); err == nil {
+		b = p
+	}
+	return cleanPath(a) == cleanPath(b)
+}
+
+// parseGitAuthor splits an author of the form "Name <email>"
+func parseGitAuthor(author string) (string, string, error) {
+	open := strings.Index(author, "<")
+	if open < 1 || !strings.HasSuffix(author, ">") {
+		return "", "", fmt.Errorf(`invalid author "%s", must be "Name <email>"`, author)
+	}
+	return strings.TrimSpace(author[:open]), author[open+1 : len(author)-1], nil
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGit(t *testing.T) *Git {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not found")
+	}
+	g, err := NewGit(t.TempDir(), "Tester <tester@example.com>")
+	require.NoError(t, err)
+	return g
+}
+
+func Test_Git(t *testing.T) {
+	a := assertions.New(t)
+	g := newTestGit(t)
+	a.Implements((*WriteableBackend)(nil), g)
+	a.Implements((*HistoricBackend)(nil), g)
+
+	_, err := NewGit(t.TempDir(), "nobody")
+	a.Error(err)
+}
+
+func Test_Git__HistoryRevert(t *testing.T) {
+	a := assertions.New(t)
+	s := NewStorage().AddBackend(newTestGit(t))
+
+	bp := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Name: "first"}}
+	_, err := s.Save(bp)
+	require.NoError(t, err)
+
+	bp.Meta.Name = "second"
+	require.NoError(t, s.SaveAllWithMessage([]core.Blueprint{bp}, "Rename", "Author <author@example.com>"))
+
+	// Unchanged blueprints are not committed again
+	_, err = s.Save(bp)
+	require.NoError(t, err)
+
+	history, err := s.History(bp.Id)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	a.Equal("Rename", history[0].Message)
+	a.Equal("Author <author@example.com>", history[0].Author)
+	a.Equal("Save first", history[1].Message)
+	a.Equal("Tester <tester@example.com>", history[1].Author)
+
+	old, err := s.LoadRevision(bp.Id, history[1].Id)
+	require.NoError(t, err)
+	a.Equal("first", old.Meta.Name)
+
+	_, err = s.LoadRevision(bp.Id, "0000000")
+	a.Error(err)
+
+	require.NoError(t, s.Revert(bp.Id, history[1].Id, ""))
+	cur, err := s.Load(bp.Id)
+	require.NoError(t, err)
+	a.Equal("first", cur.Meta.Name)
+
+	history, err = s.History(bp.Id)
+	require.NoError(t, err)
+	a.Len(history, 3)
+	a.Equal("Revert first to "+history[2].Id[:7], history[0].Message)
+}
+
+func Test_Git__NoHistory(t *testing.T) {
+	a := assertions.New(t)
+	s := NewStorage().AddBackend(NewWritableFileSystem(t.TempDir()))
+
+	bp := core.Blueprint{Id: uuid.New()}
+	_, err := s.Save(bp)
+	require.NoError(t, err)
+
+	_, err = s.History(bp.Id)
+	a.Error(err)
+	a.Error(s.Revert(bp.Id, "HEAD", ""))
+}
+
+func Test_Git__OnlyBlueprintsCommitted(t *testing.T) {
+	a := assertions.New(t)
+	g := newTestGit(t)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(g.root, "notes.txt"), []byte("draft"), os.ModePerm))
+	_, err := g.Save(core.Blueprint{Id: uuid.New()})
+	require.NoError(t, err)
+
+	status, err := g.git("status", "--porcelain")
+	require.NoError(t, err)
+	a.Equal("?? notes.txt\n", status)
+}
+
+func Test_Git__NestedWorkspace(t *testing.T) {
+	a := assertions.New(t)
+	parent := newTestGit(t)
+	g, err := NewGit(filepath.Join(parent.root, "workspace"), "Tester <tester@example.com>")
+	require.NoError(t, err)
+
+	bp := core.Blueprint{Id: uuid.New()}
+	_, err = g.Save(bp)
+	require.NoError(t, err)
+
+	history, err := g.History(bp.Id)
+	require.NoError(t, err)
+	a.Len(history, 1)
+	_, err = parent.git("rev-parse", "HEAD")
+	a.Error(err)
+}
+
+func Test_Git__ConcurrentSaves(t *testing.T) {
+	a := assertions.New(t)
+	g := newTestGit(t)
+
+	bps := make([]core.Blueprint, 8)
+	errs := make([]error, len(bps))
+	var wg sync.WaitGroup
+	for i := range bps {
+		bps[i] = core.Blueprint{Id: uuid.New()}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = g.Save(bps[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, bp := range bps {
+		a.NoError(errs[i])
+		history, err := g.History(bp.Id)
+		a.NoError(err)
+		a.Len(history, 1)
+	}
+}
@@ -0,0 +1,268 @@
+//go:build sqlite
+
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// All versions of a blueprint are kept, latest marks the one Load returns. Tags and dependencies are those of the
+// latest version.
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS blueprints (
+		id TEXT NOT NULL,
+		version TEXT NOT NULL,
+		name TEXT NOT NULL,
+		latest INTEGER NOT NULL DEFAULT 0,
+		data TEXT NOT NULL,
+		PRIMARY KEY (id, version)
+	)`,
+	`CREATE INDEX IF NOT EXISTS blueprints_name ON blueprints (name)`,
+	`CREATE INDEX IF NOT EXISTS blueprints_latest ON blueprints (latest, id)`,
+	`CREATE TABLE IF NOT EXISTS tags (
+		id TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (id, tag)
+	)`,
+	`CREATE INDEX IF NOT EXISTS tags_tag ON tags (tag)`,
+	`CREATE TABLE IF NOT EXISTS dependencies (
+		id TEXT NOT NULL,
+		dependency TEXT NOT NULL,
+		PRIMARY KEY (id, dependency)
+	)`,
+	`CREATE INDEX IF NOT EXISTS dependencies_dependency ON dependencies (dependency)`,
+}
+
+// SQLite stores blueprints in an embedded SQLite database file. Blueprints are stored as JSON along with their name,
+// tags and the blueprints they depend on, which can be looked up without loading all blueprints. It needs cgo and is
+// only built with the sqlite build tag.
+type SQLite struct {
+	path string
+	db   *sql.DB
+}
+
+// NewSQLite opens the SQLite database at path, creating it if it does not exist yet.
+func NewSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer only
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range sqliteSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("cannot create schema of %s: %s", path, err)
+		}
+	}
+
+	return &SQLite{path, db}, nil
+}
+
+// Close closes the database.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLite) List() ([]uuid.UUID, error) {
+	return s.queryIds(`SELECT id FROM blueprints WHERE latest = 1 ORDER BY id`)
+}
+
+func (s *SQLite) Has(opId uuid.UUID) bool {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM blueprints WHERE id = ?`, opId.String()).Scan(&n)
+	return err == nil && n > 0
+}
+
+func (s *SQLite) Load(opId uuid.UUID) (*core.Blueprint, error) {
+	return s.loadBlueprint(`SELECT data FROM blueprints WHERE id = ? AND latest = 1`, opId.String())
+}
+
+// Versions returns the stored versions of the blueprint with the given id.
+func (s *SQLite) Versions(opId uuid.UUID) ([]string, error) {
+	rows, err := s.db.Query(`SELECT version FROM blueprints WHERE id = ?`, opId.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make([]string, 0)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("unknown operator for id: %s", opId)
+	}
+	return versions, nil
+}
+
+// LoadVersion loads the given version of the blueprint with the given id.
+func (s *SQLite) LoadVersion(opId uuid.UUID, version string) (*core.Blueprint, error) {
+	blueprint, err := s.loadBlueprint(`SELECT data FROM blueprints WHERE id = ? AND version = ?`, opId.String(), version)
+	if err != nil {
+		return nil, fmt.Errorf("unknown version %s of operator %s", version, opId)
+	}
+	return blueprint, nil
+}
+
+// Location returns the path of the database file for stored blueprints.
+func (s *SQLite) Location(opId uuid.UUID) string {
+	if !s.Has(opId) {
+		return ""
+	}
+	return s.path
+}
+
+func (s *SQLite) Save(blueprint core.Blueprint) (uuid.UUID, error) {
+	return blueprint.Id, s.SaveAll([]core.Blueprint{blueprint})
+}
+
+// SaveAll saves all blueprints in a single transaction, so that either all or none of them are saved. Saving a
+// blueprint with another version than the stored ones keeps the stored versions.
+func (s *SQLite) SaveAll(blueprints []core.Blueprint) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, blueprint := range blueprints {
+		if err := s.save(tx, blueprint); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("cannot save operator %s: %s", blueprint.Id, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLite) save(tx *sql.Tx, blueprint core.Blueprint) error {
+	id := blueprint.Id.String()
+
+	data, err := json.Marshal(&blueprint)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO blueprints (id, version, name, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id, version) DO UPDATE SET name = excluded.name, data = excluded.data`,
+		id, blueprint.Meta.Version, blueprint.Meta.Name, string(data)); err != nil {
+		return err
+	}
+
+	// As for files, the highest version is the latest one
+	rows, err := tx.Query(`SELECT version FROM blueprints WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	latest := blueprint.Meta.Version
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		if compareVersions(version, latest) > 0 {
+			latest = version
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE blueprints SET latest = (version = ?) WHERE id = ?`, latest, id); err != nil {
+		return err
+	}
+	if latest != blueprint.Meta.Version {
+		return nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM tags WHERE id = ?`, id); err != nil {
+		return err
+	}
+	for _, tag := range blueprint.Meta.Tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO tags (id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM dependencies WHERE id = ?`, id); err != nil {
+		return err
+	}
+	for _, dep := range blueprintDependencies(&blueprint) {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO dependencies (id, dependency) VALUES (?, ?)`, id, dep.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindByName returns the ids of the blueprints whose latest version has the given name.
+func (s *SQLite) FindByName(name string) ([]uuid.UUID, error) {
+	return s.queryIds(`SELECT id FROM blueprints WHERE latest = 1 AND name = ? ORDER BY id`, name)
+}
+
+// FindByTag returns the ids of the blueprints whose latest version is tagged with tag.
+func (s *SQLite) FindByTag(tag string) ([]uuid.UUID, error) {
+	return s.queryIds(`SELECT id FROM tags WHERE tag = ? ORDER BY id`, tag)
+}
+
+// Dependents returns the ids of the blueprints whose latest version instantiates or refers to the blueprint with the
+// given id.
+func (s *SQLite) Dependents(opId uuid.UUID) ([]uuid.UUID, error) {
+	return s.queryIds(`SELECT id FROM dependencies WHERE dependency = ? ORDER BY id`, opId.String())
+}
+
+func (s *SQLite) loadBlueprint(query string, args ...interface{}) (*core.Blueprint, error) {
+	var data string
+	if err := s.db.QueryRow(query, args...).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("unknown operator for id: %s", args[0])
+		}
+		return nil, err
+	}
+
+	blueprint, err := core.ParseJSONOperatorDef(data)
+	if err != nil {
+		return nil, err
+	}
+	return &blueprint, nil
+}
+
+func (s *SQLite) queryIds(query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		opId, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, opId)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,186 @@
+//go:build sqlite
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLite(t *testing.T) *SQLite {
+	db, err := NewSQLite(filepath.Join(t.TempDir(), "blueprints.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func Test_SQLite(t *testing.T) {
+	a := assertions.New(t)
+	db := newTestSQLite(t)
+	a.Implements((*WriteableBackend)(nil), db)
+	a.Implements((*VersionedBackend)(nil), db)
+	a.Implements((*TransactionalBackend)(nil), db)
+}
+
+func Test_SQLite__SaveLoad(t *testing.T) {
+	a := assertions.New(t)
+	db := newTestSQLite(t)
+	s := NewStorage().AddBackend(db)
+
+	bp := core.Blueprint{
+		Id:   uuid.New(),
+		Meta: core.BlueprintMetaDef{Name: "double"},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {In: core.TypeDef{Type: "number"}, Out: core.TypeDef{Type: "number"}},
+		},
+	}
+	_, err := s.Save(bp)
+	require.NoError(t, err)
+
+	a.True(s.IsSavedInWritableBackend(bp.Id))
+	a.False(db.Has(uuid.New()))
+
+	ids, err := db.List()
+	a.NoError(err)
+	a.Equal([]uuid.UUID{bp.Id}, ids)
+
+	loaded, err := s.Load(bp.Id)
+	require.NoError(t, err)
+	a.Equal("double", loaded.Meta.Name)
+	a.Equal("number", loaded.ServiceDefs[core.MAIN_SERVICE].In.Type)
+
+	_, err = db.Load(uuid.New())
+	a.Error(err)
+}
+
+func Test_SQLite__Versions(t *testing.T) {
+	a := assertions.New(t)
+	s := NewStorage().AddBackend(newTestSQLite(t))
+
+	bp := core.Blueprint{Id: uuid.New()}
+	for _, version := range []string{"1.0.0", "2.0.0", "1.1.0"} {
+		bp.Meta.Version = version
+		_, err := s.Save(bp)
+		a.NoError(err)
+	}
+
+	a.Equal([]string{"1.0.0", "1.1.0", "2.0.0"}, s.Versions(bp.Id))
+
+	latest, err := s.Load(bp.Id)
+	a.NoError(err)
+	a.Equal("2.0.0", latest.Meta.Version)
+
+	pinned, err := s.LoadVersion(bp.Id, "^1")
+	a.NoError(err)
+	a.Equal("1.1.0", pinned.Meta.Version)
+}
+
+func Test_SQLite__Lookups(t *testing.T) {
+	a := assertions.New(t)
+	db := newTestSQLite(t)
+
+	inner := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Name: "inner", Tags: []string{"math"}}}
+	outer := core.Blueprint{
+		Id:           uuid.New(),
+		Meta:         core.BlueprintMetaDef{Name: "outer", Tags: []string{"math", "example"}},
+		InstanceDefs: core.InstanceDefList{{Name: "a", Operator: inner.Id}, {Name: "b", Operator: inner.Id}},
+	}
+	require.NoError(t, db.SaveAll([]core.Blueprint{inner, outer}))
+
+	ids, err := db.FindByName("outer")
+	a.NoError(err)
+	a.Equal([]uuid.UUID{outer.Id}, ids)
+
+	ids, err = db.FindByTag("example")
+	a.NoError(err)
+	a.Equal([]uuid.UUID{outer.Id}, ids)
+	ids, err = db.FindByTag("math")
+	a.NoError(err)
+	a.Len(ids, 2)
+
+	ids, err = db.Dependents(inner.Id)
+	a.NoError(err)
+	a.Equal([]uuid.UUID{outer.Id}, ids)
+
+	// Saving the blueprint again replaces its index entries
+	outer.InstanceDefs = nil
+	outer.Meta.Tags = nil
+	_, err = db.Save(outer)
+	a.NoError(err)
+	ids, err = db.Dependents(inner.Id)
+	a.NoError(err)
+	a.Empty(ids)
+	ids, err = db.FindByTag("example")
+	a.NoError(err)
+	a.Empty(ids)
+}
+
+func Test_SQLite__IndexedStorage(t *testing.T) {
+	a := assertions.New(t)
+	db := newTestSQLite(t)
+	fs := NewWritableFileSystem(t.TempDir())
+	s := NewStorage().AddBackend(db).AddBackend(fs)
+
+	// Dependents are looked up in the index of the database and in the blueprints of the file system
+	leaf := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Name: "leaf", Tags: []string{"math"}}}
+	mid := core.Blueprint{Id: uuid.New(), InstanceDefs: core.InstanceDefList{{Name: "a", Operator: leaf.Id}}}
+	top := core.Blueprint{Id: uuid.New(), InstanceDefs: core.InstanceDefList{{Name: "a", Operator: mid.Id}}}
+	require.NoError(t, db.SaveAll([]core.Blueprint{leaf, mid}))
+	_, err := fs.Save(top)
+	require.NoError(t, err)
+
+	ids, err := s.Dependents(leaf.Id, false)
+	a.NoError(err)
+	a.Equal([]uuid.UUID{mid.Id}, ids)
+
+	ids, err = s.Dependents(leaf.Id, true)
+	a.NoError(err)
+	a.ElementsMatch([]uuid.UUID{mid.Id, top.Id}, ids)
+
+	ids, err = s.FindByName("leaf")
+	a.NoError(err)
+	a.Equal([]uuid.UUID{leaf.Id}, ids)
+
+	ids, err = s.FindByTag("math")
+	a.NoError(err)
+	a.Equal([]uuid.UUID{leaf.Id}, ids)
+}
+
+func Test_SQLite__Persistent(t *testing.T) {
+	a := assertions.New(t)
+	path := filepath.Join(t.TempDir(), "blueprints.db")
+
+	db, err := NewSQLite(path)
+	require.NoError(t, err)
+	bp := core.Blueprint{Id: uuid.New()}
+	_, err = db.Save(bp)
+	a.NoError(err)
+	a.NoError(db.Close())
+
+	db, err = NewSQLite(path)
+	require.NoError(t, err)
+	defer db.Close()
+	a.True(db.Has(bp.Id))
+	a.Equal(path, db.Location(bp.Id))
+}
+
+func Test_Storage__SaveAll(t *testing.T) {
+	a := assertions.New(t)
+	fs := NewWritableFileSystem(t.TempDir())
+	db := newTestSQLite(t)
+	s := NewStorage().AddBackend(fs).AddBackend(db)
+
+	bps := []core.Blueprint{{Id: uuid.New()}, {Id: uuid.New()}}
+	a.NoError(s.SaveAll(bps))
+	for _, bp := range bps {
+		a.True(fs.Has(bp.Id))
+		a.True(db.Has(bp.Id))
+	}
+
+	a.EqualError(NewStorage().AddBackend(NewReadOnlyFileSystem("/somewhere")).SaveAll(bps), "no writable backend for saving found")
+}
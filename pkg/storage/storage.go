@@ -3,10 +3,13 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/pkg/elem"
 	"github.com/google/uuid"
+	"github.com/thoas/go-funk"
 )
 
 type Backend interface {
@@ -20,12 +23,43 @@ type WriteableBackend interface {
 	Save(blueprint core.Blueprint) (uuid.UUID, error)
 }
 
+// TransactionalBackend is a writable backend which can save several blueprints at once, so that either all or none
+// of them are saved.
+type TransactionalBackend interface {
+	WriteableBackend
+	SaveAll(blueprints []core.Blueprint) error
+}
+
+// LocatableBackend is a backend which can tell where a blueprint is stored, e.g. the path of its file.
+type LocatableBackend interface {
+	Backend
+	Location(opId uuid.UUID) string
+}
+
+// VersionedBackend is a backend which can hold several versions of the same blueprint. Load returns the latest one.
+type VersionedBackend interface {
+	Backend
+	Versions(opId uuid.UUID) ([]string, error)
+	LoadVersion(opId uuid.UUID, version string) (*core.Blueprint, error)
+}
+
+// IndexedBackend is a backend which can look up blueprints by their metadata and dependencies without loading all of
+// them. Lookups only consider the latest version of each blueprint.
+type IndexedBackend interface {
+	Backend
+	FindByName(name string) ([]uuid.UUID, error)
+	FindByTag(tag string) ([]uuid.UUID, error)
+	Dependents(opId uuid.UUID) ([]uuid.UUID, error)
+}
+
 type Storage struct {
 	backends []Backend
+	subs     *subscriptions
+	deps     *depGraph
 }
 
 func NewStorage() *Storage {
-	return &Storage{make([]Backend, 0)}
+	return &Storage{make([]Backend, 0), &subscriptions{handlers: make(map[int]func(Change))}, &depGraph{}}
 }
 
 func (s *Storage) AddBackend(backend Backend) *Storage {
@@ -52,6 +86,14 @@ func (s *Storage) IsSaved(opId uuid.UUID) bool {
 	return false
 }
 
+// Location returns where the blueprint with the given id is stored or an empty string if it is unknown.
+func (s *Storage) Location(opId uuid.UUID) string {
+	if backend, ok := s.selectBackend(opId).(LocatableBackend); ok {
+		return backend.Location(opId)
+	}
+	return ""
+}
+
 func (s *Storage) List() ([]uuid.UUID, error) {
 	all := make([]uuid.UUID, 0)
 
@@ -67,6 +109,60 @@ func (s *Storage) List() ([]uuid.UUID, error) {
 	return all, nil
 }
 
+// FindByName returns the ids of the stored blueprints with the given name.
+func (s *Storage) FindByName(name string) ([]uuid.UUID, error) {
+	return s.find(func(b IndexedBackend) ([]uuid.UUID, error) {
+		return b.FindByName(name)
+	}, func(blueprint *core.Blueprint) bool {
+		return blueprint.Meta.Name == name
+	})
+}
+
+// FindByTag returns the ids of the stored blueprints tagged with tag.
+func (s *Storage) FindByTag(tag string) ([]uuid.UUID, error) {
+	return s.find(func(b IndexedBackend) ([]uuid.UUID, error) {
+		return b.FindByTag(tag)
+	}, func(blueprint *core.Blueprint) bool {
+		return funk.ContainsString(blueprint.Meta.Tags, tag)
+	})
+}
+
+// find looks up blueprints in the index of indexed backends and loads and matches the blueprints of all other backends
+func (s *Storage) find(lookup func(IndexedBackend) ([]uuid.UUID, error), match func(*core.Blueprint) bool) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool)
+	found := make([]uuid.UUID, 0)
+
+	for _, backend := range s.backends {
+		var ids []uuid.UUID
+		if ib, ok := backend.(IndexedBackend); ok {
+			var err error
+			if ids, err = lookup(ib); err != nil {
+				return nil, err
+			}
+		} else {
+			all, err := backend.List()
+			if err != nil {
+				continue
+			}
+			for _, id := range all {
+				if blueprint, err := backend.Load(id); err == nil && match(blueprint) {
+					ids = append(ids, id)
+				}
+			}
+		}
+
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				found = append(found, id)
+			}
+		}
+	}
+
+	sortIds(found)
+	return found, nil
+}
+
 func (s *Storage) Save(blueprint core.Blueprint) (uuid.UUID, error) {
 	var opId uuid.UUID
 	var err error
@@ -79,9 +175,82 @@ func (s *Storage) Save(blueprint core.Blueprint) (uuid.UUID, error) {
 	for _, backend := range writableBackends {
 		opId, err = backend.Save(blueprint)
 	}
+	s.deps.invalidate()
 	return opId, err
 }
 
+// SaveAll saves all blueprints. Transactional backends save them at once, other backends one after the other.
+func (s *Storage) SaveAll(blueprints []core.Blueprint) error {
+	return s.SaveAllWithMessage(blueprints, "", "")
+}
+
+// SaveAllWithMessage saves all blueprints as SaveAll does. Backends keeping the history of blueprints save them as a
+// single revision with the given message and author.
+func (s *Storage) SaveAllWithMessage(blueprints []core.Blueprint, message string, author string) error {
+	writableBackends := s.writeableBackends()
+	if len(writableBackends) == 0 {
+		return errors.New("no writable backend for saving found")
+	}
+	defer s.deps.invalidate()
+	for _, backend := range writableBackends {
+		if hb, ok := backend.(HistoricBackend); ok {
+			if err := hb.SaveWithMessage(blueprints, message, author); err != nil {
+				return err
+			}
+			continue
+		}
+		if tb, ok := backend.(TransactionalBackend); ok {
+			if err := tb.SaveAll(blueprints); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, blueprint := range blueprints {
+			if _, err := backend.Save(blueprint); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// History returns the revisions of the blueprint with the given id, latest first.
+func (s *Storage) History(opId uuid.UUID) ([]Revision, error) {
+	hb, err := s.historicBackend(opId)
+	if err != nil {
+		return nil, err
+	}
+	return hb.History(opId)
+}
+
+// LoadRevision loads the blueprint with the given id as it was at revision.
+func (s *Storage) LoadRevision(opId uuid.UUID, revision string) (*core.Blueprint, error) {
+	hb, err := s.historicBackend(opId)
+	if err != nil {
+		return nil, err
+	}
+	return hb.LoadRevision(opId, revision)
+}
+
+// Revert saves the blueprint with the given id as it was at revision.
+func (s *Storage) Revert(opId uuid.UUID, revision string, author string) error {
+	hb, err := s.historicBackend(opId)
+	if err != nil {
+		return err
+	}
+	defer s.deps.invalidate()
+	return hb.Revert(opId, revision, author)
+}
+
+func (s *Storage) historicBackend(opId uuid.UUID) (HistoricBackend, error) {
+	for _, backend := range s.selectBackends(func(b Backend) bool { return b.Has(opId) }) {
+		if hb, ok := backend.(HistoricBackend); ok {
+			return hb, nil
+		}
+	}
+	return nil, fmt.Errorf("no history of operator %s", opId)
+}
+
 func (s *Storage) writeableBackends() []WriteableBackend {
 	writeableBackends := make([]WriteableBackend, 0)
 
@@ -104,6 +273,104 @@ func (s *Storage) Load(opId uuid.UUID) (*core.Blueprint, error) {
 	return &cpyBlueprint, nil
 }
 
+// Versions returns all versions of the blueprint with the given id found in any backend in ascending order.
+func (s *Storage) Versions(opId uuid.UUID) []string {
+	versions := make([]string, 0)
+	for _, c := range s.versionCandidates(opId) {
+		if !funk.ContainsString(versions, c.version) {
+			versions = append(versions, c.version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+// LoadVersion loads the latest version of the blueprint with the given id which lies within versionRange.
+// Builtin blueprints are not versioned and always returned.
+func (s *Storage) LoadVersion(opId uuid.UUID, versionRange string) (*core.Blueprint, error) {
+	if versionRange == "" || elem.IsRegistered(opId) {
+		return s.Load(opId)
+	}
+
+	r, err := core.ParseVersionRange(versionRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *versionCandidate
+	for _, c := range s.versionCandidates(opId) {
+		if !r.ContainsString(c.version) {
+			continue
+		}
+		if best == nil || compareVersions(c.version, best.version) > 0 {
+			best = c
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no version of operator %s matches %s", opId, versionRange)
+	}
+
+	blueprint, err := best.load()
+	if err != nil {
+		return nil, err
+	}
+	cpyBlueprint := blueprint.Copy(true)
+	return &cpyBlueprint, nil
+}
+
+type versionCandidate struct {
+	version string
+	load    func() (*core.Blueprint, error)
+}
+
+func (s *Storage) versionCandidates(opId uuid.UUID) []*versionCandidate {
+	candidates := make([]*versionCandidate, 0)
+
+	for _, backend := range s.selectBackends(func(b Backend) bool { return b.Has(opId) }) {
+		if vb, ok := backend.(VersionedBackend); ok {
+			versions, err := vb.Versions(opId)
+			if err != nil {
+				continue
+			}
+			for _, version := range versions {
+				version := version
+				candidates = append(candidates, &versionCandidate{version, func() (*core.Blueprint, error) {
+					return vb.LoadVersion(opId, version)
+				}})
+			}
+			continue
+		}
+
+		blueprint, err := backend.Load(opId)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, &versionCandidate{blueprint.Meta.Version, func() (*core.Blueprint, error) {
+			return blueprint, nil
+		}})
+	}
+
+	return candidates
+}
+
+// compareVersions compares two versions. Unversioned and invalid versions are lower than all valid versions.
+func compareVersions(a, b string) int {
+	va, errA := core.ParseVersion(a)
+	vb, errB := core.ParseVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return va.Compare(vb)
+}
+
 func (s *Storage) selectBackends(f func(Backend) bool) []Backend {
 	selected := make([]Backend, 0)
 	for _, backend := range s.backends {
@@ -6,6 +6,7 @@ import (
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/tests/assertions"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_ReadOnlyStorage(t *testing.T) {
@@ -34,3 +35,24 @@ func Test_SavingWithoutWritableBackend(t *testing.T) {
 	a.Equal(id, u)
 	a.EqualError(err, "No writable backend for saving found")
 }
+
+func Test_Storage__Find(t *testing.T) {
+	a := assertions.New(t)
+	s := NewStorage().AddBackend(NewWritableFileSystem(t.TempDir()))
+
+	first := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Name: "first", Tags: []string{"math"}}}
+	second := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Name: "second", Tags: []string{"math", "example"}}}
+	require.NoError(t, s.SaveAll([]core.Blueprint{first, second}))
+
+	ids, err := s.FindByName("second")
+	a.NoError(err)
+	a.Equal([]uuid.UUID{second.Id}, ids)
+
+	ids, err = s.FindByTag("math")
+	a.NoError(err)
+	a.ElementsMatch([]uuid.UUID{first.Id, second.Id}, ids)
+
+	ids, err = s.FindByTag("unknown")
+	a.NoError(err)
+	a.Empty(ids)
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/uuid"
+)
+
+// watchDelay is how long a watched file system has to be quiet before changes are collected, so that a blueprint
+// written in several steps is only reported once
+const watchDelay = 100 * time.Millisecond
+
+type ChangeType string
+
+const (
+	BLUEPRINT_ADDED    ChangeType = "added"
+	BLUEPRINT_MODIFIED ChangeType = "modified"
+	BLUEPRINT_REMOVED  ChangeType = "removed"
+)
+
+// Change describes a blueprint which has been added to, modified in or removed from a backend.
+type Change struct {
+	Id   uuid.UUID  `json:"id"`
+	Type ChangeType `json:"type"`
+}
+
+// WatchableBackend is a backend which notices changes of its blueprints, including changes made by other processes.
+type WatchableBackend interface {
+	Backend
+	// Watch keeps the backend coherent with changes and passes them to notify until Unwatch is called.
+	Watch(notify func(Change)) error
+	Unwatch() error
+}
+
+// subscriptions holds the handlers subscribed to changes of a storage
+type subscriptions struct {
+	mutex    sync.Mutex
+	handlers map[int]func(Change)
+	next     int
+}
+
+// Subscribe adds a handler called for each change of the blueprints in the watched backends. It returns a function
+// removing the handler again.
+func (s *Storage) Subscribe(handler func(Change)) func() {
+	s.subs.mutex.Lock()
+	defer s.subs.mutex.Unlock()
+
+	id := s.subs.next
+	s.subs.next++
+	s.subs.handlers[id] = handler
+
+	return func() {
+		s.subs.mutex.Lock()
+		defer s.subs.mutex.Unlock()
+		delete(s.subs.handlers, id)
+	}
+}
+
+// Watch starts watching all backends which support it.
+func (s *Storage) Watch() error {
+	for _, backend := range s.backends {
+		if wb, ok := backend.(WatchableBackend); ok {
+			if err := wb.Watch(s.notify); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Unwatch stops watching all backends.
+func (s *Storage) Unwatch() error {
+	var err error
+	for _, backend := range s.backends {
+		if wb, ok := backend.(WatchableBackend); ok {
+			if e := wb.Unwatch(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+func (s *Storage) notify(c Change) {
+	s.deps.invalidate()
+
+	s.subs.mutex.Lock()
+	handlers := make([]func(Change), 0, len(s.subs.handlers))
+	for _, handler := range s.subs.handlers {
+		handlers = append(handlers, handler)
+	}
+	s.subs.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(c)
+	}
+}
+
+// fsWatch watches the folders of a file system
+type fsWatch struct {
+	watcher *fsnotify.Watcher
+	done    chan bool
+}
+
+// Watch watches the root and all folders below it. Blueprints are read again whenever files change, so that the cache
+// no longer needs to be cleared.
+func (fs *FileSystem) Watch(notify func(Change)) error {
+	fs.cacheLock.Lock()
+	if fs.watch != nil {
+		fs.cacheLock.Unlock()
+		return errors.New("already watching " + fs.root)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fs.cacheLock.Unlock()
+		return err
+	}
+	if err := addWatches(watcher, fs.root); err != nil {
+		fs.cacheLock.Unlock()
+		watcher.Close()
+		return err
+	}
+	w := &fsWatch{watcher, make(chan bool)}
+	fs.watch = w
+	fs.cacheLock.Unlock()
+
+	// Changes are reported relative to the blueprints present when watching starts
+	fs.reload()
+	go fs.watchLoop(w, notify)
+	return nil
+}
+
+// Unwatch stops watching the file system.
+func (fs *FileSystem) Unwatch() error {
+	fs.cacheLock.Lock()
+	defer fs.cacheLock.Unlock()
+
+	if fs.watch == nil {
+		return nil
+	}
+	close(fs.watch.done)
+	err := fs.watch.watcher.Close()
+	fs.watch = nil
+	return err
+}
+
+func (fs *FileSystem) watchLoop(w *fsWatch, notify func(Change)) {
+	var delay <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if strings.HasPrefix(filepath.Base(event.Name), ".") {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addWatches(w.watcher, event.Name); err != nil {
+						log.Printf("cannot watch %s: %s", event.Name, err)
+					}
+				}
+			}
+			delay = time.After(watchDelay)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("error watching %s: %s", fs.root, err)
+		case <-delay:
+			delay = nil
+			for _, c := range fs.reload() {
+				notify(c)
+			}
+		}
+	}
+}
+
+// reload reads all blueprint files again and replaces the cache. It returns the changes compared to the previous
+// cache.
+func (fs *FileSystem) reload() []Change {
+	fresh := &FileSystem{
+		root:     fs.root,
+		cache:    make(map[uuid.UUID]*core.Blueprint),
+		paths:    make(map[uuid.UUID]string),
+		versions: make(map[uuid.UUID]map[string]*core.Blueprint),
+	}
+	fresh.loadBlueprintFiles()
+
+	fs.cacheLock.Lock()
+	defer fs.cacheLock.Unlock()
+
+	changes := make([]Change, 0)
+	for id, blueprint := range fresh.cache {
+		if cur, ok := fs.cache[id]; !ok {
+			changes = append(changes, Change{id, BLUEPRINT_ADDED})
+		} else if !reflect.DeepEqual(cur, blueprint) || !reflect.DeepEqual(fs.versions[id], fresh.versions[id]) {
+			changes = append(changes, Change{id, BLUEPRINT_MODIFIED})
+		}
+	}
+	for id := range fs.cache {
+		if _, ok := fresh.cache[id]; !ok {
+			changes = append(changes, Change{id, BLUEPRINT_REMOVED})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Id.String() < changes[j].Id.String()
+	})
+
+	fs.cache, fs.paths, fs.versions = fresh.cache, fresh.paths, fresh.versions
+	return changes
+}
+
+// addWatches adds dir and all folders below it to watcher, except hidden folders
+func addWatches(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && cleanPath(path) != cleanPath(dir) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
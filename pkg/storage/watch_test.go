@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func writeTestBlueprint(t *testing.T, path string, bp core.Blueprint) {
+	data, err := yaml.Marshal(&bp)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(path, data, os.ModePerm))
+}
+
+func waitChange(t *testing.T, changes chan Change) Change {
+	select {
+	case c := <-changes:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+		return Change{}
+	}
+}
+
+func Test_Storage__Watch(t *testing.T) {
+	a := assertions.New(t)
+	dir := t.TempDir()
+	fs := NewReadOnlyFileSystem(dir)
+	s := NewStorage().AddBackend(fs)
+	a.Implements((*WatchableBackend)(nil), fs)
+
+	existing := core.Blueprint{Id: uuid.New()}
+	writeTestBlueprint(t, filepath.Join(dir, existing.Id.String()+".yaml"), existing)
+
+	changes := make(chan Change, 10)
+	s.Subscribe(func(c Change) { changes <- c })
+	require.NoError(t, s.Watch())
+	defer s.Unwatch()
+	a.Error(fs.Watch(func(Change) {}))
+
+	bp := core.Blueprint{Id: uuid.New(), Meta: core.BlueprintMetaDef{Name: "first"}}
+	path := filepath.Join(dir, "nested", bp.Id.String()+".yaml")
+	writeTestBlueprint(t, path, bp)
+	a.Equal(Change{bp.Id, BLUEPRINT_ADDED}, waitChange(t, changes))
+
+	bp.Meta.Name = "second"
+	writeTestBlueprint(t, path, bp)
+	a.Equal(Change{bp.Id, BLUEPRINT_MODIFIED}, waitChange(t, changes))
+	loaded, err := s.Load(bp.Id)
+	a.NoError(err)
+	a.Equal("second", loaded.Meta.Name)
+
+	require.NoError(t, os.Remove(path))
+	a.Equal(Change{bp.Id, BLUEPRINT_REMOVED}, waitChange(t, changes))
+	a.False(s.IsSaved(bp.Id))
+	a.True(s.IsSaved(existing.Id))
+}
+
+func Test_Storage__Unsubscribe(t *testing.T) {
+	a := assertions.New(t)
+	dir := t.TempDir()
+	s := NewStorage().AddBackend(NewWritableFileSystem(dir))
+
+	changes := make(chan Change, 10)
+	unsubscribe := s.Subscribe(func(c Change) { changes <- c })
+	other := make(chan Change, 10)
+	s.Subscribe(func(c Change) { other <- c })
+	require.NoError(t, s.Watch())
+	defer s.Unwatch()
+
+	// Saved blueprints are reported as well
+	bp := core.Blueprint{Id: uuid.New()}
+	_, err := s.Save(bp)
+	require.NoError(t, err)
+	a.Equal(bp.Id, waitChange(t, changes).Id)
+	a.Equal(bp.Id, waitChange(t, other).Id)
+
+	unsubscribe()
+	writeTestBlueprint(t, filepath.Join(dir, uuid.New().String()+".yaml"), core.Blueprint{Id: uuid.New()})
+	waitChange(t, other)
+	a.Empty(changes)
+
+	a.NoError(s.Unwatch())
+	a.NoError(s.Unwatch())
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func bufferTestOperator(t *testing.T, in core.TypeDef) *core.Operator {
+	o, err := core.NewOperator("", func(*core.Operator) {}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: in, Out: core.TypeDef{Type: "trigger"}}}})
+	require.NoError(t, err)
+	return o
+}
+
+func pullN(p *core.Port, n int) []interface{} {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = p.Pull()
+	}
+	return items
+}
+
+// Port.SetBuffer (9 tests)
+
+func TestPort_SetBuffer__Block(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "number"}).Main().In()
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Size: 2}))
+	a.Equal(core.BufferDef{Size: 2, Policy: core.BUFFER_POLICY_BLOCK}, p.Buffer())
+
+	a.NoError(p.PushContext(context.Background(), 1.0))
+	a.NoError(p.PushContext(context.Background(), 2.0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	a.Equal(context.DeadlineExceeded, p.PushContext(ctx, 3.0))
+
+	a.Equal(2, p.Metrics().BufferCapacity)
+	a.Equal([]interface{}{1.0, 2.0}, pullN(p, 2))
+}
+
+func TestPort_SetBuffer__DropNewest(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "number"}).Main().In()
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Size: 2, Policy: core.BUFFER_POLICY_DROP_NEWEST}))
+	for i := 1; i <= 4; i++ {
+		p.Push(float64(i))
+	}
+
+	a.Equal(uint64(2), p.Metrics().Dropped)
+	a.Equal([]interface{}{1.0, 2.0}, pullN(p, 2))
+}
+
+func TestPort_SetBuffer__DropOldest(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "number"}).Main().In()
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Size: 2, Policy: core.BUFFER_POLICY_DROP_OLDEST}))
+	for i := 1; i <= 4; i++ {
+		p.Push(float64(i))
+	}
+
+	a.Equal(uint64(2), p.Metrics().Dropped)
+	a.Equal([]interface{}{3.0, 4.0}, pullN(p, 2))
+}
+
+func TestPort_SetBuffer__Spill(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "primitive"}).Main().In()
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Size: 2, Policy: core.BUFFER_POLICY_SPILL}))
+	items := []interface{}{1.0, "b", true, core.Binary("d"), 5.0}
+	for _, i := range items {
+		p.Push(i)
+	}
+
+	m := p.Metrics()
+	a.Equal(2, m.Buffered)
+	a.Equal(3, m.Spilled)
+
+	a.Equal(items[:3], pullN(p, 3))
+	p.Push(6.0)
+	a.Equal([]interface{}{core.Binary("d"), 5.0, 6.0}, pullN(p, 3))
+
+	m = p.Metrics()
+	a.Equal(0, m.Buffered)
+	a.Equal(0, m.Spilled)
+	a.Equal(uint64(0), m.Dropped)
+}
+
+func TestPort_SetBuffer__SpillStream(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "number"}}).Main().In()
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Size: 1, Policy: core.BUFFER_POLICY_SPILL}))
+	p.Push([]interface{}{1.0, 2.0, 3.0})
+	p.Push([]interface{}{})
+
+	a.Equal(6, p.Stream().Metrics().Spilled)
+	a.Equal([]interface{}{1.0, 2.0, 3.0}, p.Pull())
+	a.Equal([]interface{}{}, p.Pull())
+}
+
+func TestPort_SetBuffer__DropOldestKeepsStreams(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "number"}}).Main().In()
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Size: 3, Policy: core.BUFFER_POLICY_DROP_OLDEST}))
+	p.Push([]interface{}{1.0, 2.0, 3.0, 4.0})
+
+	a.Equal(uint64(3), p.Stream().Metrics().Dropped)
+	a.Equal([]interface{}{4.0}, p.Pull())
+}
+
+func TestPort_SetBuffer__DropOldestOnlyMarkers(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "number"}}).Main().In()
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Size: 1, Policy: core.BUFFER_POLICY_DROP_OLDEST}))
+
+	// The start of the stream cannot be dropped, so the item waits for space
+	go p.Push([]interface{}{1.0})
+	time.Sleep(10 * time.Millisecond)
+
+	metrics := make(chan core.PortMetrics, 1)
+	go func() { metrics <- p.Stream().Metrics() }()
+	select {
+	case m := <-metrics:
+		a.Equal(1, m.Buffered)
+	case <-time.After(time.Second):
+		t.Fatal("metrics blocked by waiting push")
+	}
+
+	a.Equal([]interface{}{1.0}, p.Pull())
+}
+
+func TestPort_SetBuffer__DropNewestKeepsStreams(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "number"}}).Main().In()
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Size: 3, Policy: core.BUFFER_POLICY_DROP_NEWEST}))
+
+	// The end of the stream waits for space
+	go p.Push([]interface{}{1.0, 2.0, 3.0, 4.0})
+	require.Eventually(t, func() bool { return p.Stream().Metrics().Dropped == 2 }, time.Second, time.Millisecond)
+
+	a.Equal([]interface{}{1.0, 2.0}, p.Pull())
+}
+
+func TestPort_SetBuffer__InvalidPolicy(t *testing.T) {
+	a := assertions.New(t)
+	p := bufferTestOperator(t, core.TypeDef{Type: "number"}).Main().In()
+
+	a.Error(p.SetBuffer(core.BufferDef{Policy: "drop-random"}))
+	a.Error(p.SetBuffer(core.BufferDef{Size: -1}))
+}
+
+// RuntimeConfig (2 tests)
+
+func TestRuntimeConfig__BufferDefaults(t *testing.T) {
+	a := assertions.New(t)
+	defer core.SetRuntimeConfig(core.DefaultRuntimeConfig())
+
+	require.NoError(t, core.SetRuntimeConfig(core.RuntimeConfig{BufferSize: 3, BufferPolicy: core.BUFFER_POLICY_DROP_NEWEST}))
+
+	p := bufferTestOperator(t, core.TypeDef{Type: "number"}).Main().In()
+	a.Equal(core.BufferDef{Size: 3, Policy: core.BUFFER_POLICY_DROP_NEWEST}, p.Buffer())
+	a.Equal(3, p.Metrics().BufferCapacity)
+
+	require.NoError(t, p.SetBuffer(core.BufferDef{Policy: core.BUFFER_POLICY_SPILL}))
+	a.Equal(core.BufferDef{Size: 3, Policy: core.BUFFER_POLICY_SPILL}, p.Buffer())
+}
+
+func TestRuntimeConfig__Invalid(t *testing.T) {
+	a := assertions.New(t)
+	a.Error(core.SetRuntimeConfig(core.RuntimeConfig{BufferSize: 0}))
+	a.Error(core.SetRuntimeConfig(core.RuntimeConfig{BufferSize: 1, BufferPolicy: "unbounded"}))
+	a.Equal(core.DefaultRuntimeConfig(), core.CurrentRuntimeConfig())
+}
+
+// Blueprint.Validate with buffers (1 test)
+
+func TestBlueprint_Validate__Buffers(t *testing.T) {
+	a := assertions.New(t)
+	bp := core.Blueprint{
+		Id:          uuid.New(),
+		Connections: map[string][]string{"(": {"(a"}},
+		Buffers:     map[string]*core.BufferDef{"(a": {Size: 10, Policy: core.BUFFER_POLICY_SPILL}},
+	}
+	a.NoError(bp.Validate())
+
+	bp.Buffers["(b"] = &core.BufferDef{}
+	a.Error(bp.Validate())
+
+	delete(bp.Buffers, "(b")
+	bp.Buffers["(a"].Policy = "unbounded"
+	a.Error(bp.Validate())
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
+)
+
+func constrainedDef(t *testing.T, constraints string) *core.Blueprint {
+	def, err := core.ParseJSONOperatorDef(`{"id": "d1e020d6-4414-42e0-a5c5-dd6fda91754e", "meta": {"name": "opName"}, "generics": ` + constraints + `, "services": {"main": {"in": {"type": "generic", "generic": "g1"}, "out": {"type": "number"}}}}`)
+	require.NoError(t, err)
+	return &def
+}
+
+// Generic constraints (5 tests)
+
+func TestGenericConstraint__Validate(t *testing.T) {
+	a := assertions.New(t)
+	a.NoError(constrainedDef(t, `{"g1": {"types": ["primitive"]}}`).Validate())
+	a.NoError(constrainedDef(t, `{"g1": {"entries": {"id": {}}}}`).Validate())
+	a.Error(constrainedDef(t, `{"g1": {"types": ["numeric"]}}`).Validate())
+	a.Error(constrainedDef(t, `{"g1": {"types": ["stream"], "entries": {"id": {}}}}`).Validate())
+	a.Error(constrainedDef(t, `{"g1": {"stream": {"types": ["generic"]}}}`).Validate())
+	a.Error(constrainedDef(t, `{"g1": null}`).Validate())
+}
+
+func TestGenericConstraint__Types(t *testing.T) {
+	a := assertions.New(t)
+	c := &core.GenericConstraint{Types: []string{"number", "decimal"}}
+	a.NoError(c.Check(core.TypeDef{Type: "number"}))
+	a.NoError(c.Check(core.TypeDef{Type: "decimal"}))
+	a.EqualError(c.Check(core.TypeDef{Type: "string"}), "must be of type number or decimal, not string")
+
+	c = &core.GenericConstraint{Types: []string{"primitive"}}
+	a.NoError(c.Check(core.TypeDef{Type: "boolean"}))
+	a.NoError(c.Check(core.TypeDef{Type: "enum", Enum: []string{"a"}}))
+	a.Error(c.Check(core.TypeDef{Type: "map"}))
+
+	// Unspecified generics are left to GenericsSpecified
+	a.NoError(c.Check(core.TypeDef{Type: "generic", Generic: "g2"}))
+}
+
+func TestGenericConstraint__Entries(t *testing.T) {
+	a := assertions.New(t)
+	c := &core.GenericConstraint{Entries: map[string]*core.GenericConstraint{
+		"id":   {Types: []string{"string", "number"}},
+		"name": nil,
+	}}
+	a.NoError(c.Check(core.TypeDef{Type: "map", Map: core.TypeDefMap{
+		"id":    {Type: "number"},
+		"name":  {Type: "string"},
+		"other": {Type: "boolean"},
+	}}))
+	a.EqualError(c.Check(core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "number"}}), "must be a map, not stream")
+	a.EqualError(c.Check(core.TypeDef{Type: "map", Map: core.TypeDefMap{"id": {Type: "number"}}}), `must contain entry "name"`)
+	a.EqualError(c.Check(core.TypeDef{Type: "map", Map: core.TypeDefMap{"id": {Type: "boolean"}, "name": {Type: "string"}}}), `entry "id" must be of type string or number, not boolean`)
+}
+
+func TestGenericConstraint__Stream(t *testing.T) {
+	a := assertions.New(t)
+	c := &core.GenericConstraint{Stream: &core.GenericConstraint{Types: []string{"number"}}}
+	a.NoError(c.Check(core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "number"}}))
+	a.EqualError(c.Check(core.TypeDef{Type: "number"}), "must be a stream, not number")
+	a.EqualError(c.Check(core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "string"}}), "stream must be of type number, not string")
+}
+
+func TestGenericConstraint__SpecifyGenericPorts(t *testing.T) {
+	a := assertions.New(t)
+	def := constrainedDef(t, `{"g1": {"types": ["number", "decimal"]}}`)
+	require.NoError(t, def.Validate())
+
+	a.EqualError(def.SpecifyGenericPorts(map[string]*core.TypeDef{"g1": {Type: "string"}}), `operator opName: generic "g1" must be of type number or decimal, not string`)
+	a.Equal("generic", def.ServiceDefs[core.MAIN_SERVICE].In.Type)
+
+	a.NoError(def.SpecifyGenericPorts(map[string]*core.TypeDef{"g1": {Type: "decimal"}}))
+	a.Equal("decimal", def.ServiceDefs[core.MAIN_SERVICE].In.Type)
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
+)
+
+// debugTestOperator returns a running operator doubling numbers with a debugger attached
+func debugTestOperator(t *testing.T) (*core.Operator, *core.Debugger) {
+	o, err := core.NewOperator("double", func(op *core.Operator) {
+		in := op.Main().In()
+		out := op.Main().Out()
+		for !op.CheckStop() {
+			i, _ := in.PullFloat64()
+			out.Push(i * 2)
+		}
+	}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: core.TypeDef{Type: "number"}, Out: core.TypeDef{Type: "number"}}}})
+	require.NoError(t, err)
+
+	d := core.NewDebugger()
+	o.SetDebugger(d)
+	o.Main().Out().Bufferize()
+	o.Start()
+	t.Cleanup(func() { o.HaltTimeout(time.Second) })
+	return o, d
+}
+
+func waitPaused(t *testing.T, d *core.Debugger) core.Pause {
+	require.Eventually(t, func() bool { _, ok := d.Paused(); return ok }, time.Second, time.Millisecond)
+	pause, _ := d.Paused()
+	return pause
+}
+
+// Debugger (6 tests)
+
+func TestDebugger__BreakpointEditContinue(t *testing.T) {
+	a := assertions.New(t)
+	o, d := debugTestOperator(t)
+
+	bp, err := d.SetBreakpoint(")", "")
+	require.NoError(t, err)
+
+	paused := make(chan core.Pause, 1)
+	d.OnPause(func(p core.Pause) { paused <- p })
+
+	o.Main().In().Push(1.0)
+	pause := waitPaused(t, d)
+	a.Equal(bp.Id, pause.Breakpoint)
+	a.Equal("double)", pause.Port)
+	a.Equal(2.0, pause.Item)
+	a.Equal(pause.Item, (<-paused).Item)
+
+	a.Error(d.SetItem("two"))
+	a.NoError(d.SetItem(20.0))
+	a.NoError(d.Continue())
+	a.Equal(20.0, o.Main().Out().Pull())
+
+	a.Error(d.Continue())
+}
+
+func TestDebugger__Condition(t *testing.T) {
+	a := assertions.New(t)
+	o, d := debugTestOperator(t)
+
+	_, err := d.SetBreakpoint(")", "item > 5")
+	require.NoError(t, err)
+
+	o.Main().In().Push(1.0)
+	a.Equal(2.0, o.Main().Out().Pull())
+
+	o.Main().In().Push(3.0)
+	a.Equal(6.0, waitPaused(t, d).Item)
+	a.NoError(d.Continue())
+	a.Equal(6.0, o.Main().Out().Pull())
+}
+
+func TestDebugger__Step(t *testing.T) {
+	a := assertions.New(t)
+	o, d := debugTestOperator(t)
+
+	_, err := d.SetBreakpoint("(", "")
+	require.NoError(t, err)
+
+	go o.Main().In().Push(1.0)
+	a.Equal("(double", waitPaused(t, d).Port)
+
+	// The item goes on to the out port, where stepping pauses it again
+	d.Step()
+	a.Equal("double)", waitPaused(t, d).Port)
+	a.NoError(d.Continue())
+	a.Equal(2.0, o.Main().Out().Pull())
+}
+
+func TestDebugger__Breakpoints(t *testing.T) {
+	a := assertions.New(t)
+	o, d := debugTestOperator(t)
+
+	_, err := d.SetBreakpoint("unknown)", "")
+	a.Error(err)
+	_, err = d.SetBreakpoint(")", "item >")
+	a.Error(err)
+
+	bp1, err := d.SetBreakpoint("(", "")
+	require.NoError(t, err)
+	bp2, err := d.SetBreakpoint(")", "item == 4")
+	require.NoError(t, err)
+	a.Equal([]core.Breakpoint{{Id: bp1.Id, Port: "("}, {Id: bp2.Id, Port: ")", Condition: "item == 4"}}, d.Breakpoints())
+
+	a.NoError(d.RemoveBreakpoint(bp1.Id))
+	a.Error(d.RemoveBreakpoint(bp1.Id))
+
+	o.Main().In().Push(2.0)
+	waitPaused(t, d)
+	a.NoError(d.RemoveBreakpoint(bp2.Id))
+	a.NoError(d.Continue())
+	a.Equal(4.0, o.Main().Out().Pull())
+}
+
+func TestDebugger__StopWhilePaused(t *testing.T) {
+	a := assertions.New(t)
+	o, d := debugTestOperator(t)
+
+	_, err := d.SetBreakpoint(")", "")
+	require.NoError(t, err)
+
+	o.Main().In().Push(1.0)
+	waitPaused(t, d)
+
+	a.NoError(o.HaltTimeout(time.Second))
+	_, ok := d.Paused()
+	a.False(ok)
+}
+
+func TestDebugger__StopWhilePaused__PushFails(t *testing.T) {
+	a := assertions.New(t)
+	o, d := debugTestOperator(t)
+
+	_, err := d.SetBreakpoint("(", "")
+	require.NoError(t, err)
+
+	pushed := make(chan error, 1)
+	go func() { pushed <- o.Main().In().PushContext(context.Background(), 1.0) }()
+	waitPaused(t, d)
+
+	a.NoError(o.HaltTimeout(time.Second))
+	a.EqualError(<-pushed, "operator stopped")
+}
@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/tests/assertions"
@@ -319,6 +321,213 @@ func TestTypeDef_GenericsSpecified__MapGenerics(t *testing.T) {
 	a.NoError(pd.GenericsSpecified())
 }
 
+func TestTypeDef_VerifyData__Nullable(t *testing.T) {
+	a := assertions.New(t)
+	a.NoError(core.TypeDef{Type: "number", Nullable: true}.VerifyData(nil))
+	a.NoError(core.TypeDef{Type: "number", Nullable: true}.VerifyData(1.0))
+	a.NoError(core.TypeDef{Type: "primitive"}.VerifyData(nil))
+	a.Error(core.TypeDef{Type: "binary"}.VerifyData(nil))
+	a.NoError(core.TypeDef{Type: "binary", Nullable: true}.VerifyData(nil))
+
+	// Null is still accepted where it was before nullable types existed
+	a.NoError(core.TypeDef{Type: "number"}.VerifyData(nil))
+	a.NoError(core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "string"}}.VerifyData([]interface{}{"a", nil}))
+}
+
+func TestTypeDef_VerifyNulls(t *testing.T) {
+	a := assertions.New(t)
+	a.Error(core.TypeDef{Type: "number"}.VerifyNulls(nil))
+	a.NoError(core.TypeDef{Type: "number", Nullable: true}.VerifyNulls(nil))
+	a.NoError(core.TypeDef{Type: "primitive"}.VerifyNulls(nil))
+	a.Error(core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "string"}}.VerifyNulls([]interface{}{"a", nil}))
+	a.NoError(core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "string", Nullable: true}}.VerifyNulls([]interface{}{"a", nil}))
+
+	pd := core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{"a": {Type: "number"}}}
+	a.EqualError(pd.VerifyNulls(map[string]interface{}{"a": nil}), "a: expected *number*, got null")
+	a.NoError(pd.VerifyNulls(map[string]interface{}{"a": 1.0}))
+}
+
+func TestTypeDef_VerifyData__Optional(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{
+		"a": {Type: "number"},
+		"b": {Type: "string", Optional: true},
+	}}
+	a.NoError(pd.VerifyData(map[string]interface{}{"a": 1.0, "b": "x"}))
+	a.NoError(pd.VerifyData(map[string]interface{}{"a": 1.0}))
+	a.Error(pd.VerifyData(map[string]interface{}{"b": "x"}))
+	a.Error(pd.VerifyData(map[string]interface{}{"a": 1.0, "b": 1.0}))
+	a.NoError(pd.Map.VerifyData(map[string]interface{}{"a": 1.0}))
+}
+
+func TestTypeDef_Equals__Modifiers(t *testing.T) {
+	a := assertions.New(t)
+	a.True(core.TypeDef{Type: "number", Nullable: true}.Equals(core.TypeDef{Type: "number", Nullable: true}))
+	a.False(core.TypeDef{Type: "number", Nullable: true}.Equals(core.TypeDef{Type: "number"}))
+	a.False(core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{"a": {Type: "number", Optional: true}}}.Equals(
+		core.TypeDef{Type: "map", Map: map[string]*core.TypeDef{"a": {Type: "number"}}}))
+}
+
+func TestTypeDef_String(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.ParseTypeDef(`{"type":"map","map":{"b":{"type":"stream","stream":{"type":"string"},"optional":true},"a":{"type":"number","nullable":true}}}`)
+	a.Equal("map{a: number?, b?: stream[string]}", pd.String())
+}
+
+func TestTypeDef_SpecifyGenericPorts__KeepsModifiers(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.TypeDef{Type: "generic", Generic: "t1", Nullable: true}
+	require.NoError(t, pd.Validate())
+	a.NoError(pd.SpecifyGenerics(map[string]*core.TypeDef{
+		"t1": {Type: "number"},
+	}))
+	a.Equal(core.TypeDef{Type: "number", Nullable: true}, pd)
+}
+
+func TestParseYAMLOperatorDef__NullableOptional(t *testing.T) {
+	a := assertions.New(t)
+	op, err := core.ParseYAMLOperatorDef(`
+id: 8b62495a-e482-4a3e-8020-0ab8a350ad2d
+services:
+  main:
+    in:
+      type: map
+      map:
+        a:
+          type: number
+          nullable: true
+        b:
+          type: string
+          optional: true
+    out:
+      type: trigger
+`)
+	require.NoError(t, err)
+	in := op.ServiceDefs[core.MAIN_SERVICE].In
+	a.True(in.Map["a"].Nullable)
+	a.True(in.Map["b"].Optional)
+
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	a.Equal(in, core.ParseTypeDef(string(data)))
+}
+
+func TestBlueprint_SpecifyOperator__UnsetOptionalProperty(t *testing.T) {
+	a := assertions.New(t)
+	op, err := core.ParseYAMLOperatorDef(`
+id: 2d1a6f0e-9b7c-4b64-9a0e-5f0c3d9a1b77
+services:
+  main:
+    in:
+      type: trigger
+    out:
+      type: trigger
+properties:
+  broker:
+    type: string
+  certificate:
+    type: string
+    optional: true
+`)
+	require.NoError(t, err)
+	unset := op.Copy(true)
+	a.NoError(unset.SpecifyOperator(core.Generics{}, core.Properties{"broker": "tcp://x"}))
+	set := op.Copy(true)
+	a.NoError(set.SpecifyOperator(core.Generics{}, core.Properties{"broker": "tcp://x", "certificate": "x"}))
+	missing := op.Copy(true)
+	a.Error(missing.SpecifyOperator(core.Generics{}, core.Properties{"certificate": "x"}))
+}
+
+func validateTypeDef(def string) error {
+	td := core.ParseTypeDef(def)
+	return td.Validate()
+}
+
+func TestTypeDef_Validate__Enum(t *testing.T) {
+	a := assertions.New(t)
+	a.NoError(validateTypeDef(`{"type":"enum","enum":["red","green"]}`))
+	a.Error(validateTypeDef(`{"type":"enum"}`))
+	a.Error(validateTypeDef(`{"type":"enum","enum":["red","red"]}`))
+}
+
+func TestTypeDef_Validate__Union(t *testing.T) {
+	a := assertions.New(t)
+	a.NoError(validateTypeDef(`{"type":"union","union":{"ok":{"type":"number"},"error":{"type":"map","map":{"message":{"type":"string"}}}}}`))
+	a.Error(validateTypeDef(`{"type":"union"}`))
+	a.Error(validateTypeDef(`{"type":"union","union":{"ok":{"type":"nope"}}}`))
+}
+
+func TestTypeDef_VerifyData__Enum(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.TypeDef{Type: "enum", Enum: []string{"red", "green"}}
+	a.NoError(pd.VerifyData("red"))
+	a.Error(pd.VerifyData("blue"))
+	a.Error(pd.VerifyData(1.0))
+}
+
+func TestTypeDef_VerifyData__Union(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.TypeDef{Type: "union", Union: map[string]*core.TypeDef{
+		"ok":    {Type: "number"},
+		"error": {Type: "map", Map: map[string]*core.TypeDef{"message": {Type: "string"}}},
+	}}
+	a.NoError(pd.VerifyData(core.UnionValue("ok", 1.0)))
+	a.NoError(pd.VerifyData(core.UnionValue("error", map[string]interface{}{"message": "failed"})))
+	a.Error(pd.VerifyData(core.UnionValue("ok", "one")))
+	a.Error(pd.VerifyData(core.UnionValue("warning", 1.0)))
+	a.Error(pd.VerifyData(map[string]interface{}{"ok": 1.0, "error": map[string]interface{}{"message": "failed"}}))
+	a.Error(pd.VerifyData(1.0))
+}
+
+func TestTypeDef_Equals__EnumUnion(t *testing.T) {
+	a := assertions.New(t)
+	a.True(core.TypeDef{Type: "enum", Enum: []string{"a", "b"}}.Equals(core.TypeDef{Type: "enum", Enum: []string{"b", "a"}}))
+	a.False(core.TypeDef{Type: "enum", Enum: []string{"a", "b"}}.Equals(core.TypeDef{Type: "enum", Enum: []string{"a"}}))
+	a.True(core.TypeDef{Type: "union", Union: map[string]*core.TypeDef{"a": {Type: "number"}}}.Equals(core.TypeDef{Type: "union", Union: map[string]*core.TypeDef{"a": {Type: "number"}}}))
+	a.False(core.TypeDef{Type: "union", Union: map[string]*core.TypeDef{"a": {Type: "number"}}}.Equals(core.TypeDef{Type: "union", Union: map[string]*core.TypeDef{"a": {Type: "string"}}}))
+}
+
+func TestTypeDef_SpecifyGenericPorts__Union(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.TypeDef{Type: "union", Union: map[string]*core.TypeDef{"a": {Type: "generic", Generic: "t1"}}}
+	require.NoError(t, pd.Validate())
+	a.Error(pd.GenericsSpecified())
+	a.NoError(pd.SpecifyGenerics(map[string]*core.TypeDef{
+		"t1": {Type: "number"},
+	}))
+	a.Equal("union{a: number}", pd.String())
+	a.NoError(pd.GenericsSpecified())
+}
+
+func TestTypeDef_VerifyData__DateTime(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.TypeDef{Type: "datetime"}
+	a.NoError(pd.VerifyData(time.Now()))
+	a.NoError(pd.VerifyData("2021-03-04T05:06:07+01:00"))
+	a.Error(pd.VerifyData("yesterday"))
+	a.Error(pd.VerifyData(1.0))
+	a.Error(core.TypeDef{Type: "string"}.VerifyData(time.Now()))
+}
+
+func TestTypeDef_VerifyData__Duration(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.TypeDef{Type: "duration"}
+	a.NoError(pd.VerifyData(time.Second))
+	a.NoError(pd.VerifyData("1h30m"))
+	a.Error(pd.VerifyData("1 hour"))
+	a.Error(pd.VerifyData(1.0))
+}
+
+func TestTypeDef_VerifyData__Decimal(t *testing.T) {
+	a := assertions.New(t)
+	pd := core.TypeDef{Type: "decimal"}
+	a.NoError(pd.VerifyData(core.NewDecimal(12.3)))
+	a.NoError(pd.VerifyData("12.30"))
+	a.NoError(pd.VerifyData(12.3))
+	a.Error(pd.VerifyData("12,30"))
+	a.Error(core.TypeDef{Type: "number"}.VerifyData(core.NewDecimal(12.3)))
+}
+
 // PROPERTY PARSING
 
 func makeProps() (map[string]*core.TypeDef, core.Properties) {
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
+)
+
+const diffBaseYAML = `
+id: 6a5ce5d6-1c3a-4b93-8f0c-0d2c6de9a9a1
+meta:
+  name: base
+services:
+  main:
+    in:
+      type: number
+    out:
+      type: number
+operators:
+  add:
+    operator: 37ccdc28-67b0-4bb1-8591-4e0e813e3ec1
+    properties:
+      expression: a+1
+      variables: [a]
+    geometry:
+      position:
+        x: 10
+        y: 10
+  mul:
+    operator: 37ccdc28-67b0-4bb1-8591-4e0e813e3ec1
+    properties:
+      expression: a*2
+      variables: [a]
+connections:
+  (:
+  - (add
+  add):
+  - (mul
+  mul):
+  - )
+`
+
+func parseDiffBlueprint(t *testing.T, yaml string) core.Blueprint {
+	bp, err := core.ParseYAMLOperatorDef(yaml)
+	require.NoError(t, err)
+	return bp
+}
+
+func diffBlueprintVariant(t *testing.T, change func(bp *core.Blueprint)) core.Blueprint {
+	bp := parseDiffBlueprint(t, diffBaseYAML)
+	change(&bp)
+	return bp
+}
+
+func instanceByName(bp core.Blueprint, name string) *core.InstanceDef {
+	for _, ins := range bp.InstanceDefs {
+		if ins.Name == name {
+			return ins
+		}
+	}
+	return nil
+}
+
+func TestDiffBlueprints__Unchanged(t *testing.T) {
+	a := assertions.New(t)
+	base := parseDiffBlueprint(t, diffBaseYAML)
+
+	changes, err := core.DiffBlueprints(base, parseDiffBlueprint(t, diffBaseYAML))
+	require.NoError(t, err)
+	a.Empty(changes)
+}
+
+func TestDiffBlueprints__IgnoresGeometry(t *testing.T) {
+	a := assertions.New(t)
+	base := parseDiffBlueprint(t, diffBaseYAML)
+	moved := diffBlueprintVariant(t, func(bp *core.Blueprint) {
+		instanceByName(*bp, "add").Geometry.Position.X = 200
+	})
+
+	changes, err := core.DiffBlueprints(base, moved)
+	require.NoError(t, err)
+	a.Empty(changes)
+}
+
+func TestDiffBlueprints__Changes(t *testing.T) {
+	a := assertions.New(t)
+	base := parseDiffBlueprint(t, diffBaseYAML)
+	changed := diffBlueprintVariant(t, func(bp *core.Blueprint) {
+		instanceByName(*bp, "add").Properties["expression"] = "a+2"
+		bp.InstanceDefs = append(bp.InstanceDefs, &core.InstanceDef{Name: "sub", Operator: instanceByName(*bp, "add").Operator})
+		bp.Connections["add)"] = []string{"(sub"}
+		bp.Connections["sub)"] = []string{"(mul"}
+		bp.Meta.Name = "changed"
+	})
+
+	changes, err := core.DiffBlueprints(base, changed)
+	require.NoError(t, err)
+
+	paths := make(map[string]core.ChangeKind)
+	for _, c := range changes {
+		paths[c.Path] = c.Kind
+	}
+	a.Equal(map[string]core.ChangeKind{
+		"meta/name":                           core.CHANGE_MODIFIED,
+		"operators/add/properties/expression": core.CHANGE_MODIFIED,
+		"operators/sub":                       core.CHANGE_ADDED,
+		"connections/add) -> (mul":            core.CHANGE_REMOVED,
+		"connections/add) -> (sub":            core.CHANGE_ADDED,
+		"connections/sub) -> (mul":            core.CHANGE_ADDED,
+	}, paths)
+}
+
+func TestMergeBlueprints__Clean(t *testing.T) {
+	a := assertions.New(t)
+	base := parseDiffBlueprint(t, diffBaseYAML)
+	ours := diffBlueprintVariant(t, func(bp *core.Blueprint) {
+		instanceByName(*bp, "add").Properties["expression"] = "a+2"
+	})
+	theirs := diffBlueprintVariant(t, func(bp *core.Blueprint) {
+		instanceByName(*bp, "mul").Properties["expression"] = "a*3"
+		instanceByName(*bp, "add").Geometry.Position.Y = 50
+	})
+
+	merged, conflicts, err := core.MergeBlueprints(base, ours, theirs)
+	require.NoError(t, err)
+	a.Empty(conflicts)
+	a.Equal("a+2", instanceByName(merged, "add").Properties["expression"])
+	a.Equal("a*3", instanceByName(merged, "mul").Properties["expression"])
+	a.Equal(float32(50), instanceByName(merged, "add").Geometry.Position.Y)
+	a.Equal(base.Connections, merged.Connections)
+	a.NoError(merged.Validate())
+}
+
+func TestMergeBlueprints__Conflict(t *testing.T) {
+	a := assertions.New(t)
+	base := parseDiffBlueprint(t, diffBaseYAML)
+	ours := diffBlueprintVariant(t, func(bp *core.Blueprint) {
+		instanceByName(*bp, "add").Properties["expression"] = "a+2"
+	})
+	theirs := diffBlueprintVariant(t, func(bp *core.Blueprint) {
+		instanceByName(*bp, "add").Properties["expression"] = "a+3"
+	})
+
+	merged, conflicts, err := core.MergeBlueprints(base, ours, theirs)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	a.Equal("operators/add/properties/expression", conflicts[0].Path)
+	a.Equal("a+3", conflicts[0].Theirs)
+	a.Equal("a+2", instanceByName(merged, "add").Properties["expression"])
+}
+
+func TestMergeBlueprints__RemovedInstance(t *testing.T) {
+	a := assertions.New(t)
+	base := parseDiffBlueprint(t, diffBaseYAML)
+	ours := diffBlueprintVariant(t, func(bp *core.Blueprint) {
+		bp.InstanceDefs = core.InstanceDefList{instanceByName(*bp, "add")}
+		delete(bp.Connections, "mul)")
+		bp.Connections["add)"] = []string{")"}
+	})
+	theirs := diffBlueprintVariant(t, func(bp *core.Blueprint) {
+		instanceByName(*bp, "mul").Properties["expression"] = "a*3"
+	})
+
+	merged, conflicts, err := core.MergeBlueprints(base, ours, theirs)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	a.Equal("operators/mul/properties/expression", conflicts[0].Path)
+	a.Nil(instanceByName(merged, "mul"))
+	a.Equal(map[string][]string{"(": {"(add"}, "add)": {")"}}, merged.Connections)
+}
@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+)
+
+var errTest = errors.New("cannot process item")
+
+// failingOperator creates a parent operator with the given name and a child which fails for negative numbers and
+// passes all other numbers through. If errSrv is true, the parent defines an error service.
+func failingOperator(name string, policy core.ErrorPolicy, errSrv bool) (*core.Operator, *core.Operator) {
+	defPort := core.ParseTypeDef(`{"type":"number"}`)
+	bp := core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: defPort, Out: defPort}}, ErrorPolicy: policy}
+	if errSrv {
+		bp.ServiceDefs[core.ERROR_SERVICE] = &core.ServiceDef{In: core.TypeDef{Type: "trigger"}, Out: core.ERROR_TYPEDEF}
+	}
+	oParent, _ := core.NewOperator(name, nil, nil, nil, nil, bp)
+	oChild, _ := core.NewOperator("child", func(op *core.Operator) {
+		in := op.Main().In()
+		out := op.Main().Out()
+		for !op.CheckStop() {
+			i := in.Pull()
+			if core.IsMarker(i) {
+				out.Push(i)
+				continue
+			}
+			if i.(float64) < 0 {
+				if !op.Fail(errTest) {
+					return
+				}
+				out.Push(nil)
+				continue
+			}
+			out.Push(i)
+		}
+	}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: defPort, Out: defPort}}})
+	oChild.SetParent(oParent)
+
+	oParent.Main().In().Connect(oChild.Main().In())
+	oChild.Main().Out().Connect(oParent.Main().Out())
+	oParent.Main().Out().Bufferize()
+
+	return oParent, oChild
+}
+
+func TestOperator_Fail__FailFast(t *testing.T) {
+	a := assertions.New(t)
+	o, oChild := failingOperator("", core.ERROR_POLICY_FAIL, false)
+
+	var reported *core.SlangError
+	o.SetErrorHandler(func(err *core.SlangError) {
+		reported = err
+	})
+
+	o.Start()
+	o.Main().In().Push(1.0)
+	a.Equal(1.0, o.Main().Out().Pull())
+	o.Main().In().Push(-1.0)
+
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+	a.True(o.Stopped())
+	a.True(oChild.Stopped())
+	a.Equal(core.ERROR_POLICY_FAIL, oChild.ErrorPolicy())
+
+	a.Error(o.Err())
+	a.True(errors.Is(o.Err(), errTest))
+	a.Equal("child: cannot process item", o.Err().Error())
+	a.Equal(o.Err(), reported)
+}
+
+func TestOperator_Fail__Skip(t *testing.T) {
+	a := assertions.New(t)
+	o, oChild := failingOperator("", core.ERROR_POLICY_SKIP, false)
+
+	var reported []*core.SlangError
+	o.SetErrorHandler(func(err *core.SlangError) {
+		reported = append(reported, err)
+	})
+
+	o.Start()
+	o.Main().In().Push(-1.0)
+	o.Main().In().Push(2.0)
+	a.Nil(o.Main().Out().Pull())
+	a.Equal(2.0, o.Main().Out().Pull())
+
+	a.False(oChild.Stopped())
+	a.NoError(o.Err())
+	a.Len(reported, 1)
+	a.Equal([]string{"child"}, reported[0].Operator)
+
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+}
+
+func TestOperator_Fail__Route(t *testing.T) {
+	a := assertions.New(t)
+	o, oChild := failingOperator("", core.ERROR_POLICY_ROUTE, true)
+	o.Service(core.ERROR_SERVICE).Out().Bufferize()
+
+	o.Start()
+	o.Main().In().Push(-1.0)
+	o.Main().In().Push(2.0)
+	a.Nil(o.Main().Out().Pull())
+	a.Equal(2.0, o.Main().Out().Pull())
+	a.Equal(map[string]interface{}{"operator": "child", "error": "cannot process item"}, o.Service(core.ERROR_SERVICE).Out().Pull())
+
+	a.False(oChild.Stopped())
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+}
+
+func TestOperator_Fail__DefaultSkip(t *testing.T) {
+	a := assertions.New(t)
+	o, oChild := failingOperator("", "", false)
+
+	a.Equal(core.ERROR_POLICY_SKIP, oChild.ErrorPolicy())
+	a.Equal(core.ERROR_POLICY_SKIP, o.ErrorPolicy())
+}
+
+func TestOperator_Fail__PolicyInherited(t *testing.T) {
+	a := assertions.New(t)
+	o, oChild := failingOperator("", core.ERROR_POLICY_SKIP, false)
+
+	a.Equal(core.ERROR_POLICY_SKIP, oChild.ErrorPolicy())
+	oChild.SetErrorPolicy(core.ERROR_POLICY_FAIL)
+	a.Equal(core.ERROR_POLICY_FAIL, oChild.ErrorPolicy())
+	a.Equal(core.ERROR_POLICY_SKIP, o.ErrorPolicy())
+}
+
+func TestOperator_Fail__CompiledNested(t *testing.T) {
+	a := assertions.New(t)
+	defPort := core.ParseTypeDef(`{"type":"number"}`)
+	oNested, oChild := failingOperator("nested", core.ERROR_POLICY_ROUTE, true)
+	o, _ := core.NewOperator("", nil, nil, nil, nil, core.Blueprint{
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE:  {In: defPort, Out: defPort},
+			core.ERROR_SERVICE: {In: core.TypeDef{Type: "trigger"}, Out: core.ERROR_TYPEDEF},
+		},
+		ErrorPolicy: core.ERROR_POLICY_FAIL,
+	})
+	oNested.SetParent(o)
+
+	o.Main().In().Connect(oNested.Main().In())
+	oNested.Main().Out().Connect(o.Main().Out())
+	oNested.Service(core.ERROR_SERVICE).Out().Connect(o.Service(core.ERROR_SERVICE).Out())
+	o.Main().Out().Bufferize()
+	o.Service(core.ERROR_SERVICE).Out().Bufferize()
+
+	o.Compile()
+	a.NoError(o.CorrectlyCompiled())
+	a.Equal(o, oChild.Parent())
+	a.Equal(core.ERROR_POLICY_ROUTE, oChild.ErrorPolicy())
+
+	o.Start()
+	o.Main().In().Push(-1.0)
+	o.Main().In().Push(2.0)
+	a.Nil(o.Main().Out().Pull())
+	a.Equal(2.0, o.Main().Out().Pull())
+	a.Equal(map[string]interface{}{"operator": "nested#child", "error": "cannot process item"}, o.Service(core.ERROR_SERVICE).Out().Pull())
+
+	a.False(oChild.Stopped())
+	a.NoError(o.Err())
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+}
+
+func TestBlueprint_Validate__ErrorPolicy(t *testing.T) {
+	a := assertions.New(t)
+	defPort := core.ParseTypeDef(`{"type":"number"}`)
+	bp := core.Blueprint{
+		Id:          uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: defPort, Out: defPort}},
+	}
+
+	a.NoError(bp.Validate())
+
+	bp.ErrorPolicy = "ignore"
+	a.Error(bp.Validate())
+
+	bp.ErrorPolicy = core.ERROR_POLICY_ROUTE
+	a.NoError(bp.Validate())
+
+	bp.ServiceDefs[core.ERROR_SERVICE] = &core.ServiceDef{In: core.TypeDef{Type: "trigger"}, Out: core.TypeDef{Type: "string"}}
+	a.Error(bp.Validate())
+
+	bp.ServiceDefs[core.ERROR_SERVICE] = &core.ServiceDef{In: core.TypeDef{Type: "trigger"}, Out: core.ERROR_TYPEDEF}
+	a.NoError(bp.Validate())
+}
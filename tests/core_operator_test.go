@@ -1,10 +1,13 @@
 package tests
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/Bitspark/slang/pkg/core"
 	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOperator_NewOperator__CorrectRelation(t *testing.T) {
@@ -122,3 +125,70 @@ func TestOperator_Compile__NestedChildren(t *testing.T) {
 	a.False(op3.Main().In().Connected(op6.Main().In()))
 	a.False(op6.Main().Out().Connected(op3.Main().Out()))
 }
+
+func TestOperator_Halt__BlockedInPull(t *testing.T) {
+	a := assertions.New(t)
+	defPort := core.ParseTypeDef(`{"type":"number"}`)
+	o, _ := core.NewOperator("", func(op *core.Operator) {
+		for !op.CheckStop() {
+			op.Main().Out().Push(op.Main().In().Pull())
+		}
+	}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: defPort, Out: defPort}}})
+
+	o.Main().Out().Bufferize()
+	o.Start()
+	o.Main().In().Push(1.0)
+	a.Equal(1.0, o.Main().Out().Pull())
+
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+	a.True(o.Stopped())
+	a.Equal(int64(0), o.Metrics().Goroutines)
+}
+
+func TestOperator_Halt__BlockedInPush(t *testing.T) {
+	a := assertions.New(t)
+	defPort := core.ParseTypeDef(`{"type":"number"}`)
+	o, _ := core.NewOperator("", func(op *core.Operator) {
+		for !op.CheckStop() {
+			op.Main().Out().Push(1.0)
+		}
+	}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: defPort, Out: defPort}}})
+
+	o.Main().Out().Bufferize()
+	require.NoError(t, o.Main().Out().SetBuffer(core.BufferDef{Size: 1}))
+	o.Start()
+	a.Equal(1.0, o.Main().Out().Pull())
+
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+	a.EqualError(o.Main().Out().PushContext(context.Background(), 1.0), "operator stopped")
+	a.EqualError(o.Main().In().PushContext(context.Background(), 1.0), "operator stopped")
+}
+
+func TestOperator_StartContext__Cancel(t *testing.T) {
+	a := assertions.New(t)
+	defPort := core.ParseTypeDef(`{"type":"number"}`)
+	oParent, _ := core.NewOperator("", nil, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: defPort, Out: defPort}}})
+	oChild, _ := core.NewOperator("child", func(op *core.Operator) {
+		op.Main().In().Pull()
+	}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: defPort, Out: defPort}}})
+	oChild.SetParent(oParent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	oParent.StartContext(ctx)
+	cancel()
+
+	a.NoError(oParent.HaltTimeout(100 * time.Millisecond))
+	a.True(oChild.Stopped())
+}
+
+func TestPort_PullContext__Cancel(t *testing.T) {
+	a := assertions.New(t)
+	o, _ := core.NewOperator("", func(*core.Operator) {}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: core.TypeDef{Type: "number"}, Out: core.TypeDef{Type: "number"}}}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	i, err := o.Main().In().PullContext(ctx)
+	a.Nil(i)
+	a.Equal(context.DeadlineExceeded, err)
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -425,3 +426,262 @@ func TestPort_Connect__Map__Subport2Primitive(t *testing.T) {
 	a.NoError(err)
 	a.True(p.Map("a").Connected(q), "connection expected")
 }
+
+// Port nullable and optional (3 tests)
+
+func modifierTestOperator(t *testing.T, in string, out string) *core.Operator {
+	o, err := core.NewOperator("", func(*core.Operator) {}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: core.ParseTypeDef(in), Out: core.ParseTypeDef(out)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestPort_Connect__Nullable(t *testing.T) {
+	a := assertions.New(t)
+
+	o := modifierTestOperator(t, `{"type":"number","nullable":true}`, `{"type":"number"}`)
+	a.Error(o.Main().In().Connect(o.Main().Out()))
+
+	o = modifierTestOperator(t, `{"type":"number"}`, `{"type":"number","nullable":true}`)
+	a.NoError(o.Main().In().Connect(o.Main().Out()))
+	a.True(o.Main().Out().Nullable())
+}
+
+func TestPort_Connect__Optional(t *testing.T) {
+	a := assertions.New(t)
+
+	o := modifierTestOperator(t, `{"type":"map","map":{"a":{"type":"number","optional":true}}}`, `{"type":"map","map":{"a":{"type":"number"}}}`)
+	a.Error(o.Main().In().Connect(o.Main().Out()))
+
+	o = modifierTestOperator(t, `{"type":"map","map":{"a":{"type":"number"}}}`, `{"type":"map","map":{"a":{"type":"number","optional":true}}}`)
+	a.NoError(o.Main().In().Connect(o.Main().Out()))
+	a.True(o.Main().Out().Map("a").Optional())
+}
+
+func TestPort_PushPull__MissingOptionalEntry(t *testing.T) {
+	a := assertions.New(t)
+	o := modifierTestOperator(t, `{"type":"map","map":{"a":{"type":"number"},"b":{"type":"string","optional":true},"c":{"type":"map","map":{"d":{"type":"number"}},"optional":true}}}`, `{"type":"trigger"}`)
+	p := o.Main().In()
+
+	p.Push(map[string]interface{}{"a": 1.0})
+	p.Push(map[string]interface{}{"a": 2.0, "b": "x", "c": map[string]interface{}{"d": 3.0}})
+
+	a.Equal(map[string]interface{}{"a": 1.0}, p.Pull())
+	a.Equal(map[string]interface{}{"a": 2.0, "b": "x", "c": map[string]interface{}{"d": 3.0}}, p.Pull())
+
+	a.Equal(`{"type":"map","map":{"a":{"type":"number"},"b":{"type":"string","optional":true},"c":{"type":"map","map":{"d":{"type":"number"}},"optional":true}}}`, mustMarshal(t, p.Define()))
+}
+
+// Port enum and union (3 tests)
+
+func TestPort_Connect__Enum(t *testing.T) {
+	a := assertions.New(t)
+
+	o := modifierTestOperator(t, `{"type":"enum","enum":["a","b"]}`, `{"type":"enum","enum":["a","b","c"]}`)
+	a.NoError(o.Main().In().Connect(o.Main().Out()))
+	a.Equal(core.TYPE_ENUM, o.Main().In().Type())
+
+	o = modifierTestOperator(t, `{"type":"enum","enum":["a","b","c"]}`, `{"type":"enum","enum":["a","b"]}`)
+	a.Error(o.Main().In().Connect(o.Main().Out()))
+
+	o = modifierTestOperator(t, `{"type":"enum","enum":["a"]}`, `{"type":"string"}`)
+	a.NoError(o.Main().In().Connect(o.Main().Out()))
+
+	o = modifierTestOperator(t, `{"type":"string"}`, `{"type":"enum","enum":["a"]}`)
+	a.Error(o.Main().In().Connect(o.Main().Out()))
+}
+
+func TestPort_Connect__Union(t *testing.T) {
+	a := assertions.New(t)
+
+	o := modifierTestOperator(t, `{"type":"union","union":{"a":{"type":"number"}}}`, `{"type":"union","union":{"a":{"type":"number"},"b":{"type":"string"}}}`)
+	a.NoError(o.Main().In().Connect(o.Main().Out()))
+	a.Equal(core.TYPE_UNION, o.Main().In().Type())
+
+	o = modifierTestOperator(t, `{"type":"union","union":{"a":{"type":"number"}}}`, `{"type":"union","union":{"a":{"type":"string"}}}`)
+	a.Error(o.Main().In().Connect(o.Main().Out()))
+
+	o = modifierTestOperator(t, `{"type":"union","union":{"a":{"type":"number"}}}`, `{"type":"primitive"}`)
+	a.NoError(o.Main().In().Connect(o.Main().Out()))
+}
+
+func TestPort_PushPull__Union(t *testing.T) {
+	a := assertions.New(t)
+	def := `{"type":"stream","stream":{"type":"union","union":{"a":{"type":"number"},"b":{"type":"enum","enum":["x","y"]}}}}`
+	o := modifierTestOperator(t, def, `{"type":"trigger"}`)
+	p := o.Main().In()
+
+	items := []interface{}{core.UnionValue("a", 1.0), core.UnionValue("b", "y")}
+	p.Push(items)
+	a.Equal(items, p.Pull())
+	a.True(core.ParseTypeDef(def).Equals(p.Define()))
+}
+
+// Port datetime, duration and decimal (4 tests)
+
+func TestPort_PullTime(t *testing.T) {
+	a := assertions.New(t)
+	o := modifierTestOperator(t, `{"type":"stream","stream":{"type":"datetime"}}`, `{"type":"trigger"}`)
+	p := o.Main().In()
+	a.Equal(core.TYPE_DATETIME, p.Stream().Type())
+
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	p.Push([]interface{}{ts, "2021-03-04T05:06:07Z"})
+
+	p.PullBOS()
+	for i := 0; i < 2; i++ {
+		pulled, item := p.Stream().PullTime()
+		a.Nil(item)
+		a.True(ts.Equal(pulled))
+	}
+	a.True(p.PullEOS())
+}
+
+func TestPort_PullDuration(t *testing.T) {
+	a := assertions.New(t)
+	p := modifierTestOperator(t, `{"type":"duration"}`, `{"type":"trigger"}`).Main().In()
+	a.Equal(core.TYPE_DURATION, p.Type())
+
+	p.Push("1h30m")
+	d, item := p.PullDuration()
+	a.Nil(item)
+	a.Equal(90*time.Minute, d)
+
+	p.Push(2 * time.Second)
+	a.Equal(2*time.Second, p.Pull())
+}
+
+func TestPort_PullDecimal(t *testing.T) {
+	a := assertions.New(t)
+	p := modifierTestOperator(t, `{"type":"decimal"}`, `{"type":"trigger"}`).Main().In()
+	a.Equal(core.TYPE_DECIMAL, p.Type())
+
+	for _, in := range []interface{}{"0.3", 0.3, core.NewDecimal(0.3)} {
+		p.Push(in)
+		d, item := p.PullDecimal()
+		a.Nil(item)
+		a.Equal("0.3", d.String())
+	}
+
+	sum := core.NewDecimal(0.1).Add(core.NewDecimal(0.2))
+	a.True(sum.Equal(core.NewDecimal(0.3)))
+}
+
+func TestPort_Connect__DateTime(t *testing.T) {
+	a := assertions.New(t)
+
+	o := modifierTestOperator(t, `{"type":"datetime"}`, `{"type":"datetime"}`)
+	a.NoError(o.Main().In().Connect(o.Main().Out()))
+	a.Equal("datetime", o.Main().In().Define().Type)
+
+	o = modifierTestOperator(t, `{"type":"datetime"}`, `{"type":"string"}`)
+	a.Error(o.Main().In().Connect(o.Main().Out()))
+
+	o = modifierTestOperator(t, `{"type":"decimal"}`, `{"type":"number"}`)
+	a.Error(o.Main().In().Connect(o.Main().Out()))
+}
+
+// Port.Tap (3 tests)
+
+func TestPort_Tap__Primitive(t *testing.T) {
+	a := assertions.New(t)
+	def := core.ParseTypeDef(`{"type":"number"}`)
+	p, _ := core.NewPort(nil, nil, def, core.DIRECTION_OUT)
+
+	var items []interface{}
+	p.Tap(func(p *core.Port, item interface{}) {
+		items = append(items, item)
+	})
+
+	p.Push(1.0)
+	p.Push(2.0)
+
+	a.Equal([]interface{}{1.0, 2.0}, items)
+}
+
+func TestPort_Tap__Stream_Map__Markers(t *testing.T) {
+	a := assertions.New(t)
+	def := core.ParseTypeDef(`{"type":"stream","stream":{"type":"map","map":{"a":{"type":"number"}}}}`)
+	p, _ := core.NewPort(nil, nil, def, core.DIRECTION_OUT)
+
+	var portNames []string
+	var items []interface{}
+	p.Tap(func(p *core.Port, item interface{}) {
+		portNames = append(portNames, p.Name())
+		items = append(items, item)
+	})
+
+	p.Push([]interface{}{map[string]interface{}{"a": 1.0}})
+
+	a.Equal([]string{")~.a", ")~.a", ")~.a"}, portNames)
+	a.True(core.IsBOS(items[0]))
+	a.Equal(1.0, items[1])
+	a.True(core.IsEOS(items[2]))
+}
+
+func TestPort_Tap__Untap(t *testing.T) {
+	a := assertions.New(t)
+	def := core.ParseTypeDef(`{"type":"map","map":{"a":{"type":"number"},"b":{"type":"string"}}}`)
+	p, _ := core.NewPort(nil, nil, def, core.DIRECTION_OUT)
+
+	count := 0
+	tap := p.Tap(func(p *core.Port, item interface{}) {
+		count++
+	})
+	a.True(p.Map("a").Tapped())
+
+	p.Push(map[string]interface{}{"a": 1.0, "b": "x"})
+	a.Equal(2, count)
+
+	tap.Untap()
+	a.False(p.Map("a").Tapped())
+
+	p.Push(map[string]interface{}{"a": 1.0, "b": "x"})
+	a.Equal(2, count)
+}
+
+// Port.Metrics (2 tests)
+
+func TestPort_Metrics__PushPull(t *testing.T) {
+	a := assertions.New(t)
+	o, _ := core.NewOperator("", func(*core.Operator) {}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: core.TypeDef{Type: "number"}, Out: core.TypeDef{Type: "number"}}}})
+	p := o.Main().In()
+
+	p.Push(1.0)
+	p.Push(2.0)
+
+	m := p.Metrics()
+	a.Equal(uint64(2), m.Pushed)
+	a.Equal(uint64(0), m.Pulled)
+	a.Equal(2, m.Buffered)
+	a.Equal(core.CurrentRuntimeConfig().BufferSize, m.BufferCapacity)
+
+	p.Pull()
+
+	m = p.Metrics()
+	a.Equal(uint64(1), m.Pulled)
+	a.Equal(1, m.Buffered)
+}
+
+func TestPort_Metrics__PullBlocked(t *testing.T) {
+	a := assertions.New(t)
+	o, _ := core.NewOperator("", func(*core.Operator) {}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: core.TypeDef{Type: "number"}, Out: core.TypeDef{Type: "number"}}}})
+	p := o.Main().In()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		p.Push(1.0)
+	}()
+	p.Pull()
+
+	a.True(p.Metrics().PullBlocked >= 10*time.Millisecond)
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
+)
+
+// sumTestOperator returns a started operator summing up the streams pushed into it and adding offset
+func sumTestOperator(t *testing.T, offset float64) *core.Operator {
+	o, err := core.NewOperator("sum", func(op *core.Operator) {
+		in := op.Main().In()
+		out := op.Main().Out()
+		for !op.CheckStop() {
+			sum := offset
+			for _, i := range in.Pull().([]interface{}) {
+				sum += i.(float64)
+			}
+			out.Push(sum)
+		}
+	}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {
+		In:  core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "number"}},
+		Out: core.TypeDef{Type: "number"},
+	}}})
+	require.NoError(t, err)
+	t.Cleanup(func() { o.HaltTimeout(time.Second) })
+	return o
+}
+
+func recordSums(t *testing.T, streams ...[]interface{}) []byte {
+	o := sumTestOperator(t, 0)
+	buf := &bytes.Buffer{}
+	rec, err := core.NewRecorder(o, buf, nil)
+	require.NoError(t, err)
+
+	o.Main().Out().Bufferize()
+	o.Start()
+	for _, s := range streams {
+		o.Main().In().Push(s)
+		o.Main().Out().Pull()
+	}
+	require.NoError(t, rec.Close())
+	return buf.Bytes()
+}
+
+// Recording (4 tests)
+
+func TestRecorder__RecordsItemsAndMarkers(t *testing.T) {
+	a := assertions.New(t)
+	data := recordSums(t, []interface{}{1.0, 2.0})
+
+	var records []core.Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var rec core.Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		a.False(rec.Time.IsZero())
+		records = append(records, rec)
+	}
+
+	require.Len(t, records, 5)
+	a.Equal("~(sum", records[0].Port)
+	a.Equal(map[string]interface{}{"$bos": "(sum"}, records[0].Item)
+	a.Equal(1.0, records[1].Item)
+	a.Equal(2.0, records[2].Item)
+	a.Equal(map[string]interface{}{"$eos": "(sum"}, records[3].Item)
+	a.Equal("sum)", records[4].Port)
+	a.Equal(3.0, records[4].Item)
+}
+
+func TestRecorder__UnknownDelegate(t *testing.T) {
+	a := assertions.New(t)
+	_, err := core.NewRecorder(sumTestOperator(t, 0), &bytes.Buffer{}, []string{"missing"})
+	a.Error(err)
+}
+
+func TestReplay__SameOutputs(t *testing.T) {
+	a := assertions.New(t)
+	data := recordSums(t, []interface{}{1.0, 2.0}, []interface{}{}, []interface{}{4.0})
+
+	o := sumTestOperator(t, 0)
+	o.Start()
+	res, err := core.Replay(o, bytes.NewReader(data), time.Second)
+	require.NoError(t, err)
+	a.Equal(3, res.Outputs)
+	a.Equal(9, res.Inputs)
+	a.Empty(res.Diffs)
+}
+
+func TestReplay__DiffersOutputs(t *testing.T) {
+	a := assertions.New(t)
+	data := recordSums(t, []interface{}{1.0, 2.0}, []interface{}{4.0})
+
+	// The changed operator adds one to each sum
+	o := sumTestOperator(t, 1)
+	o.Start()
+	res, err := core.Replay(o, bytes.NewReader(data), time.Second)
+	require.NoError(t, err)
+	a.Equal([]core.ReplayDiff{
+		{Port: "sum)", Index: 0, Expected: 3.0, Actual: 4.0},
+		{Port: "sum)", Index: 1, Expected: 4.0, Actual: 5.0},
+	}, res.Diffs)
+
+	_, err = core.Replay(o, bytes.NewReader([]byte(`{"port": "other)", "item": 1}`)), time.Second)
+	a.Error(err)
+}
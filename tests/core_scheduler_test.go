@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
+)
+
+// workersTestOperator returns an operator whose two workers race for the items pushed into it. Each worker tags the
+// items it forwards with its number.
+func workersTestOperator(t *testing.T, name string) *core.Operator {
+	o, err := core.NewOperator(name, func(op *core.Operator) {
+		in := op.Main().In()
+		out := op.Main().Out()
+		for w := 1; w <= 2; w++ {
+			w := w
+			op.Go(func() {
+				for {
+					i, _ := in.PullFloat64()
+					out.Push(i*10 + float64(w))
+				}
+			})
+		}
+		op.WaitForStop()
+	}, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: core.TypeDef{Type: "number"}, Out: core.TypeDef{Type: "number"}}}})
+	require.NoError(t, err)
+	return o
+}
+
+func runScheduled(t *testing.T, o *core.Operator, seed int64, n int) ([]interface{}, []string) {
+	s := core.NewScheduler(seed)
+	o.SetScheduler(s)
+	o.Main().Out().Bufferize()
+	o.Start()
+
+	for i := 1; i <= n; i++ {
+		o.Main().In().Push(float64(i))
+	}
+	out := pullN(o.Main().Out(), n)
+
+	require.NoError(t, o.HaltTimeout(time.Second))
+	return out, s.Trace()
+}
+
+// Scheduler (4 tests)
+
+func TestScheduler__SameSeedSameTrace(t *testing.T) {
+	a := assertions.New(t)
+
+	out1, trace1 := runScheduled(t, workersTestOperator(t, "workers"), 42, 20)
+	out2, trace2 := runScheduled(t, workersTestOperator(t, "workers"), 42, 20)
+
+	a.Equal(out1, out2)
+	a.Equal(trace1, trace2)
+	a.Contains(trace1, "workers#1")
+	a.Contains(trace1, "workers#2")
+	a.Contains(trace1, core.EXTERNAL_TASK)
+}
+
+func TestScheduler__SeedDecidesOrder(t *testing.T) {
+	a := assertions.New(t)
+
+	outs := make(map[string]bool)
+	for seed := int64(0); seed < 10; seed++ {
+		out, _ := runScheduled(t, workersTestOperator(t, "workers"), seed, 20)
+		a.Len(out, 20)
+		outs[fmt.Sprint(out)] = true
+	}
+	a.True(len(outs) > 1)
+}
+
+func TestScheduler__Children(t *testing.T) {
+	a := assertions.New(t)
+
+	numberDef := core.ServiceDef{In: core.TypeDef{Type: "number"}, Out: core.TypeDef{Type: "number"}}
+	o, err := core.NewOperator("", nil, nil, nil, nil, core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: &numberDef}})
+	require.NoError(t, err)
+
+	// The scheduler is inherited by operators added later on
+	o.SetScheduler(core.NewScheduler(7))
+	c1 := workersTestOperator(t, "first")
+	c1.SetParent(o)
+	c2 := workersTestOperator(t, "second")
+	c2.SetParent(o)
+	a.Equal(o.Scheduler(), c2.Scheduler())
+
+	require.NoError(t, o.Main().In().Connect(c1.Main().In()))
+	require.NoError(t, c1.Main().Out().Connect(c2.Main().In()))
+	require.NoError(t, c2.Main().Out().Connect(o.Main().Out()))
+
+	out1, trace1 := runScheduled(t, o, 7, 10)
+	a.Len(out1, 10)
+	for _, i := range out1 {
+		a.True(i.(float64) > 100)
+	}
+	a.Contains(trace1, "/first#1")
+	a.Contains(trace1, "/second#2")
+}
+
+func TestScheduler__StopUnwinds(t *testing.T) {
+	a := assertions.New(t)
+	o := workersTestOperator(t, "workers")
+	o.SetScheduler(core.NewScheduler(1))
+	o.Main().Out().Bufferize()
+	o.Start()
+
+	o.Main().In().Push(1.0)
+	a.NoError(o.HaltTimeout(time.Second))
+	a.Nil(o.Main().Out().Pull())
+}
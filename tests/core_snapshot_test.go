@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotOperator creates a parent operator with a child which is connected to its main service and runs f.
+func snapshotOperator(f core.OFunc) *core.Operator {
+	defPort := core.ParseTypeDef(`{"type":"stream","stream":{"type":"primitive"}}`)
+	bp := core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: defPort, Out: defPort}}}
+	oParent, _ := core.NewOperator("", nil, nil, nil, nil, bp)
+	oChild, _ := core.NewOperator("child", f, nil, nil, nil, bp)
+	oChild.SetParent(oParent)
+
+	oParent.Main().In().Connect(oChild.Main().In())
+	oChild.Main().Out().Connect(oParent.Main().Out())
+	oParent.Main().Out().Bufferize()
+
+	return oParent
+}
+
+func TestOperator_Snapshot__NotHalted(t *testing.T) {
+	a := assertions.New(t)
+	o := snapshotOperator(func(op *core.Operator) {
+		op.WaitForStop()
+	})
+
+	o.Start()
+	_, err := o.Snapshot()
+	a.Error(err)
+
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+	_, err = o.Snapshot()
+	a.NoError(err)
+}
+
+func TestOperator_Snapshot__BufferedItems(t *testing.T) {
+	a := assertions.New(t)
+	o := snapshotOperator(func(op *core.Operator) {
+		op.WaitForStop()
+	})
+
+	o.Start()
+	o.Main().In().Push([]interface{}{"a", core.Binary("b")})
+	o.Main().In().Push([]interface{}{})
+	require.NoError(t, o.HaltTimeout(100*time.Millisecond))
+
+	snap, err := o.Snapshot()
+	require.NoError(t, err)
+	a.Len(snap.Operators["child"].Buffers["~(child"], 6)
+
+	data, err := json.Marshal(snap)
+	require.NoError(t, err)
+	snap = &core.Snapshot{}
+	require.NoError(t, json.Unmarshal(data, snap))
+
+	o = snapshotOperator(func(op *core.Operator) {
+		for !op.CheckStop() {
+			op.Main().Out().Push(op.Main().In().Pull())
+		}
+	})
+	require.NoError(t, o.Restore(snap))
+
+	o.Start()
+	a.Equal([]interface{}{"a", core.Binary("b")}, o.Main().Out().Pull())
+	a.Equal([]interface{}{}, o.Main().Out().Pull())
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+}
+
+func TestOperator_Snapshot__FailureKeepsBuffers(t *testing.T) {
+	a := assertions.New(t)
+	o := snapshotOperator(func(op *core.Operator) {
+		op.WaitForStop()
+	})
+
+	// The state of the nested operator is captured after the buffers of its parent
+	bp := core.Blueprint{ServiceDefs: map[string]*core.ServiceDef{core.MAIN_SERVICE: {In: core.TypeDef{Type: "trigger"}, Out: core.TypeDef{Type: "trigger"}}}}
+	oInner, err := core.NewOperator("inner", nil, nil, nil, nil, bp)
+	require.NoError(t, err)
+	oInner.SetParent(o.Child("child"))
+	failing := true
+	oInner.OnSnapshot(func() (interface{}, error) {
+		if failing {
+			return nil, errors.New("not serializable")
+		}
+		return 1.0, nil
+	})
+
+	o.Start()
+	o.Main().In().Push([]interface{}{"a"})
+	require.NoError(t, o.HaltTimeout(100*time.Millisecond))
+
+	_, err = o.Snapshot()
+	a.EqualError(err, "child/inner: not serializable")
+
+	failing = false
+	snap, err := o.Snapshot()
+	require.NoError(t, err)
+	a.Len(snap.Operators["child"].Buffers["~(child"], 3)
+	a.Equal(json.RawMessage("1"), snap.Operators["child/inner"].State)
+}
+
+func TestOperator_Snapshot__State(t *testing.T) {
+	a := assertions.New(t)
+	counter := func(op *core.Operator) {
+		count := 0.0
+		op.RestoreState(&count)
+		op.OnSnapshot(func() (interface{}, error) {
+			return count, nil
+		})
+		for !op.CheckStop() {
+			i := op.Main().In().Pull()
+			if !core.IsMarker(i) {
+				count += float64(len(i.([]interface{})))
+				i = []interface{}{count}
+			}
+			op.Main().Out().Push(i)
+		}
+	}
+
+	o := snapshotOperator(counter)
+	o.Start()
+	o.Main().In().Push([]interface{}{1, 2})
+	a.Equal([]interface{}{2.0}, o.Main().Out().Pull())
+	require.NoError(t, o.HaltTimeout(100*time.Millisecond))
+
+	snap, err := o.Snapshot()
+	require.NoError(t, err)
+
+	o = snapshotOperator(counter)
+	require.NoError(t, o.Restore(snap))
+	o.Start()
+	o.Main().In().Push([]interface{}{3})
+	a.Equal([]interface{}{3.0}, o.Main().Out().Pull())
+	a.NoError(o.HaltTimeout(100 * time.Millisecond))
+}
+
+func TestOperator_Restore__UnknownOperator(t *testing.T) {
+	a := assertions.New(t)
+	o := snapshotOperator(func(op *core.Operator) {})
+
+	a.Error(o.Restore(&core.Snapshot{Operators: map[string]*core.OperatorSnapshot{"other": {}}}))
+}
+
+func TestEncodeItem__Scalars(t *testing.T) {
+	a := assertions.New(t)
+
+	item := map[string]interface{}{
+		"at":     time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC),
+		"after":  1500 * time.Millisecond,
+		"amount": core.NewDecimal(12.3),
+		"list":   []interface{}{core.Binary("abc"), "text", 1.0},
+	}
+
+	data, err := json.Marshal(core.EncodeItem(item))
+	require.NoError(t, err)
+
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	a.Equal(core.PlainItem(item), core.PlainItem(core.DecodeItem(decoded)))
+	a.IsType(time.Time{}, core.DecodeItem(decoded).(map[string]interface{})["at"])
+
+	a.Equal(map[string]interface{}{
+		"at":     "2021-03-04T05:06:07.000000008Z",
+		"after":  "1.5s",
+		"amount": "12.3",
+		"list":   []interface{}{core.Binary("abc"), "text", 1.0},
+	}, core.PlainItem(item))
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func typeRefLoader(bps ...core.Blueprint) func(uuid.UUID) (*core.Blueprint, error) {
+	return func(id uuid.UUID) (*core.Blueprint, error) {
+		for _, bp := range bps {
+			if bp.Id == id {
+				cpy := bp.Copy(true)
+				return &cpy, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown blueprint %s", id)
+	}
+}
+
+// ParseTypeRef (2 tests)
+
+func TestParseTypeRef(t *testing.T) {
+	a := assertions.New(t)
+	id := uuid.New()
+
+	refId, name, err := core.ParseTypeRef(id.String() + "#Point")
+	require.NoError(t, err)
+	a.Equal(id, refId)
+	a.Equal("Point", name)
+
+	refId, name, err = core.ParseTypeRef("#Point")
+	require.NoError(t, err)
+	a.Equal(uuid.Nil, refId)
+	a.Equal("Point", name)
+}
+
+func TestParseTypeRef__Invalid(t *testing.T) {
+	a := assertions.New(t)
+	for _, ref := range []string{"", "Point", "#", uuid.New().String() + "#", "abc#Point"} {
+		_, _, err := core.ParseTypeRef(ref)
+		a.Error(err, ref)
+	}
+}
+
+// Blueprint.ResolveTypes (5 tests)
+
+func TestBlueprint_ResolveTypes__Local(t *testing.T) {
+	a := assertions.New(t)
+	bp := core.Blueprint{
+		Id: uuid.New(),
+		Types: core.TypeDefMap{
+			"Point": {Type: "map", Map: core.TypeDefMap{"x": {Type: "number"}, "y": {Type: "number"}}},
+		},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "stream", Stream: &core.TypeDef{Type: "ref", Ref: "#Point"}},
+				Out: core.TypeDef{Type: "ref", Ref: "#Point", Nullable: true},
+			},
+		},
+	}
+	require.NoError(t, bp.Validate())
+	require.NoError(t, bp.ResolveTypes(typeRefLoader()))
+
+	a.Equal("stream[map{x: number, y: number}]", bp.ServiceDefs[core.MAIN_SERVICE].In.String())
+	a.Equal("map{x: number, y: number}?", bp.ServiceDefs[core.MAIN_SERVICE].Out.String())
+
+	// The declaration itself is left untouched
+	a.Equal("map{x: number, y: number}", bp.Types["Point"].String())
+}
+
+func TestBlueprint_ResolveTypes__OtherBlueprint(t *testing.T) {
+	a := assertions.New(t)
+	types := core.Blueprint{
+		Id: uuid.New(),
+		Types: core.TypeDefMap{
+			"Point":  {Type: "map", Map: core.TypeDefMap{"x": {Type: "ref", Ref: "#Coord"}}},
+			"Coord":  {Type: "number"},
+			"Unused": {Type: "string"},
+		},
+	}
+	bp := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {
+				In:  core.TypeDef{Type: "ref", Ref: types.Id.String() + "#Point"},
+				Out: core.TypeDef{Type: "trigger"},
+			},
+		},
+		PropertyDefs: core.PropertyMap{
+			"origin": {Type: "ref", Ref: types.Id.String() + "#Point", Optional: true},
+		},
+		InstanceDefs: core.InstanceDefList{
+			{Name: "a", Operator: uuid.New(), Generics: core.Generics{"itemType": {Type: "ref", Ref: types.Id.String() + "#Coord"}}},
+		},
+	}
+	a.Equal([]uuid.UUID{types.Id}, bp.TypeRefs())
+
+	require.NoError(t, bp.ResolveTypes(typeRefLoader(types)))
+	a.Equal("map{x: number}", bp.ServiceDefs[core.MAIN_SERVICE].In.String())
+	a.True(bp.PropertyDefs["origin"].Optional)
+	a.Equal("map{x: number}", bp.PropertyDefs["origin"].String())
+	a.Equal("number", bp.InstanceDefs[0].Generics["itemType"].String())
+	a.Empty(bp.TypeRefs())
+}
+
+func TestBlueprint_ResolveTypes__Cyclic(t *testing.T) {
+	a := assertions.New(t)
+	bp := core.Blueprint{
+		Id: uuid.New(),
+		Types: core.TypeDefMap{
+			"Tree": {Type: "map", Map: core.TypeDefMap{"children": {Type: "stream", Stream: &core.TypeDef{Type: "ref", Ref: "#Tree"}}}},
+		},
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {In: core.TypeDef{Type: "ref", Ref: "#Tree"}, Out: core.TypeDef{Type: "trigger"}},
+		},
+	}
+	a.Error(bp.ResolveTypes(typeRefLoader()))
+}
+
+func TestBlueprint_ResolveTypes__Unknown(t *testing.T) {
+	a := assertions.New(t)
+	bp := core.Blueprint{
+		Id: uuid.New(),
+		ServiceDefs: map[string]*core.ServiceDef{
+			core.MAIN_SERVICE: {In: core.TypeDef{Type: "ref", Ref: "#Point"}, Out: core.TypeDef{Type: "trigger"}},
+		},
+	}
+	a.Error(bp.ResolveTypes(typeRefLoader()))
+
+	bp.ServiceDefs[core.MAIN_SERVICE].In.Ref = uuid.New().String() + "#Point"
+	a.Error(bp.ResolveTypes(typeRefLoader()))
+}
+
+func TestBlueprint_Validate__Types(t *testing.T) {
+	a := assertions.New(t)
+	bp := core.Blueprint{
+		Id:    uuid.New(),
+		Types: core.TypeDefMap{"Point": {Type: "ref", Ref: "#Coord"}, "Coord": {Type: "number"}},
+	}
+	a.NoError(bp.Validate())
+
+	bp.Types["Point"].Ref = "Coord"
+	a.Error(bp.Validate())
+
+	bp.Types["Point"].Ref = "#Coord"
+	bp.Types["A#B"] = &core.TypeDef{Type: "number"}
+	a.Error(bp.Validate())
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Bitspark/slang/pkg/core"
+	"github.com/Bitspark/slang/tests/assertions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	a := assertions.New(t)
+
+	v, err := core.ParseVersion("v1.2.3-beta")
+	require.NoError(t, err)
+	a.Equal(core.Version{Major: 1, Minor: 2, Patch: 3, Pre: "beta"}, v)
+	a.Equal("1.2.3-beta", v.String())
+
+	for _, invalid := range []string{"", "1", "1.2", "1.2.x", "a.b.c", "1.2.3.4", "1.2.3-"} {
+		_, err := core.ParseVersion(invalid)
+		a.Error(err, invalid)
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	a := assertions.New(t)
+	parse := func(s string) core.Version {
+		v, err := core.ParseVersion(s)
+		require.NoError(t, err)
+		return v
+	}
+
+	a.Equal(0, parse("1.2.3").Compare(parse("1.2.3")))
+	a.Equal(-1, parse("1.2.3").Compare(parse("1.10.0")))
+	a.Equal(1, parse("2.0.0").Compare(parse("1.99.99")))
+	a.Equal(-1, parse("1.0.0-alpha").Compare(parse("1.0.0")))
+	a.Equal(-1, parse("1.0.0-alpha").Compare(parse("1.0.0-beta")))
+}
+
+func TestVersionRange_Contains(t *testing.T) {
+	a := assertions.New(t)
+
+	cases := map[string]map[string]bool{
+		"":               {"0.0.1": true, "3.4.5": true},
+		"*":              {"0.0.1": true, "3.4.5": true},
+		"1.2.3":          {"1.2.3": true, "1.2.4": false},
+		"1.2":            {"1.2.0": true, "1.2.9": true, "1.3.0": false},
+		"1.x":            {"1.0.0": true, "1.9.0": true, "2.0.0": false},
+		"^1.2.3":         {"1.2.3": true, "1.9.0": true, "1.2.2": false, "2.0.0": false},
+		"^0.2.3":         {"0.2.5": true, "0.3.0": false},
+		"^0.0.3":         {"0.0.3": true, "0.0.4": false},
+		"~1.2.3":         {"1.2.9": true, "1.3.0": false},
+		"~1":             {"1.5.0": true, "2.0.0": false},
+		">=1.0.0 <2.0.0": {"1.0.0": true, "1.5.0": true, "2.0.0": false, "0.9.0": false},
+		">1.2":           {"1.2.9": false, "1.3.0": true},
+		"<=1.2":          {"1.2.9": true, "1.3.0": false},
+		"^1.0.0 || ^3.0": {"1.1.0": true, "2.0.0": false, "3.2.1": true},
+	}
+
+	for rs, versions := range cases {
+		r, err := core.ParseVersionRange(rs)
+		require.NoError(t, err, rs)
+		for vs, expected := range versions {
+			v, err := core.ParseVersion(vs)
+			require.NoError(t, err)
+			a.Equal(expected, r.Contains(v), "%s contains %s", rs, vs)
+		}
+	}
+
+	r, _ := core.ParseVersionRange("^1.0.0")
+	a.False(r.ContainsString(""))
+	r, _ = core.ParseVersionRange("")
+	a.True(r.ContainsString(""))
+
+	for _, invalid := range []string{"^a", ">*", "1.2.3 || ", "1.2.3.4"} {
+		_, err := core.ParseVersionRange(invalid)
+		a.Error(err, invalid)
+	}
+}
+
+func TestInstanceDef_Validate__Version(t *testing.T) {
+	a := assertions.New(t)
+
+	_, err := validateJSONOperatorDef(`{
+		"id": "d1e020d6-4414-42e0-a5c5-dd6fda91754e",
+		"meta": {"version": "1.0"},
+		"services": {"main": {"in": {"type": "number"}, "out": {"type": "number"}}}
+	}`)
+	a.Error(err)
+
+	_, err = validateJSONOperatorDef(`{
+		"id": "d1e020d6-4414-42e0-a5c5-dd6fda91754e",
+		"meta": {"version": "1.0.0"},
+		"services": {"main": {"in": {"type": "number"}, "out": {"type": "number"}}},
+		"operators": {"a": {"operator": "37ccdc28-67b0-4bb1-8591-4e0e813e3ec1", "version": "^^1"}}
+	}`)
+	a.Error(err)
+
+	_, err = validateJSONOperatorDef(`{
+		"id": "d1e020d6-4414-42e0-a5c5-dd6fda91754e",
+		"meta": {"version": "1.0.0"},
+		"services": {"main": {"in": {"type": "number"}, "out": {"type": "number"}}},
+		"operators": {"a": {"operator": "37ccdc28-67b0-4bb1-8591-4e0e813e3ec1", "version": "^1"}}
+	}`)
+	a.NoError(err)
+}
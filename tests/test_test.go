@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/Bitspark/slang/pkg/elem"
 	"github.com/Bitspark/slang/tests/assertions"
 )
 
@@ -69,6 +70,17 @@ func TestOperator__MergeSort(t *testing.T) {
 	a.Equal(0, fails)
 }
 
+func TestOperator__MergeSortScheduled(t *testing.T) {
+	elem.Init()
+	a := assertions.New(t)
+	for seed := int64(1); seed <= 3; seed++ {
+		succs, fails, err := Test.RunTestBenchSeed("test_data/slib/merge_sort.yaml", seed, ioutil.Discard, true)
+		a.NoError(err)
+		a.Equal(5, succs)
+		a.Equal(0, fails)
+	}
+}
+
 func TestOperator_Properties(t *testing.T) {
 	a := assertions.New(t)
 	succs, fails, err := Test.RunTestBench("test_data/properties/prop_op.yaml", ioutil.Discard, true)
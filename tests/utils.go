@@ -166,6 +166,13 @@ func (t testEnv) RunTestBench(opFile string, writer io.Writer, failFast bool) (i
 	return tb.Run(opId, writer, failFast)
 }
 
+func (t testEnv) RunTestBenchSeed(opFile string, seed int64, writer io.Writer, failFast bool) (int, int, error) {
+	tb := api.NewTestBench(t.stor)
+	tb.SetSeed(seed)
+	opId := t.getUUIDFromFile(opFile)
+	return tb.Run(opId, writer, failFast)
+}
+
 func (t testEnv) CompileFile(opFile string, gens map[string]*core.TypeDef, props map[string]interface{}) (*core.Operator, error) {
 	return api.BuildAndCompile(t.getUUIDFromFile(opFile), gens, props, *st)
 }